@@ -0,0 +1,277 @@
+// Package config loads settings of the whole application
+// from environment and .env file into typed struct once at startup.
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/banderlog"
+	"github.com/delveper/mylib/lib/env"
+	"github.com/delveper/mylib/lib/tracer"
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	Server    Server
+	DB        DB
+	Session   Session
+	JWT       JWT
+	Books     Books
+	Log       banderlog.Config
+	Trace     Trace
+	OIDC      OIDC
+	RateLimit RateLimit
+}
+
+type Server struct {
+	Host         string        `env:"SRV_HOST"`
+	Port         string        `env:"SRV_PORT" default:"8080"`
+	ReadTimeout  time.Duration `env:"SRV_READ_TIMEOUT" default:"5s"`
+	WriteTimeout time.Duration `env:"SRV_WRITE_TIMEOUT" default:"10s"`
+	IdleTimeout  time.Duration `env:"SRV_IDLE_TIMEOUT" default:"1m"`
+	DrainTimeout time.Duration `env:"SRV_DRAIN_TIMEOUT" default:"15s"`
+	DrainDelay   time.Duration `env:"SRV_DRAIN_DELAY" default:"5s"`
+	TLSMode      string        `env:"SRV_TLS_MODE" default:"off"`
+	CertFile     string        `env:"SRV_CERT_FILE"`
+	KeyFile      string        `env:"SRV_KEY_FILE"`
+	Domains      []string      `env:"SRV_DOMAIN"`
+	CertDir      string        `env:"SRV_CERT_DIR"`
+	RedirectAddr string        `env:"SRV_REDIRECT_ADDR"`
+	TrustProxy   bool          `env:"SRV_TRUST_PROXY" default:"false"`
+}
+
+// Addr returns address server listens on.
+func (s Server) Addr() string {
+	return s.Host + ":" + s.Port
+}
+
+type DB struct {
+	Host     string `env:"DB_HOST" required:"true"`
+	Port     int    `env:"DB_EXPOSE_PORT" default:"5432"`
+	User     string `env:"DB_USER" required:"true"`
+	Password string `env:"DB_PASSWORD" secret:"true"`
+	Name     string `env:"DB_NAME" required:"true"`
+	SSLMode  string `env:"DB_SSL_MODE" default:"disable"`
+	Dialect  string `env:"DB_DIALECT" default:"pgx"`
+	Migrate  string `env:"DB_MIGRATE" default:"up"`
+}
+
+// DSN returns connection string in key/value format.
+func (d DB) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		d.Host, d.Port, d.User, d.Password, d.Name, d.SSLMode)
+}
+
+type Session struct {
+	Host     string `env:"SESSION_HOST" required:"true"`
+	Port     int    `env:"SESSION_PORT" default:"6379"`
+	Password string `env:"SESSION_PASSWORD" secret:"true"`
+}
+
+// Addr returns address of session repo.
+func (s Session) Addr() string {
+	return fmt.Sprintf("%s:%d", s.Host, s.Port)
+}
+
+// JWT configures tokens. Access tokens are not checked against session storage,
+// so that ClientAccessExp bounds how long access token of revoked grant is still accepted.
+type JWT struct {
+	Alg             string        `env:"JWT_ALG" default:"HS256"`
+	Key             string        `env:"JWT_KEY" required:"true" secret:"true"`
+	AccessExp       time.Duration `env:"JWT_ACCESS_EXP" default:"15m"`
+	ClientAccessExp time.Duration `env:"JWT_CLIENT_ACCESS_EXP" default:"5m"`
+	RefreshExp      time.Duration `env:"JWT_REFRESH_EXP" default:"720h"`
+}
+
+type Books struct {
+	MaxOnPage           int           `env:"BOOKS_MAX_ON_PAGE" default:"100"`
+	ExportTimeout       time.Duration `env:"BOOKS_EXPORT_TIMEOUT" default:"10m"`
+	ImportTimeout       time.Duration `env:"BOOKS_IMPORT_TIMEOUT" default:"30m"`
+	ImportUploadTimeout time.Duration `env:"BOOKS_IMPORT_UPLOAD_TIMEOUT" default:"5m"`
+	ImportMaxSize       int64         `env:"BOOKS_IMPORT_MAX_SIZE" default:"67108864"`
+	ImportSyncRows      int           `env:"BOOKS_IMPORT_SYNC_ROWS" default:"1000"`
+}
+
+type Trace struct {
+	Exporter string `env:"TRACE_EXPORTER" default:"none"`
+}
+
+type OIDC struct {
+	Issuer       string   `env:"OIDC_ISSUER"`
+	ClientID     string   `env:"OIDC_CLIENT_ID"`
+	ClientSecret string   `env:"OIDC_CLIENT_SECRET" secret:"true"`
+	RedirectURL  string   `env:"OIDC_REDIRECT_URL"`
+	Scopes       []string `env:"OIDC_SCOPES"`
+}
+
+// Enabled reports if external identity provider is configured.
+func (o OIDC) Enabled() bool {
+	return o.Issuer != ""
+}
+
+type RateLimit struct {
+	Store     string `env:"RATE_LIMIT_STORE" default:"memory"`
+	Anonymous string `env:"RATE_LIMIT_ANONYMOUS" default:"60/1m"`
+	Reader    string `env:"RATE_LIMIT_READER" default:"600/1m"`
+	Admin     string `env:"RATE_LIMIT_ADMIN" default:"1200/1m"`
+	Client    string `env:"RATE_LIMIT_CLIENT" default:"1200/1m"`
+	quotas    models.Quotas
+}
+
+// Quotas returns quotas parsed during validation.
+func (r RateLimit) Quotas() models.Quotas {
+	return r.quotas
+}
+
+// parseQuotas parses quotas of every caller class.
+func (r RateLimit) parseQuotas() (models.Quotas, error) {
+	raw := map[string]string{
+		models.CallerAnonymous: r.Anonymous,
+		models.CallerReader:    r.Reader,
+		models.CallerAdmin:     r.Admin,
+		models.CallerClient:    r.Client,
+	}
+
+	quotas := make(models.Quotas, len(raw))
+
+	for class, val := range raw {
+		quota, err := models.ParseQuota(val)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s quota: %w", class, err)
+		}
+
+		quotas[class] = quota
+	}
+
+	return quotas, nil
+}
+
+// Load reads .env file if there is one and parses environment into Config.
+func Load() (*Config, error) {
+	if err := env.LoadVars(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error loading environment file: %w", err)
+	}
+
+	var cfg Config
+	if err := env.Parse(&cfg); err != nil {
+		return nil, fmt.Errorf("error parsing environment: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// Validate checks values that depend on each other.
+func (cfg *Config) Validate() error {
+	var errs []string
+
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	check(cfg.Log.Validate())
+
+	switch cfg.Server.TLSMode {
+	case "off":
+	case "file":
+		if cfg.Server.CertFile == "" || cfg.Server.KeyFile == "" {
+			check(errors.New("SRV_CERT_FILE and SRV_KEY_FILE are required in file tls mode"))
+		}
+	case "acme":
+		if len(cfg.Server.Domains) == 0 {
+			check(errors.New("SRV_DOMAIN is required in acme tls mode"))
+		}
+	default:
+		check(fmt.Errorf("unknown tls mode: %q", cfg.Server.TLSMode))
+	}
+
+	if cfg.Server.ReadTimeout <= 0 || cfg.Server.WriteTimeout <= 0 || cfg.Server.IdleTimeout <= 0 {
+		check(errors.New("server timeouts must be positive"))
+	}
+
+	if cfg.Server.DrainDelay < 0 {
+		check(errors.New("SRV_DRAIN_DELAY must not be negative"))
+	}
+
+	switch cfg.DB.Migrate {
+	case "up", "down":
+	default:
+		check(fmt.Errorf("unknown migration direction: %q", cfg.DB.Migrate))
+	}
+
+	switch strings.ToUpper(cfg.JWT.Alg) {
+	case "HS256", "HS384", "HS512":
+	default:
+		check(fmt.Errorf("unsupported jwt algorithm: %q", cfg.JWT.Alg))
+	}
+
+	if cfg.JWT.AccessExp <= 0 || cfg.JWT.AccessExp >= cfg.JWT.RefreshExp {
+		check(errors.New("JWT_ACCESS_EXP must be positive and shorter than JWT_REFRESH_EXP"))
+	}
+
+	if cfg.JWT.ClientAccessExp <= 0 || cfg.JWT.ClientAccessExp > cfg.JWT.AccessExp {
+		check(errors.New("JWT_CLIENT_ACCESS_EXP must be positive and not longer than JWT_ACCESS_EXP"))
+	}
+
+	if cfg.Books.MaxOnPage <= 0 {
+		check(errors.New("BOOKS_MAX_ON_PAGE must be positive"))
+	}
+
+	if cfg.Books.ExportTimeout <= 0 || cfg.Books.ImportTimeout <= 0 || cfg.Books.ImportUploadTimeout <= 0 {
+		check(errors.New("BOOKS_EXPORT_TIMEOUT, BOOKS_IMPORT_TIMEOUT and BOOKS_IMPORT_UPLOAD_TIMEOUT must be positive"))
+	}
+
+	if cfg.Books.ImportMaxSize <= 0 {
+		check(errors.New("BOOKS_IMPORT_MAX_SIZE must be positive"))
+	}
+
+	switch cfg.Trace.Exporter {
+	case tracer.ExporterNone, tracer.ExporterStdout, tracer.ExporterOTLP:
+	default:
+		check(fmt.Errorf("unknown trace exporter: %q", cfg.Trace.Exporter))
+	}
+
+	if cfg.OIDC.Enabled() && (cfg.OIDC.ClientID == "" || cfg.OIDC.RedirectURL == "") {
+		check(errors.New("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required with OIDC_ISSUER"))
+	}
+
+	switch cfg.RateLimit.Store {
+	case "memory", "redis":
+	default:
+		check(fmt.Errorf("unknown rate limit store: %q", cfg.RateLimit.Store))
+	}
+
+	quotas, err := cfg.RateLimit.parseQuotas()
+	check(err)
+
+	cfg.RateLimit.quotas = quotas
+
+	for _, class := range []string{models.CallerAnonymous, models.CallerReader, models.CallerAdmin, models.CallerClient} {
+		if quota, ok := quotas[class]; ok {
+			if err := quota.CheckCosts(models.DefaultRouteCosts); err != nil {
+				check(fmt.Errorf("%s quota: %w", class, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// Print writes config as environment variables masking secrets.
+func (cfg *Config) Print(w io.Writer) error {
+	return env.Print(w, cfg)
+}
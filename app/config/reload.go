@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/env"
+	"github.com/pkg/errors"
+)
+
+// ErrNotReloadable is returned when reload changes settings that require restart.
+var ErrNotReloadable = errors.New("settings can not be reloaded without restart")
+
+// reloadable lists variables that take effect without restart.
+var reloadable = map[string]bool{
+	"LOG_LEVEL":                   true,
+	"BOOKS_MAX_ON_PAGE":           true,
+	"BOOKS_EXPORT_TIMEOUT":        true,
+	"BOOKS_IMPORT_TIMEOUT":        true,
+	"BOOKS_IMPORT_UPLOAD_TIMEOUT": true,
+	"BOOKS_IMPORT_MAX_SIZE":       true,
+	"BOOKS_IMPORT_SYNC_ROWS":      true,
+	"RATE_LIMIT_ANONYMOUS":        true,
+	"RATE_LIMIT_READER":           true,
+	"RATE_LIMIT_ADMIN":            true,
+	"RATE_LIMIT_CLIENT":           true,
+	"JWT_ACCESS_EXP":              true,
+	"JWT_CLIENT_ACCESS_EXP":       true,
+	"JWT_REFRESH_EXP":             true,
+}
+
+type Change = env.Change
+
+// Reloadable holds config swapped atomically on reload.
+// Values read through Current take effect on next read,
+// subscribers are notified about components that keep their own state, like logger level.
+type Reloadable struct {
+	cur    atomic.Pointer[Config]
+	mu     sync.Mutex
+	subs   []func(old, cur *Config)
+	load   func() (*Config, error)
+	logger models.Logger
+}
+
+func NewReloadable(cfg *Config, logger models.Logger) *Reloadable {
+	r := Reloadable{load: Load, logger: logger}
+	r.cur.Store(cfg)
+
+	return &r
+}
+
+// Current returns config in effect.
+func (r *Reloadable) Current() *Config {
+	return r.cur.Load()
+}
+
+// Subscribe registers fn called after every successful reload.
+func (r *Reloadable) Subscribe(fn func(old, cur *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subs = append(r.subs, fn)
+}
+
+// Reload reads config again and swaps it if only reloadable settings changed.
+// Source tells who triggered reload and is recorded in audit log.
+func (r *Reloadable) Reload(source string) ([]Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := r.load()
+	if err != nil {
+		r.logger.Warnw("Config reload failed.", "source", source, "error", err)
+		return nil, fmt.Errorf("%w: %w", exceptions.ErrValidation, err)
+	}
+
+	old := r.cur.Load()
+	changes := env.Diff(old, cfg)
+
+	var rejected []string
+
+	for _, c := range changes {
+		if !reloadable[c.Key] {
+			rejected = append(rejected, c.Key)
+		}
+	}
+
+	if len(rejected) > 0 {
+		err := fmt.Errorf("%w: %s", ErrNotReloadable, strings.Join(rejected, ", "))
+		r.logger.Warnw("Config reload rejected.", "source", source, "error", err)
+
+		return nil, err
+	}
+
+	r.cur.Store(cfg)
+
+	for _, c := range changes {
+		r.logger.Infow("Config changed.", "source", source, "key", c.Key, "old", c.Old, "new", c.New)
+	}
+
+	for _, fn := range r.subs {
+		fn(old, cfg)
+	}
+
+	r.logger.Infow("Config reloaded.", "source", source, "changes", len(changes))
+
+	return changes, nil
+}
@@ -4,7 +4,8 @@ import "errors"
 
 var ErrValidation = errors.New("validation error")
 var ErrDuplicateEmail = errors.New("email is already taken")
-var ErrDuplicateTitle = errors.New("book with same title is exist")
+var ErrDuplicateEdition = errors.New("book with same edition is exist")
+var ErrDuplicateISBN = errors.New("book with same isbn is exist")
 var ErrDuplicateID = errors.New("id already exists")
 
 var ErrRecordNotFound = errors.New("record not found")
@@ -22,3 +23,14 @@ var ErrDeadline = errors.New("deadline exceeded")
 var ErrHashing = errors.New("error hashing")
 var ErrComparingHash = errors.New("error comparing hash")
 var ErrRecordExists = errors.New("record already exists")
+var ErrImportInterrupted = errors.New("import interrupted")
+
+var ErrClientNotFound = errors.New("client not found")
+var ErrInvalidClient = errors.New("invalid client")
+var ErrInvalidGrant = errors.New("invalid grant")
+var ErrUnsupportedGrant = errors.New("unsupported grant type")
+var ErrInvalidScope = errors.New("invalid scope")
+var ErrInvalidRequest = errors.New("invalid request")
+var ErrAccessDenied = errors.New("access denied")
+var ErrInvalidState = errors.New("invalid or expired state")
+var ErrIdentityNotVerified = errors.New("external identity is not verified")
@@ -0,0 +1,50 @@
+package exceptions
+
+import "strings"
+
+// FieldError describes single invalid field of request body.
+// Pointer locates field in request document as RFC 6901 defines.
+type FieldError struct {
+	Field   string `json:"field"`
+	Pointer string `json:"pointer"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every invalid field, so that all of them are reported at once.
+// It matches ErrValidation, so callers not interested in fields can keep using errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Add records field failed rule. Field is JSON name of field, nested ones are joined with slash.
+func (e *ValidationError) Add(field, rule, message string) {
+	e.Fields = append(e.Fields, FieldError{
+		Field:   field,
+		Pointer: "/" + strings.NewReplacer("~", "~0").Replace(field),
+		Rule:    rule,
+		Message: message,
+	})
+}
+
+// Err returns nil if no field was added, so result can be returned from validation directly.
+func (e *ValidationError) Err() error {
+	if e == nil || len(e.Fields) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+
+	return ErrValidation.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
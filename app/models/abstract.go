@@ -4,7 +4,7 @@ package models
 type Logger interface {
 	Flush() error
 	Level() string
-	With(...any)
+	With(...any) Logger
 	Debugf(string, ...any)
 	Debugw(string, ...any)
 	Infof(string, ...any)
@@ -20,3 +20,9 @@ type Logger interface {
 	Println(...any)
 	Printf(string, ...any)
 }
+
+// Metrics is designed for counting business events.
+type Metrics interface {
+	Inc(event string)
+	Add(event string, n int)
+}
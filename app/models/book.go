@@ -2,26 +2,95 @@ package models
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/delveper/mylib/app/exceptions"
-	"github.com/delveper/revalid"
+	"github.com/delveper/mylib/lib/marc"
 )
 
+// maxRate is upper bound of book rate.
+const maxRate = 10
+
+// Book is edition of work. Book added without work is edition of work of the same author and title,
+// new work is created if there is none, so work has to be given for volumes of series sharing title.
 type Book struct {
-	ID       string `json:"id" sql:"id"`
-	AuthorID string `json:"author_id" sql:"author_id" regex:"(?i)^[0-9a-f]{8}\b-[0-9a-f]{4}\b-[0-9a-f]{4}\b-[0-9a-f]{4}\b-[0-9a-f]{12}$"`
-	Title    string `json:"title" sql:"title" regex:"^[[:graph:]]{1,256}$"`
-	// TODO: Add ISBN
-	Genre string `json:"genre" sql:"genre" regex:"^[[:graph:]]{1,256}$"`
-	Rate  int    `json:"rate" sql:"rate" regex:"^([[:digit:]]|10)$"`
-	Size  int    `json:"size" sql:"size" regex:"^[[:digit:]]{1,256}$"`
-	Year  int    `json:"year" sql:"year" regex:"^[[:digit:]]{4}$"`
+	ID        string `json:"id" sql:"id"`
+	WorkID    string `json:"work_id,omitempty" sql:"work_id" regex:"(?i)^([0-9a-f]{8}\\b-[0-9a-f]{4}\\b-[0-9a-f]{4}\\b-[0-9a-f]{4}\\b-[0-9a-f]{12})?$"`
+	AuthorID  string `json:"author_id" sql:"author_id" regex:"(?i)^[0-9a-f]{8}\\b-[0-9a-f]{4}\\b-[0-9a-f]{4}\\b-[0-9a-f]{4}\\b-[0-9a-f]{12}$"`
+	Title     string `json:"title" sql:"title" regex:"^[^\\p{C}]{1,256}$"`
+	ISBN      string `json:"isbn,omitempty" sql:"isbn"`
+	Genre     string `json:"genre" sql:"genre" regex:"^[^\\p{C}]{1,256}$"`
+	Rate      int    `json:"rate" sql:"rate"`
+	Size      int    `json:"size" sql:"size" regex:"^[[:digit:]]{1,256}$"`
+	Year      int    `json:"year" sql:"year" regex:"^[[:digit:]]{4}$"`
+	Publisher string `json:"publisher,omitempty" sql:"publisher" regex:"^[^\\p{C}]{0,256}$"`
+	// Language is ISO 639 code of language of edition, e.g. "en" or "ukr".
+	Language string   `json:"language,omitempty" sql:"language" regex:"^([a-z]{2,3})?$"`
+	Format   string   `json:"format,omitempty" sql:"format"`
+	Subjects []string `json:"subjects,omitempty" sql:"subjects"`
+	// Covers are IDs of cover images of Open Library.
+	Covers []int `json:"covers,omitempty" sql:"covers"`
+	// MARC keeps fields of imported MARC record that are not mapped to book.
+	MARC *marc.Record `json:"-" sql:"marc"`
+}
+
+func (b *Book) Normalize() {
+	b.WorkID = strings.ToLower(strings.TrimSpace(b.WorkID))
+	b.AuthorID = strings.ToLower(strings.TrimSpace(b.AuthorID))
+	b.Title = strings.TrimSpace(b.Title)
+	b.Genre = strings.TrimSpace(b.Genre)
+	b.ISBN = NormalizeISBN(b.ISBN)
+	b.Publisher = strings.TrimSpace(b.Publisher)
+	b.Language = strings.ToLower(strings.TrimSpace(b.Language))
+	b.Format = strings.ToLower(strings.TrimSpace(b.Format))
+
+	subjects := b.Subjects[:0]
+
+	for _, subject := range b.Subjects {
+		if subject = strings.TrimSpace(subject); subject != "" {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	b.Subjects = subjects
+}
+
+// Edition tells apart editions without ISBN as unique constraint of catalog does.
+// Work is told by its ID if book is given one, by author and title otherwise.
+func (b Book) Edition() string {
+	work := b.WorkID
+	if work == "" {
+		work = b.AuthorID + "\x00" + b.Title
+	}
+
+	return strings.Join([]string{work, b.Publisher, strconv.Itoa(b.Year), b.Language, b.Format}, "\x00")
 }
 
 func (b *Book) OK() error {
-	if err := revalid.ValidateStruct(b); err != nil {
-		return fmt.Errorf("%w: %w", exceptions.ErrValidation, err)
+	vErr := validate(b)
+
+	if b.Rate < 0 || b.Rate > maxRate {
+		vErr.Add("rate", "range", "must be between 0 and 10")
+	}
+
+	if b.Year > time.Now().Year() {
+		vErr.Add("year", "not_future", "must not be in the future")
+	}
+
+	if b.ISBN != "" && !ValidISBN(b.ISBN) {
+		vErr.Add("isbn", "isbn", "must be valid ISBN-10 or ISBN-13")
+	}
+
+	if b.Format != "" && !formats[b.Format] {
+		vErr.Add("format", "enum", "must be one of hardcover, paperback, ebook or audiobook")
+	}
+
+	for i, subject := range b.Subjects {
+		if len(subject) > 256 {
+			vErr.Add(fmt.Sprintf("subjects/%d", i), "pattern", "must be at most 256 characters")
+		}
 	}
 
-	return nil
+	return vErr.Err()
 }
@@ -0,0 +1,37 @@
+package models
+
+import "github.com/delveper/mylib/lib/cite"
+
+// Citation returns book as citation entry. Place of publisher is known only for books
+// that kept publication field of imported MARC record, publisher is taken from it as well.
+func (r BookRecord) Citation() cite.Entry {
+	entry := cite.Entry{
+		ID:        r.ID,
+		Title:     r.Title,
+		Year:      r.Year,
+		ISBN:      r.ISBN,
+		Publisher: r.Publisher,
+		Pages:     r.Size,
+		Subjects:  r.Subjects,
+	}
+
+	if r.Author.LastName != "" {
+		entry.Authors = []cite.Name{{Given: r.Author.FirstName, Family: r.Author.LastName}}
+	}
+
+	if r.Book.MARC != nil {
+		for _, tag := range []string{tagPublication, tagImprint} {
+			if f, ok := r.Book.MARC.First(tag); ok {
+				entry.Place = trimISBD(f.Subfield('a'))
+
+				if publisher := trimISBD(f.Subfield('b')); publisher != "" {
+					entry.Publisher = publisher
+				}
+
+				break
+			}
+		}
+	}
+
+	return entry
+}
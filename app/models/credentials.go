@@ -1,15 +1,11 @@
 package models
 
 import (
-	"fmt"
 	"strings"
-
-	"github.com/delveper/mylib/app/exceptions"
-	"github.com/delveper/revalid"
 )
 
 type Credentials struct {
-	Email    string `json:"email" regex:"(?i)(^[a-z0-9_.+-]+@[a-z0-9-]+\.[a-z0-9-.]+$)"`
+	Email    string `json:"email" regex:"(?i)(^[a-z0-9_.+-]+@[a-z0-9-]+\\.[a-z0-9-.]+$)"`
 	Password string `json:"password" regex:"^[[:graph:]]{8,256}$"`
 }
 
@@ -18,9 +14,5 @@ func (c *Credentials) Normalize() {
 }
 
 func (c *Credentials) OK() error {
-	if err := revalid.ValidateStruct(c); err != nil {
-		return fmt.Errorf("%w: %w", exceptions.ErrValidation, err)
-	}
-
-	return nil
+	return validate(c).Err()
 }
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/delveper/mylib/lib/openlib"
+)
+
+// Methods of matching book to edition of bibliographic dump, from the most reliable one.
+const (
+	MatchISBN        = "isbn"
+	MatchTitleAuthor = "title_author"
+	MatchTitle       = "title"
+)
+
+// Statuses of match review.
+const (
+	MatchPending  = "pending"
+	MatchAccepted = "accepted"
+	MatchRejected = "rejected"
+)
+
+// SourceOpenLibrary names Open Library data dumps as source of matches.
+const SourceOpenLibrary = "openlibrary"
+
+// BookMatch records edition of bibliographic dump matched to book, so that librarian can review it.
+// Fields holds values found for fields book is missing, Applied tells whether they were filled in,
+// which is done only for matches confident enough.
+type BookMatch struct {
+	ID         string     `json:"id"`
+	BookID     string     `json:"book_id"`
+	Source     string     `json:"source"`
+	EditionKey string     `json:"edition_key"`
+	WorkKey    string     `json:"work_key,omitempty"`
+	Method     string     `json:"method"`
+	Confidence float64    `json:"confidence"`
+	Fields     Enrichment `json:"fields"`
+	Applied    bool       `json:"applied"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Enrichment holds values of fields that can be filled in from bibliographic data.
+type Enrichment struct {
+	Size     int      `json:"size,omitempty"`
+	Year     int      `json:"year,omitempty"`
+	Subjects []string `json:"subjects,omitempty"`
+	Covers   []int    `json:"covers,omitempty"`
+}
+
+// EnrichOptions tells which matches are applied.
+// Matches below MinConfidence are only recorded, dry run records nothing.
+type EnrichOptions struct {
+	MinConfidence float64 `json:"min_confidence"`
+	DryRun        bool    `json:"dry_run"`
+}
+
+// EnrichReport sums up enrichment, Incomplete is number of books missing any field.
+// Skipped is number of dump records that could not be decoded.
+type EnrichReport struct {
+	Incomplete int            `json:"incomplete"`
+	Skipped    int            `json:"skipped"`
+	Matched    int            `json:"matched"`
+	Applied    int            `json:"applied"`
+	Methods    map[string]int `json:"methods"`
+}
+
+// Gaps returns values of found that fill fields book is missing.
+func (b Book) Gaps(found Enrichment) Enrichment {
+	var gaps Enrichment
+
+	if b.Size == 0 {
+		gaps.Size = found.Size
+	}
+
+	if b.Year == 0 {
+		gaps.Year = found.Year
+	}
+
+	if len(b.Subjects) == 0 {
+		gaps.Subjects = found.Subjects
+	}
+
+	if len(b.Covers) == 0 {
+		gaps.Covers = found.Covers
+	}
+
+	return gaps
+}
+
+// Count returns number of fields having value.
+func (e Enrichment) Count() int {
+	var n int
+
+	for _, ok := range []bool{e.Size != 0, e.Year != 0, len(e.Subjects) != 0, len(e.Covers) != 0} {
+		if ok {
+			n++
+		}
+	}
+
+	return n
+}
+
+// EnrichmentFromOpenLibrary maps edition to values it can fill in, subjects and covers
+// are taken from work of edition if edition has none. Work is nil if it is unknown.
+func EnrichmentFromOpenLibrary(ed openlib.Edition, work *openlib.Work) Enrichment {
+	found := Enrichment{
+		Size:     ed.Pages,
+		Subjects: olSubjects(ed.Subjects),
+		Covers:   olCovers(ed.Covers),
+	}
+
+	if year, err := strconv.Atoi(yearPattern.FindString(ed.PublishDate)); err == nil && year <= time.Now().Year() {
+		found.Year = year
+	}
+
+	if work != nil {
+		if len(found.Subjects) == 0 {
+			found.Subjects = olSubjects(work.Subjects)
+		}
+
+		if len(found.Covers) == 0 {
+			found.Covers = olCovers(work.Covers)
+		}
+	}
+
+	return found
+}
+
+// olSubjects drops blank subjects and ones too long to be valid.
+func olSubjects(list []string) []string {
+	var subjects []string
+
+	for _, subject := range list {
+		if subject = strings.TrimSpace(subject); subject != "" && len(subject) <= 256 {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	return subjects
+}
+
+// olCovers drops placeholders of removed covers, which are negative.
+func olCovers(list []int) []int {
+	var covers []int
+
+	for _, id := range list {
+		if id > 0 {
+			covers = append(covers, id)
+		}
+	}
+
+	return covers
+}
+
+// articles are dropped from start of normalized title.
+var articles = map[string]bool{"the": true, "a": true, "an": true}
+
+// NormalizeTitle folds title for matching: case and punctuation are ignored,
+// as well as leading article, so that "The Hobbit, or There and Back Again" matches "hobbit or there and back again".
+func NormalizeTitle(title string) string {
+	words := foldWords(title)
+
+	if len(words) > 1 && articles[words[0]] {
+		words = words[1:]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// NormalizeName folds personal name for matching the same way as title.
+func NormalizeName(name string) string {
+	return strings.Join(foldWords(name), " ")
+}
+
+func foldWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,13 @@
+package models
+
+// Business events counted by Metrics.
+const (
+	EventSignUp            = "signup"
+	EventLoginSucceeded    = "login_succeeded"
+	EventLoginFailed       = "login_failed"
+	EventBookImported      = "book_imported"
+	EventBooksBulkImported = "books_bulk_imported" // counts books, not imports
+	EventBooksExported     = "books_exported"
+	EventFavoriteAdded     = "favorite_added"
+	EventWishlistAdded     = "wishlist_added"
+)
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Query options of export in addition to ones of DataFilter.
+const (
+	OptionSelect = "$select"
+	OptionFormat = "$format"
+)
+
+// BookColumns lists columns of exported books in default order.
+var BookColumns = []string{"id", "work_id", "author_id", "author", "title", "isbn", "genre", "rate", "size", "year",
+	"publisher", "language", "format"}
+
+// BookRecord is book as it is exported, with author resolved.
+type BookRecord struct {
+	Book
+	Author Author
+}
+
+// BookExport describes which books are exported, in which format and which columns.
+type BookExport struct {
+	Filter  DataFilter
+	Format  string
+	Columns []string
+}
+
+// ParseBookColumns parses comma separated list of columns as in OData $select.
+// Empty list selects all columns.
+func ParseBookColumns(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return BookColumns, nil
+	}
+
+	known := make(map[string]bool, len(BookColumns))
+	for _, col := range BookColumns {
+		known[col] = true
+	}
+
+	var cols []string
+
+	for _, col := range strings.Split(raw, ",") {
+		col = strings.ToLower(strings.TrimSpace(col))
+		if !known[col] {
+			return nil, fmt.Errorf("unknown column %q", col)
+		}
+
+		cols = append(cols, col)
+	}
+
+	return cols, nil
+}
+
+// Value returns value of column listed in BookColumns.
+func (r BookRecord) Value(column string) any {
+	switch column {
+	case "id":
+		return r.ID
+	case "author_id":
+		return r.AuthorID
+	case "author":
+		return r.Author.FullName()
+	case "title":
+		return r.Title
+	case "isbn":
+		return r.ISBN
+	case "genre":
+		return r.Genre
+	case "rate":
+		return r.Rate
+	case "size":
+		return r.Size
+	case "year":
+		return r.Year
+	case "work_id":
+		return r.WorkID
+	case "publisher":
+		return r.Publisher
+	case "language":
+		return r.Language
+	case "format":
+		return r.Format
+	default:
+		return nil
+	}
+}
@@ -14,7 +14,8 @@ import (
 // DataFilter represents a set of [OData](https://www.odata.org/getting-started/basic-tutorial/#queryData)
 // query options to filter and sort data.
 // It supports the following query options:
-//   - $filter: optional parameter that represents a filter operation with operations: 'and', 'or', 'eq', 'ne', 'gt', 'lt', 'ge', 'le'.
+//   - $filter: optional parameter that represents a filter operation with operations: 'and', 'or', 'eq', 'ne', 'gt', 'lt', 'ge', 'le',
+//     and 'under' matching genre, given by its name or synonym, or any genre beneath it, e.g. Genre under 'Fiction'.
 //   - $orderby: optional parameter that represents a sorting column with operators: 'asc' and 'desc'.
 //   - $top: optional parameter that represents a limit of items from the resource.
 //   - $skip: optional parameter that represents an offset of records in the resource.
@@ -57,6 +58,12 @@ const (
 
 const defaultTagName = "sql"
 
+// OperatorUnder is operator of FilterNode matching genre or any genre beneath it in taxonomy.
+const OperatorUnder = "UNDER"
+
+// genreField is the only field OperatorUnder applies to.
+const genreField = "genre"
+
 // NewDataFilter creates a new instance of *DataFilter of struct type T
 // based on the OData query options present in the specified URL.
 // The input of the OData query options will be validated during the process.
@@ -208,12 +215,13 @@ func parseFilter(u *url.URL, fieldMap fieldData) (*Filter, error) {
 	}
 
 	operMap := map[string]string{
-		"eq": "=",
-		"ne": "!=",
-		"gt": ">",
-		"lt": "<",
-		"le": "<=",
-		"ge": ">=",
+		"eq":    "=",
+		"ne":    "!=",
+		"gt":    ">",
+		"lt":    "<",
+		"le":    "<=",
+		"ge":    ">=",
+		"under": OperatorUnder,
 	}
 
 	conjMap := map[string]string{
@@ -270,6 +278,10 @@ func parseFilter(u *url.URL, fieldMap fieldData) (*Filter, error) {
 			}
 		}
 
+		if node.Operator == OperatorUnder && (node.Field != genreField || !strings.HasPrefix(node.Value, "'")) {
+			return nil, fmt.Errorf("operator under applies to genre name only, got: %s %s", node.Field, node.Value)
+		}
+
 		f.insert(&node)
 	}
 
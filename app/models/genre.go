@@ -0,0 +1,86 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Genre is node of genre taxonomy, genre of book is always name of some genre.
+// Synonyms are other names of genre curated by admins, e.g. "SciFi" for "Science Fiction".
+// Books counts books of genre itself, not of genres beneath it.
+type Genre struct {
+	ID       string   `json:"id"`
+	ParentID string   `json:"parent_id,omitempty" regex:"(?i)^([0-9a-f]{8}\\b-[0-9a-f]{4}\\b-[0-9a-f]{4}\\b-[0-9a-f]{4}\\b-[0-9a-f]{12})?$"`
+	Name     string   `json:"name" regex:"^[^\\p{C}]{1,256}$"`
+	Synonyms []string `json:"synonyms,omitempty"`
+	Books    int      `json:"books"`
+	Children []*Genre `json:"children,omitempty"`
+}
+
+// GenreSynonym is other name of genre. If synonym is name of another genre,
+// that genre is merged into GenreID, so that both are the same genre.
+type GenreSynonym struct {
+	GenreID string `json:"-"`
+	Name    string `json:"name" regex:"^[^\\p{C}]{1,256}$"`
+}
+
+func (g *Genre) Normalize() {
+	g.ParentID = strings.ToLower(strings.TrimSpace(g.ParentID))
+	g.Name = strings.TrimSpace(g.Name)
+}
+
+func (g *Genre) OK() error {
+	vErr := validate(g)
+
+	if g.Name != "" && !hasAlnum(g.Name) {
+		vErr.Add("name", "pattern", "must contain letter or digit")
+	}
+
+	return vErr.Err()
+}
+
+func (s *GenreSynonym) Normalize() {
+	s.Name = strings.TrimSpace(s.Name)
+}
+
+func (s *GenreSynonym) OK() error {
+	vErr := validate(s)
+
+	if s.Name != "" && !hasAlnum(s.Name) {
+		vErr.Add("name", "pattern", "must contain letter or digit")
+	}
+
+	return vErr.Err()
+}
+
+// GenreTree nests genres under their parents keeping order of list, top level genres are returned.
+func GenreTree(list []Genre) []*Genre {
+	nodes := make(map[string]*Genre, len(list))
+
+	for i := range list {
+		nodes[list[i].ID] = &list[i]
+	}
+
+	roots := make([]*Genre, 0)
+
+	for i := range list {
+		genre := &list[i]
+
+		parent, ok := nodes[genre.ParentID]
+		if !ok {
+			roots = append(roots, genre)
+			continue
+		}
+
+		parent.Children = append(parent.Children, genre)
+	}
+
+	return roots
+}
+
+// hasAlnum tells whether name has letter or digit, names are told apart by them only.
+func hasAlnum(name string) bool {
+	return strings.IndexFunc(name, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
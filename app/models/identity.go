@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Identity links reader to account of external identity provider.
+type Identity struct {
+	ReaderID  string    `json:"reader_id"`
+	Issuer    string    `json:"issuer"`
+	Subject   string    `json:"subject"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	"github.com/delveper/mylib/app/exceptions"
+)
+
+// Policies of bulk import.
+const (
+	// ImportAtomic imports nothing if any row fails.
+	ImportAtomic = "all_or_nothing"
+	// ImportBestEffort imports every valid row and reports the rest.
+	ImportBestEffort = "best_effort"
+)
+
+// Statuses of import job.
+const (
+	ImportPending = "pending"
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+// ImportRow is book parsed from line of imported file, or from record of file that has no lines.
+// Author is given by formats naming author instead of Book.AuthorID, such author is looked up by name
+// and added if missing. Replace is set by feeds resending books on every change, such row updates
+// book having the same ISBN instead of being rejected as duplicate.
+// Err is set if row could not be parsed, such row is reported without further checks.
+type ImportRow struct {
+	Line    int
+	Book    Book
+	Author  Author
+	Replace bool
+	Err     error
+}
+
+// ImportOptions tells how rows are imported.
+type ImportOptions struct {
+	Policy string `json:"policy"`
+	DryRun bool   `json:"dry_run"`
+}
+
+// ImportRowError reports why row was not imported.
+type ImportRowError struct {
+	Line    int                     `json:"line"`
+	Message string                  `json:"message"`
+	Fields  []exceptions.FieldError `json:"fields,omitempty"`
+}
+
+// ImportReport sums up bulk import. Imported is zero for dry run,
+// Valid tells how many rows would have been imported then.
+// Updated tells how many of imported rows replaced existing books.
+type ImportReport struct {
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Imported int              `json:"imported"`
+	Updated  int              `json:"updated"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// ImportJob tracks bulk import running in background.
+type ImportJob struct {
+	ID         string        `json:"id"`
+	CreatedBy  string        `json:"created_by"`
+	Status     string        `json:"status"`
+	Options    ImportOptions `json:"options"`
+	Report     ImportReport  `json:"report"`
+	Message    string        `json:"message,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+}
+
+// OK checks options, missing policy defaults to ImportAtomic.
+func (o *ImportOptions) OK() error {
+	var vErr exceptions.ValidationError
+
+	switch o.Policy {
+	case "":
+		o.Policy = ImportAtomic
+	case ImportAtomic, ImportBestEffort:
+	default:
+		vErr.Add("policy", "enum", "must be one of: "+ImportAtomic+", "+ImportBestEffort)
+	}
+
+	return vErr.Err()
+}
@@ -0,0 +1,93 @@
+package models
+
+import "strings"
+
+// NormalizeISBN strips hyphens and spaces and upper-cases ISBN-10 check digit.
+func NormalizeISBN(isbn string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn)))
+}
+
+// ValidISBN checks length and check digit of normalized ISBN-10 or ISBN-13.
+func ValidISBN(isbn string) bool {
+	switch len(isbn) {
+	case 10:
+		return validISBN10(isbn)
+	case 13:
+		return validISBN13(isbn)
+	default:
+		return false
+	}
+}
+
+// validISBN10 checks that weighted sum 10*d1 + 9*d2 + ... + 1*d10 is divisible by 11,
+// last digit may be X standing for 10.
+func validISBN10(isbn string) bool {
+	var sum int
+
+	for i := 0; i < 10; i++ {
+		c := isbn[i]
+
+		var d int
+
+		switch {
+		case c >= '0' && c <= '9':
+			d = int(c - '0')
+		case c == 'X' && i == 9:
+			d = 10
+		default:
+			return false
+		}
+
+		sum += (10 - i) * d
+	}
+
+	return sum%11 == 0
+}
+
+// validISBN13 checks EAN-13 checksum with alternating weights 1 and 3.
+func validISBN13(isbn string) bool {
+	if !strings.HasPrefix(isbn, "978") && !strings.HasPrefix(isbn, "979") {
+		return false
+	}
+
+	var sum int
+
+	for i := 0; i < 13; i++ {
+		c := isbn[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+
+		sum += d
+	}
+
+	return sum%10 == 0
+}
+
+// ISBN13 converts valid normalized ISBN-10 to ISBN-13, so that both forms of the same ISBN compare equal.
+// Any other value is returned as is.
+func ISBN13(isbn string) string {
+	if len(isbn) != 10 || !validISBN10(isbn) {
+		return isbn
+	}
+
+	isbn = "978" + isbn[:9]
+
+	var sum int
+
+	for i := 0; i < 12; i++ {
+		d := int(isbn[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+
+		sum += d
+	}
+
+	return isbn + string(rune('0'+(10-sum%10)%10))
+}
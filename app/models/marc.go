@@ -0,0 +1,205 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/delveper/mylib/lib/marc"
+)
+
+// MARC 21 tags mapped to book and author.
+const (
+	tagControlNumber = "001"
+	tagFixedData     = "008"
+	tagISBN          = "020"
+	tagAuthor        = "100"
+	tagTitle         = "245"
+	tagImprint       = "260"
+	tagPublication   = "264"
+	tagExtent        = "300"
+	tagSubject       = "650"
+	tagGenre         = "655"
+)
+
+// subjectSeparator joins subdivisions of subject heading.
+const subjectSeparator = " -- "
+
+var (
+	yearPattern  = regexp.MustCompile(`\d{4}`)
+	pagesPattern = regexp.MustCompile(`(\d+)\s*p`)
+)
+
+// BookFromMARC maps bibliographic record to book and its author.
+// ISBN, author, title and genre are taken from first field of its tag, subjects from all of them,
+// the rest of fields is kept in Book.MARC, so that record can be exported back whole.
+// Year and number of pages are read from publication and physical description, which are kept as well.
+func BookFromMARC(rec *marc.Record) (Book, Author) {
+	var (
+		book   Book
+		author Author
+		used   = make(map[string]bool)
+	)
+
+	extra := marc.Record{Leader: rec.Leader}
+
+	for _, f := range rec.Fields {
+		switch {
+		case f.Tag == tagISBN && !used[f.Tag] && f.Subfield('a') != "":
+			// ISBN is often followed by qualifier, e.g. "0261102214 (pbk.)".
+			book.ISBN, _, _ = strings.Cut(f.Subfield('a'), " ")
+		case f.Tag == tagAuthor && !used[f.Tag]:
+			author = authorFromName(f.Subfield('a'))
+		case f.Tag == tagTitle && !used[f.Tag]:
+			book.Title = trimISBD(f.Subfield('a'))
+			if sub := trimISBD(f.Subfield('b')); sub != "" {
+				book.Title += ": " + sub
+			}
+		case f.Tag == tagSubject:
+			if subject := subjectFromMARC(f); subject != "" {
+				book.Subjects = append(book.Subjects, subject)
+			}
+		case f.Tag == tagGenre && !used[f.Tag]:
+			book.Genre = trimISBD(f.Subfield('a'))
+		default:
+			extra.Fields = append(extra.Fields, f)
+			continue
+		}
+
+		used[f.Tag] = true
+	}
+
+	book.Year = yearFromMARC(&extra)
+
+	if book.Genre == "" && len(book.Subjects) > 0 {
+		book.Genre, _, _ = strings.Cut(book.Subjects[0], subjectSeparator)
+	}
+
+	if f, ok := extra.First(tagExtent); ok {
+		if m := pagesPattern.FindStringSubmatch(f.Subfield('a')); m != nil {
+			book.Size, _ = strconv.Atoi(m[1])
+		}
+	}
+
+	book.MARC = &extra
+
+	return book, author
+}
+
+// MARC returns bibliographic record of book, kept fields of imported record are merged back.
+func (r BookRecord) MARC() *marc.Record {
+	rec := marc.Record{Fields: []marc.Field{{Tag: tagControlNumber, Value: r.ID}}}
+
+	if r.Book.MARC != nil {
+		rec.Leader = r.Book.MARC.Leader
+
+		for _, f := range r.Book.MARC.Fields {
+			if f.Tag != tagControlNumber {
+				rec.Fields = append(rec.Fields, f)
+			}
+		}
+	}
+
+	if r.ISBN != "" {
+		rec.Fields = append(rec.Fields, dataField(tagISBN, ' ', ' ', 'a', r.ISBN))
+	}
+
+	if r.Author.LastName != "" {
+		name := r.Author.LastName
+		if r.Author.FirstName != "" {
+			name += ", " + r.Author.FirstName
+		}
+
+		rec.Fields = append(rec.Fields, dataField(tagAuthor, '1', ' ', 'a', name))
+	}
+
+	rec.Fields = append(rec.Fields, dataField(tagTitle, '1', '0', 'a', r.Title))
+
+	_, imprint := rec.First(tagImprint)
+	if _, ok := rec.First(tagPublication); !ok && !imprint && r.Year != 0 {
+		rec.Fields = append(rec.Fields, dataField(tagPublication, ' ', '1', 'c', strconv.Itoa(r.Year)))
+	}
+
+	if _, ok := rec.First(tagExtent); !ok && r.Size != 0 {
+		rec.Fields = append(rec.Fields, dataField(tagExtent, ' ', ' ', 'a', strconv.Itoa(r.Size)+" p."))
+	}
+
+	for _, subject := range r.Subjects {
+		parts := strings.Split(subject, subjectSeparator)
+
+		f := dataField(tagSubject, ' ', '4', 'a', parts[0])
+		for _, part := range parts[1:] {
+			f.Subfields = append(f.Subfields, marc.Subfield{Code: 'x', Value: part})
+		}
+
+		rec.Fields = append(rec.Fields, f)
+	}
+
+	if r.Genre != "" {
+		rec.Fields = append(rec.Fields, dataField(tagGenre, ' ', '4', 'a', r.Genre))
+	}
+
+	sort.SliceStable(rec.Fields, func(i, j int) bool { return rec.Fields[i].Tag < rec.Fields[j].Tag })
+
+	return &rec
+}
+
+func dataField(tag string, ind1, ind2, code byte, value string) marc.Field {
+	return marc.Field{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: []marc.Subfield{{Code: code, Value: value}}}
+}
+
+// authorFromName parses personal name, which is taken as inverted if it has comma.
+func authorFromName(name string) Author {
+	name = strings.TrimRight(strings.TrimSpace(name), ",")
+
+	if last, first, ok := strings.Cut(name, ","); ok {
+		return Author{FirstName: strings.TrimSpace(first), LastName: strings.TrimSpace(last)}
+	}
+
+	if i := strings.LastIndex(name, " "); i > 0 {
+		return Author{FirstName: name[:i], LastName: name[i+1:]}
+	}
+
+	return Author{LastName: name}
+}
+
+// subjectFromMARC joins topical term with its subdivisions.
+func subjectFromMARC(f marc.Field) string {
+	var parts []string
+
+	for _, sf := range f.Subfields {
+		switch sf.Code {
+		case 'a', 'x', 'y', 'z', 'v':
+			if part := trimISBD(sf.Value); part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+
+	return strings.Join(parts, subjectSeparator)
+}
+
+// yearFromMARC reads year of publication, of imprint in older records or of fixed-length data.
+func yearFromMARC(rec *marc.Record) int {
+	for _, tag := range []string{tagPublication, tagImprint} {
+		for _, f := range rec.Get(tag) {
+			if year, err := strconv.Atoi(yearPattern.FindString(f.Subfield('c'))); err == nil {
+				return year
+			}
+		}
+	}
+
+	if f, ok := rec.First(tagFixedData); ok && len(f.Value) >= 11 {
+		if year, err := strconv.Atoi(f.Value[7:11]); err == nil {
+			return year
+		}
+	}
+
+	return 0
+}
+
+// trimISBD drops punctuation ISBD puts between elements of description.
+func trimISBD(s string) string {
+	return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(s), " /:;,=."))
+}
@@ -0,0 +1,213 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/lib/hash"
+)
+
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+const (
+	ScopeProfile     = "profile"
+	ScopeBooksRead   = "books:read"
+	ScopeBooksWrite  = "books:write"
+	ScopeLibraryRead = "library:read"
+	ScopeLibraryEdit = "library:write"
+)
+
+const (
+	PermissionReadProfile = "read_profile"
+	PermissionReadBooks   = "read_books"
+	PermissionImportBooks = "import_books"
+	PermissionExportBooks = "export_books"
+	PermissionEditLibrary = "edit_library"
+)
+
+// ScopePermissions maps OAuth2 scopes granted to third-party
+// clients onto permissions checked by handlers.
+var ScopePermissions = map[string][]string{
+	ScopeProfile:     {PermissionReadProfile},
+	ScopeBooksRead:   {PermissionReadBooks, PermissionExportBooks},
+	ScopeBooksWrite:  {PermissionImportBooks},
+	ScopeLibraryRead: {PermissionReadBooks},
+	ScopeLibraryEdit: {PermissionEditLibrary},
+}
+
+// Client represents third-party application registered
+// to act on behalf of readers or on its own behalf.
+type Client struct {
+	ID           string    `json:"client_id"`
+	Secret       string    `json:"client_secret,omitempty"`
+	Name         string    `json:"client_name" regex:"^[\\p{L}\\p{N}&\\s-\\\\'’.]{2,256}$"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	GrantTypes   []string  `json:"grant_types"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (c *Client) OK() error {
+	vErr := validate(c)
+
+	if len(c.GrantTypes) == 0 {
+		vErr.Add("grant_types", "required", "at least one grant type is required")
+	}
+
+	for i, grant := range c.GrantTypes {
+		switch grant {
+		case GrantAuthorizationCode, GrantRefreshToken:
+		case GrantClientCredentials:
+			if !c.Confidential {
+				vErr.Add(fmt.Sprintf("grant_types/%d", i), "confidential", grant+" grant requires confidential client")
+			}
+		default:
+			vErr.Add(fmt.Sprintf("grant_types/%d", i), "enum", fmt.Sprintf("unsupported grant type %q", grant))
+		}
+	}
+
+	if c.Allows(GrantAuthorizationCode) && len(c.RedirectURIs) == 0 {
+		vErr.Add("redirect_uris", "required", "redirect uri is required for "+GrantAuthorizationCode+" grant")
+	}
+
+	for i, uri := range c.RedirectURIs {
+		u, err := url.Parse(uri)
+		if err != nil || !u.IsAbs() || u.Fragment != "" {
+			vErr.Add(fmt.Sprintf("redirect_uris/%d", i), "uri", "must be absolute uri without fragment")
+		}
+	}
+
+	for i, scope := range c.Scopes {
+		if _, ok := ScopePermissions[scope]; !ok {
+			vErr.Add(fmt.Sprintf("scopes/%d", i), "enum", fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+
+	return vErr.Err()
+}
+
+func (c *Client) Normalize() {
+	c.Name = strings.TrimSpace(c.Name)
+}
+
+func (c *Client) HashSecret() (err error) {
+	if c.Secret, err = hash.Make(c.Secret); err != nil {
+		return fmt.Errorf("%w: %w", exceptions.ErrHashing, err)
+	}
+
+	return nil
+}
+
+func (c *Client) CheckSecret(secret string) error {
+	if err := hash.Verify(secret, c.Secret); err != nil {
+		return fmt.Errorf("%w: %w", exceptions.ErrComparingHash, err)
+	}
+
+	return nil
+}
+
+// Allows reports whether client is registered for given grant type.
+func (c *Client) Allows(grant string) bool {
+	for _, g := range c.GrantTypes {
+		if g == grant {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasRedirectURI reports whether uri exactly matches one of registered redirect URIs.
+func (c *Client) HasRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GrantScope narrows requested space-delimited scope to ones registered for client.
+// Empty request results in all registered scopes.
+func (c *Client) GrantScope(requested string) (string, error) {
+	if strings.TrimSpace(requested) == "" {
+		return strings.Join(c.Scopes, " "), nil
+	}
+
+	registered := make(map[string]bool, len(c.Scopes))
+	for _, scope := range c.Scopes {
+		registered[scope] = true
+	}
+
+	scopes := strings.Fields(requested)
+	for _, scope := range scopes {
+		if !registered[scope] {
+			return "", fmt.Errorf("%w: %q", exceptions.ErrInvalidScope, scope)
+		}
+	}
+
+	return strings.Join(scopes, " "), nil
+}
+
+// AuthCode represents authorization code issued after reader consent.
+type AuthCode struct {
+	Code                string        `json:"code"`
+	ClientID            string        `json:"client_id"`
+	ReaderID            string        `json:"reader_id"`
+	Role                string        `json:"role"`
+	RedirectURI         string        `json:"redirect_uri"`
+	Scope               string        `json:"scope"`
+	CodeChallenge       string        `json:"code_challenge"`
+	CodeChallengeMethod string        `json:"code_challenge_method"`
+	Expiry              time.Duration `json:"expiry"`
+}
+
+// AuthRequest represents authorization request parameters
+// of authorization code flow with PKCE (RFC 6749, RFC 7636).
+type AuthRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+// Consent is rendered to reader to approve or deny access for client.
+type Consent struct {
+	ClientID   string      `json:"client_id"`
+	ClientName string      `json:"client_name"`
+	Scopes     []string    `json:"scopes"`
+	Request    AuthRequest `json:"request"`
+}
+
+// TokenRequest represents parameters of token endpoint.
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+}
+
+// Introspection represents token introspection response (RFC 7662).
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+}
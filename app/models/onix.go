@@ -0,0 +1,66 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/delveper/mylib/lib/onix"
+)
+
+// BookFromONIX maps product of ONIX message to book and its author.
+// Genre is heading of main subject, or of the first one if none is marked as main.
+func BookFromONIX(p *onix.Product) (Book, Author) {
+	book := Book{ISBN: p.ISBN()}
+
+	title, subtitle := p.Title()
+
+	book.Title = strings.TrimSpace(title)
+	if subtitle = strings.TrimSpace(subtitle); subtitle != "" {
+		book.Title += ": " + subtitle
+	}
+
+	var main bool
+
+	for _, subject := range p.Descriptive.Subjects {
+		heading := strings.TrimSpace(subject.Heading)
+		if heading == "" {
+			continue
+		}
+
+		book.Subjects = append(book.Subjects, heading)
+
+		switch {
+		case subject.Main != nil && !main:
+			book.Genre, main = heading, true
+		case book.Genre == "":
+			book.Genre = heading
+		}
+	}
+
+	for _, extent := range p.Descriptive.Extents {
+		if extent.Type == onix.ExtentMainContent && extent.Unit == onix.ExtentUnitPages {
+			book.Size, _ = strconv.Atoi(strings.TrimSpace(extent.Value))
+		}
+	}
+
+	for _, date := range p.Publishing.Dates {
+		if date.Role == onix.DateRolePublication {
+			book.Year, _ = strconv.Atoi(yearPattern.FindString(date.Date))
+		}
+	}
+
+	var author Author
+
+	if c, ok := p.Author(); ok {
+		switch {
+		case c.KeyNames != "":
+			author = Author{FirstName: strings.TrimSpace(c.NamesBeforeKey), LastName: strings.TrimSpace(c.KeyNames)}
+		case c.PersonNameInverted != "":
+			author = authorFromName(c.PersonNameInverted)
+		default:
+			author = authorFromName(c.PersonName)
+		}
+	}
+
+	return book, author
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/delveper/mylib/app/exceptions"
+)
+
+// minPasswordClasses is number of character classes strong password consists of
+// out of lower case letters, upper case letters, digits and symbols.
+const minPasswordClasses = 3
+
+//go:embed breached.txt
+var breachedList string
+
+var (
+	breachedOnce sync.Once
+	breached     map[string]struct{}
+)
+
+// isBreached reports if password is in local list of breached ones.
+func isBreached(password string) bool {
+	breachedOnce.Do(func() {
+		breached = make(map[string]struct{})
+
+		for _, line := range strings.Split(breachedList, "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+				breached[strings.ToLower(line)] = struct{}{}
+			}
+		}
+	})
+
+	_, ok := breached[strings.ToLower(password)]
+
+	return ok
+}
+
+// passwordClasses counts character classes used in password.
+func passwordClasses(password string) int {
+	var lower, upper, digit, symbol int
+
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
+
+// checkPassword records strength violations of new password.
+// Length and allowed characters are checked by pattern, so empty password is skipped here.
+func checkPassword(vErr *exceptions.ValidationError, field, password string, personal ...string) {
+	if password == "" {
+		return
+	}
+
+	if passwordClasses(password) < minPasswordClasses {
+		vErr.Add(field, "strength", "must contain at least 3 of: lower case letters, upper case letters, digits, symbols")
+	}
+
+	lower := strings.ToLower(password)
+
+	for _, word := range personal {
+		if word = strings.ToLower(strings.TrimSpace(word)); len(word) >= 3 && strings.Contains(lower, word) {
+			vErr.Add(field, "personal", "must not contain name or email")
+			break
+		}
+	}
+
+	if isBreached(password) {
+		vErr.Add(field, "breached", "is too common and appears in breached passwords list")
+	}
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CallerAnonymous = "anonymous"
+	CallerReader    = "reader"
+	CallerAdmin     = "admin"
+	CallerClient    = "client"
+)
+
+// DefaultRouteCosts holds costs of expensive routes, others cost 1 token.
+// Probes of orchestrator are free.
+var DefaultRouteCosts = map[string]int{
+	"GET /healthz":        0,
+	"GET /readyz":         0,
+	"GET /metrics":        0,
+	"GET /books/download": 10,
+	"POST /books":         5,
+	"POST /books/import":  50,
+	"GET /search":         2,
+	"POST /readers/login": 5,
+	"POST /oauth/token":   5,
+}
+
+// Quota describes token bucket of Limit tokens refilled completely during Period.
+type Quota struct {
+	Limit  int
+	Period time.Duration
+}
+
+// Quotas holds Quota for every caller class.
+type Quotas map[string]Quota
+
+// RateLimit represents state of token bucket after request.
+type RateLimit struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// ParseQuota parses quota in format of `<limit>/<period>`, e.g. `100/1m`.
+func ParseQuota(s string) (Quota, error) {
+	limit, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Quota{}, fmt.Errorf("quota does not correspond format <limit>/<period>: %q", s)
+	}
+
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		return Quota{}, fmt.Errorf("invalid quota limit: %q", limit)
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Quota{}, fmt.Errorf("invalid quota period: %q", period)
+	}
+
+	return Quota{Limit: n, Period: d}, nil
+}
+
+// CheckCosts reports routes costing more than limit of quota, since bucket never holds enough tokens for them.
+func (q Quota) CheckCosts(costs map[string]int) error {
+	var routes []string
+
+	for route, cost := range costs {
+		if cost > q.Limit {
+			routes = append(routes, route)
+		}
+	}
+
+	if len(routes) == 0 {
+		return nil
+	}
+
+	sort.Strings(routes)
+
+	return fmt.Errorf("limit %d is less than cost of %s", q.Limit, strings.Join(routes, ", "))
+}
+
+// Rate returns number of tokens refilled per second.
+func (q Quota) Rate() float64 {
+	return float64(q.Limit) / q.Period.Seconds()
+}
+
+// Result evaluates RateLimit from tokens left in bucket.
+func (q Quota) Result(tokens float64, cost int, allowed bool) RateLimit {
+	rate := q.Rate()
+
+	res := RateLimit{
+		Allowed:   allowed,
+		Limit:     q.Limit,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     time.Duration((float64(q.Limit) - tokens) / rate * float64(time.Second)),
+	}
+
+	if !allowed {
+		res.RetryAfter = time.Duration((float64(cost) - tokens) / rate * float64(time.Second))
+	}
+
+	return res
+}
@@ -7,14 +7,13 @@ import (
 
 	"github.com/delveper/mylib/app/exceptions"
 	"github.com/delveper/mylib/lib/hash"
-	"github.com/delveper/revalid"
 )
 
 type Reader struct {
 	ID        string    `json:"id"` // regex:"(?i)^[0-9a-f]{8}\b-[0-9a-f]{4}\b-[0-9a-f]{4}\b-[0-9a-f]{4}\b-[0-9a-f]{12}$"
-	FirstName string    `json:"first_name" regex:"^[\p{L}&\s-\\'’.]{2,256}$"`
-	LastName  string    `json:"last_name" regex:"^[\p{L}&\s-\\'’.]{2,256}$"`
-	Email     string    `json:"email" regex:"(?i)(^[a-z0-9_.+-]+@[a-z0-9-]+\.[a-z0-9-.]+$)"`
+	FirstName string    `json:"first_name" regex:"^[\\p{L}&\\s-\\\\'’.]{2,256}$"`
+	LastName  string    `json:"last_name" regex:"^[\\p{L}&\\s-\\\\'’.]{2,256}$"`
+	Email     string    `json:"email" regex:"(?i)(^[a-z0-9_.+-]+@[a-z0-9-]+\\.[a-z0-9-.]+$)"`
 	Password  string    `json:"password" regex:"^[[:graph:]]{8,256}$"`
 	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
@@ -22,11 +21,12 @@ type Reader struct {
 }
 
 func (r *Reader) OK() error {
-	if err := revalid.ValidateStruct(r); err != nil {
-		return fmt.Errorf("%w: %w", exceptions.ErrValidation, err)
-	}
+	vErr := validate(r)
 
-	return nil
+	localPart, _, _ := strings.Cut(r.Email, "@")
+	checkPassword(vErr, "password", r.Password, r.FirstName, r.LastName, localPart)
+
+	return vErr.Err()
 }
 
 func (r *Reader) Normalize() {
@@ -0,0 +1,46 @@
+package models
+
+import "fmt"
+
+// Reasons of recommendation, from the strongest one.
+const (
+	ReasonAlsoSaved  = "also_saved"
+	ReasonSameAuthor = "same_author"
+	ReasonSameGenre  = "same_genre"
+	ReasonPopular    = "popular"
+)
+
+// DefaultRecommendations is number of recommendations returned when reader does not ask for other.
+const DefaultRecommendations = 10
+
+// Recommendation is the latest edition of work reader has not saved yet. Source is title of saved work
+// recommendation comes from, it is empty for popular works recommended to new readers.
+type Recommendation struct {
+	Book        Book    `json:"book"`
+	Author      string  `json:"author,omitempty"`
+	Score       float64 `json:"score"`
+	Reason      string  `json:"reason"`
+	Explanation string  `json:"explanation"`
+	Source      string  `json:"-"`
+}
+
+// SimilarityOptions tells which pairs of works batch job keeps.
+// Pair needs at least MinShared readers who saved both works, every work keeps PerBook most similar ones.
+type SimilarityOptions struct {
+	MinShared int
+	PerBook   int
+}
+
+// Explain sets explanation of recommendation from its reason.
+func (r *Recommendation) Explain() {
+	switch r.Reason {
+	case ReasonAlsoSaved:
+		r.Explanation = fmt.Sprintf("Readers who saved “%s” saved this as well", r.Source)
+	case ReasonSameAuthor:
+		r.Explanation = fmt.Sprintf("More by %s, author of “%s”", r.Author, r.Source)
+	case ReasonSameGenre:
+		r.Explanation = fmt.Sprintf("%s, like “%s”", r.Book.Genre, r.Source)
+	case ReasonPopular:
+		r.Explanation = "Popular with readers"
+	}
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/delveper/mylib/app/exceptions"
+)
+
+// Query parameters of search, paging is given by $top and $skip as in DataFilter.
+const (
+	OptionQuery    = "q"
+	OptionGenre    = "genre"
+	OptionYearFrom = "year_from"
+	OptionYearTo   = "year_to"
+)
+
+// maxQueryLength bounds search text, longer one is not a query someone typed.
+const maxQueryLength = 256
+
+// SearchQuery is search of books by text, narrowed by genre and years.
+// Fuzzy is set by usecase when text matched nothing and misspelled words are tolerated.
+type SearchQuery struct {
+	Text     string `json:"q"`
+	Genre    string `json:"genre,omitempty"`
+	YearFrom int    `json:"year_from,omitempty"`
+	YearTo   int    `json:"year_to,omitempty"`
+	Top      int    `json:"-"`
+	Skip     int    `json:"-"`
+	Fuzzy    bool   `json:"-"`
+}
+
+// SearchHit is book found with its relevance, Headline is text of book with matches marked by <mark>.
+type SearchHit struct {
+	Book     Book    `json:"book"`
+	Author   string  `json:"author"`
+	Rank     float64 `json:"rank"`
+	Headline string  `json:"headline"`
+}
+
+// FacetCount tells how many of found books have value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// SearchFacets count books matching text by genre and by decade of year, e.g. "1930-1939".
+// Genre and years filters are not applied, so that facets show how search can be widened as well.
+type SearchFacets struct {
+	Genres []FacetCount `json:"genres"`
+	Years  []FacetCount `json:"years"`
+}
+
+// SearchResult is page of hits, Total is number of hits of all pages.
+// Fuzzy tells that text matched nothing as typed and hits are similar titles and names instead.
+type SearchResult struct {
+	Total  int          `json:"total"`
+	Fuzzy  bool         `json:"fuzzy"`
+	Hits   []SearchHit  `json:"hits"`
+	Facets SearchFacets `json:"facets"`
+}
+
+// ParseSearchQuery reads search from URL query, every invalid parameter is reported.
+func ParseSearchQuery(u *url.URL) (SearchQuery, error) {
+	var (
+		vErr   exceptions.ValidationError
+		values = u.Query()
+	)
+
+	q := SearchQuery{
+		Text:  strings.TrimSpace(values.Get(OptionQuery)),
+		Genre: strings.TrimSpace(values.Get(OptionGenre)),
+	}
+
+	switch {
+	case q.Text == "":
+		vErr.Add(OptionQuery, "required", "must not be empty")
+	case len(q.Text) > maxQueryLength:
+		vErr.Add(OptionQuery, "pattern", "must be at most 256 characters")
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{OptionYearFrom, &q.YearFrom},
+		{OptionYearTo, &q.YearTo},
+		{OptionTop, &q.Top},
+		{OptionSkip, &q.Skip},
+	}
+
+	for _, p := range ints {
+		raw := values.Get(p.name)
+		if raw == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			vErr.Add(p.name, "type", "must be non-negative integer")
+			continue
+		}
+
+		*p.dst = n
+	}
+
+	if q.YearFrom != 0 && q.YearTo != 0 && q.YearFrom > q.YearTo {
+		vErr.Add(OptionYearTo, "range", "must not be before "+OptionYearFrom)
+	}
+
+	return q, vErr.Err()
+}
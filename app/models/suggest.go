@@ -0,0 +1,82 @@
+package models
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/delveper/mylib/app/exceptions"
+)
+
+// Kinds of suggestions.
+const (
+	SuggestTitle  = "title"
+	SuggestAuthor = "author"
+	SuggestGenre  = "genre"
+)
+
+// OptionType is query parameter of suggestion kind, text is given by OptionQuery and limit by OptionTop.
+const OptionType = "type"
+
+// Bounds of suggestions. Shorter prefix would match most of catalog.
+const (
+	minSuggestPrefix   = 2
+	defaultSuggestions = 10
+	maxSuggestions     = 20
+)
+
+// SuggestQuery asks for suggestions of given kind starting with Prefix, or having word starting with it.
+type SuggestQuery struct {
+	Prefix string
+	Type   string
+	Top    int
+}
+
+// Suggestion is completion of prefix, ID is ID of book or author and is empty for genres.
+// Popularity counts readers having book in favorites or wishlist, summed up for authors and genres.
+type Suggestion struct {
+	ID         string `json:"id,omitempty"`
+	Value      string `json:"value"`
+	Popularity int    `json:"popularity"`
+}
+
+// ParseSuggestQuery reads suggestion query from URL query, titles are suggested if kind is not given.
+func ParseSuggestQuery(u *url.URL) (SuggestQuery, error) {
+	var (
+		vErr   exceptions.ValidationError
+		values = u.Query()
+	)
+
+	q := SuggestQuery{
+		Prefix: strings.TrimSpace(values.Get(OptionQuery)),
+		Type:   strings.ToLower(strings.TrimSpace(values.Get(OptionType))),
+		Top:    defaultSuggestions,
+	}
+
+	switch n := utf8.RuneCountInString(q.Prefix); {
+	case n < minSuggestPrefix:
+		vErr.Add(OptionQuery, "pattern", "must be at least 2 characters")
+	case n > maxQueryLength:
+		vErr.Add(OptionQuery, "pattern", "must be at most 256 characters")
+	}
+
+	switch q.Type {
+	case "":
+		q.Type = SuggestTitle
+	case SuggestTitle, SuggestAuthor, SuggestGenre:
+	default:
+		vErr.Add(OptionType, "enum", "must be one of: "+SuggestTitle+", "+SuggestAuthor+", "+SuggestGenre)
+	}
+
+	if raw := values.Get(OptionTop); raw != "" {
+		top, err := strconv.Atoi(raw)
+		if err != nil || top < 1 || top > maxSuggestions {
+			vErr.Add(OptionTop, "range", "must be between 1 and 20")
+		}
+
+		q.Top = top
+	}
+
+	return q, vErr.Err()
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type Token struct {
 	ID     string
@@ -8,16 +11,23 @@ type Token struct {
 	Expiry time.Duration
 }
 
+// AccessToken is issued either to reader directly
+// or to third-party client acting on behalf of reader.
+// In latter case ClientID and Scope are set.
 type AccessToken struct {
 	ReaderID       string
 	RefreshTokenID string
 	Role           string
+	ClientID       string `json:",omitempty"`
+	Scope          string `json:",omitempty"`
 	Expiry         time.Duration
 }
 
 type RefreshToken struct {
 	ID       string
 	ReaderID string
+	ClientID string `json:",omitempty"`
+	Scope    string `json:",omitempty"`
 	Expiry   time.Duration
 }
 
@@ -25,5 +35,24 @@ type TokenPair struct {
 	AccessToken  string        `json:"access_token"`
 	TokenType    string        `json:"token_type"`
 	ExpiresIn    time.Duration `json:"expires_in"`
-	RefreshToken string        `json:"refresh_token"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
+	Scope        string        `json:"scope,omitempty"`
+}
+
+// Permits reports whether token grants given permission.
+// Tokens issued to readers directly are not limited by scopes.
+func (t AccessToken) Permits(permission string) bool {
+	if t.ClientID == "" {
+		return true
+	}
+
+	for _, scope := range strings.Fields(t.Scope) {
+		for _, p := range ScopePermissions[scope] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+
+	return false
 }
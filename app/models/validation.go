@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/delveper/mylib/app/exceptions"
+)
+
+const patternTag = "regex"
+
+// patterns caches compiled regex tags by their source.
+var patterns sync.Map
+
+// validate checks every field having `regex` tag and records mismatches under JSON names of fields,
+// nested structs are checked recursively. Unlike revalid it does not stop on first mismatch.
+// Tags are read as quoted Go strings, so regex escapes in them are doubled, e.g. \\p{L}.
+func validate(src any) *exceptions.ValidationError {
+	var vErr exceptions.ValidationError
+
+	walkPatterns(reflect.Indirect(reflect.ValueOf(src)), "", &vErr)
+
+	return &vErr
+}
+
+func walkPatterns(val reflect.Value, prefix string, vErr *exceptions.ValidationError) {
+	typ := val.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name := prefix + jsonName(sf)
+		field := val.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			walkPatterns(field, name+"/", vErr)
+			continue
+		}
+
+		pattern, ok := sf.Tag.Lookup(patternTag)
+		if !ok {
+			continue
+		}
+
+		str := fmt.Sprint(field.Interface())
+		if compile(pattern).MatchString(str) {
+			continue
+		}
+
+		if str == "" {
+			vErr.Add(name, "required", "is required")
+			continue
+		}
+
+		vErr.Add(name, "pattern", "has invalid format")
+	}
+}
+
+func compile(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+
+	re := regexp.MustCompile(pattern)
+	patterns.Store(pattern, re)
+
+	return re
+}
+
+func jsonName(sf reflect.StructField) string {
+	if name, ok := sf.Tag.Lookup("json"); ok {
+		if name, _, _ = strings.Cut(name, ","); name != "" {
+			return name
+		}
+	}
+
+	return sf.Name
+}
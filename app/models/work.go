@@ -0,0 +1,69 @@
+package models
+
+import "strings"
+
+// Formats of edition.
+const (
+	FormatHardcover = "hardcover"
+	FormatPaperback = "paperback"
+	FormatEbook     = "ebook"
+	FormatAudiobook = "audiobook"
+)
+
+// maxSeriesNumber bounds number of work in series as column does.
+const maxSeriesNumber = 1e6
+
+var formats = map[string]bool{
+	FormatHardcover: true,
+	FormatPaperback: true,
+	FormatEbook:     true,
+	FormatAudiobook: true,
+}
+
+// Work is book as it is written, editions publish it. Readers save works,
+// so that saving one edition saves every other as well.
+// SeriesNumber orders works of series, it is not necessarily integer, e.g. 1.5 for novella between volumes.
+type Work struct {
+	ID           string  `json:"id"`
+	AuthorID     string  `json:"author_id" regex:"(?i)^[0-9a-f]{8}\\b-[0-9a-f]{4}\\b-[0-9a-f]{4}\\b-[0-9a-f]{4}\\b-[0-9a-f]{12}$"`
+	Title        string  `json:"title" regex:"^[^\\p{C}]{1,256}$"`
+	SeriesID     string  `json:"series_id,omitempty" regex:"(?i)^([0-9a-f]{8}\\b-[0-9a-f]{4}\\b-[0-9a-f]{4}\\b-[0-9a-f]{4}\\b-[0-9a-f]{12})?$"`
+	SeriesNumber float64 `json:"series_number,omitempty"`
+	Editions     []Book  `json:"editions,omitempty"`
+}
+
+// Series lists its works ordered by their number.
+type Series struct {
+	ID    string `json:"id"`
+	Title string `json:"title" regex:"^[^\\p{C}]{1,256}$"`
+	Works []Work `json:"works,omitempty"`
+}
+
+func (w *Work) Normalize() {
+	w.AuthorID = strings.ToLower(strings.TrimSpace(w.AuthorID))
+	w.Title = strings.TrimSpace(w.Title)
+	w.SeriesID = strings.ToLower(strings.TrimSpace(w.SeriesID))
+}
+
+func (w *Work) OK() error {
+	vErr := validate(w)
+
+	switch {
+	case w.SeriesNumber < 0 || w.SeriesNumber >= maxSeriesNumber:
+		vErr.Add("series_number", "range", "must be positive and less than million")
+	case w.SeriesID != "" && w.SeriesNumber == 0:
+		vErr.Add("series_number", "required", "is required for work of series")
+	case w.SeriesID == "" && w.SeriesNumber != 0:
+		vErr.Add("series_id", "required", "is required for numbered work")
+	}
+
+	return vErr.Err()
+}
+
+func (s *Series) Normalize() {
+	s.Title = strings.TrimSpace(s.Title)
+}
+
+func (s *Series) OK() error {
+	return validate(s).Err()
+}
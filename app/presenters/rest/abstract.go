@@ -2,13 +2,15 @@ package rest
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/delveper/mylib/app/models"
 )
 
 type ReaderLogic interface {
 	Auth(context.Context, models.AccessToken) error
-	Refresh(context.Context, models.RefreshToken) (*models.TokenPair, error)
+	Refresh(context.Context, models.AccessToken, string) (*models.TokenPair, error)
 	SignUp(context.Context, models.Reader) error
 	SignIn(context.Context, models.Credentials) (*models.TokenPair, error)
 	SignOut(context.Context, models.AccessToken) error
@@ -18,7 +20,50 @@ type BookLogic interface {
 	Import(context.Context, models.Book) error
 	Fetch(context.Context, models.Book) (models.Book, error)
 	FetchMany(context.Context, models.DataFilter) ([]models.Book, error)
-	ExportToCSV(context.Context, models.DataFilter) ([]byte, error)
+	Search(context.Context, models.SearchQuery) (models.SearchResult, error)
+	Suggest(context.Context, models.SuggestQuery) ([]models.Suggestion, error)
+	Export(context.Context, models.BookExport, io.Writer) error
+	Cite(context.Context, models.Book, string, io.Writer) error
+	BulkImport(context.Context, []models.ImportRow, models.ImportOptions) (*models.ImportReport, error)
+	StartImport(context.Context, models.ImportJob, []models.ImportRow) (models.ImportJob, error)
+	FetchImport(context.Context, models.ImportJob) (models.ImportJob, error)
 	AddToFavorites(context.Context, models.Reader, models.Book) error
 	AddToWishlist(context.Context, models.Reader, models.Book) error
+	Recommend(context.Context, models.Reader, int) ([]models.Recommendation, error)
+}
+
+type GenreLogic interface {
+	Tree(context.Context) ([]*models.Genre, error)
+	Create(context.Context, models.Genre) (models.Genre, error)
+	AddSynonym(context.Context, models.GenreSynonym) error
+}
+
+type WorkLogic interface {
+	Create(context.Context, models.Work) (models.Work, error)
+	Fetch(context.Context, models.Work) (models.Work, error)
+	CreateSeries(context.Context, models.Series) (models.Series, error)
+	FetchSeries(context.Context, models.Series) (models.Series, error)
+}
+
+type OAuthLogic interface {
+	Register(context.Context, models.Client) (models.Client, error)
+	Consent(context.Context, models.AuthRequest) (*models.Consent, error)
+	Authorize(context.Context, models.AccessToken, models.AuthRequest) (string, error)
+	Deny(context.Context, models.AccessToken, models.AuthRequest) (string, error)
+	Exchange(context.Context, models.TokenRequest) (*models.TokenPair, error)
+	Introspect(context.Context, models.TokenRequest, string) (*models.Introspection, error)
+	Revoke(context.Context, models.TokenRequest, string) error
+}
+
+type IdentityLogic interface {
+	Begin(context.Context) (uri, state string, err error)
+	Complete(ctx context.Context, state, code string) (*models.TokenPair, error)
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, quota models.Quota, cost int) (models.RateLimit, error)
+}
+
+type HTTPMetrics interface {
+	ObserveRequest(method, route string, status int, elapsed time.Duration)
 }
@@ -2,15 +2,15 @@ package rest
 
 import (
 	"context"
-	"fmt"
 	"net/http"
-	"os"
 	"strconv"
+	"time"
 
+	"github.com/delveper/mylib/app/config"
 	"github.com/delveper/mylib/app/exceptions"
 	"github.com/delveper/mylib/app/models"
 	"github.com/go-chi/chi/v5"
-	"github.com/pkg/errors"
+	"github.com/google/uuid"
 )
 
 type Book struct {
@@ -18,133 +18,150 @@ type Book struct {
 	resp  responder
 }
 
-func NewBook(logic BookLogic, logger models.Logger) Book {
+func NewBook(logic BookLogic, logger models.Logger, cfg *config.Reloadable) Book {
 	return Book{
 		logic: logic,
-		resp:  responder{logger},
+		resp:  responder{Logger: logger, cfg: cfg},
 	}
 }
 
 func (b Book) Route(rtr chi.Router) {
 	rtr.With(b.resp.WithAuth).Route("/books", func(rtr chi.Router) {
-		rtr.With(b.resp.WithAdmin).Post("/", b.Create)
-		rtr.Get("/{id}", b.Find)
-		rtr.Get("/", b.FindMany)
-		rtr.Get("/download", b.Download)
+		rtr.With(b.resp.WithAdmin, b.resp.WithPermission(models.PermissionImportBooks)).Post("/", b.Create)
+		rtr.With(b.resp.WithAdmin, b.resp.WithPermission(models.PermissionImportBooks)).Post("/import", b.Import)
+		rtr.With(b.resp.WithAdmin, b.resp.WithPermission(models.PermissionImportBooks)).Get("/import/{id}", b.ImportStatus)
+		rtr.With(b.resp.WithPermission(models.PermissionReadBooks)).Get("/{id}", b.Find)
+		rtr.With(b.resp.WithPermission(models.PermissionReadBooks)).Get("/", b.FindMany)
+		rtr.With(b.resp.WithPermission(models.PermissionExportBooks)).Get("/download", b.Download)
 	})
 
+	rtr.With(b.resp.WithAuth, b.resp.WithPermission(models.PermissionReadBooks)).Get("/search", b.Search)
+	rtr.With(b.resp.WithAuth, b.resp.WithPermission(models.PermissionReadBooks)).Get("/suggest", b.Suggest)
+
 	rtr.With(b.resp.WithAuth).Route("/readers/me/", func(rtr chi.Router) {
-		rtr.Post("/favorites", b.AddToFavorites)
-		rtr.Post("/wishlist", b.AddToWishlist)
+		rtr.With(b.resp.WithPermission(models.PermissionEditLibrary)).Post("/favorites", b.AddToFavorites)
+		rtr.With(b.resp.WithPermission(models.PermissionEditLibrary)).Post("/wishlist", b.AddToWishlist)
+		rtr.With(b.resp.WithPermission(models.PermissionReadBooks)).Get("/recommendations", b.Recommend)
 	})
 }
 
 func (b Book) Create(rw http.ResponseWriter, req *http.Request) {
 	var book models.Book
 	if err := b.resp.decodeBody(req, &book); err != nil {
-		b.resp.writeJSON(rw, req, http.StatusBadRequest, ErrDecoding)
-		b.resp.Errorw("Failed decoding book data from request.", "error", err)
+		b.resp.writeError(rw, req, ErrDecoding)
+		b.resp.logger(req).Errorw("Failed decoding book data from request.", "error", err)
 
 		return
 	}
 
+	book.Normalize()
+
 	if err := book.OK(); err != nil {
-		b.resp.writeJSON(rw, req, http.StatusBadRequest, err)
-		b.resp.Debugw("Failed validating book.", "error", err)
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Debugw("Failed validating book.", "error", err)
 
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
 	defer cancel()
 
 	if err := b.logic.Import(ctx, book); err != nil {
-		switch {
-		case errors.Is(err, exceptions.ErrDeadline):
-			b.resp.writeJSON(rw, req, http.StatusGatewayTimeout, exceptions.ErrDeadline)
-		case errors.Is(err, exceptions.ErrRecordNotFound):
-			b.resp.writeJSON(rw, req, http.StatusExpectationFailed, fmt.Errorf("author : %w", exceptions.ErrRecordNotFound))
-		default:
-			b.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
-		}
-
-		b.resp.Errorw("Failed importing book.", "error", err)
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Errorw("Failed importing book.", "error", err)
 
 		return
 	}
 
 	msg := response{Message: "Book imported successfully."}
 	b.resp.writeJSON(rw, req, http.StatusCreated, msg)
-	b.resp.Debugf(msg.Message)
+	b.resp.logger(req).Debugf(msg.Message)
 }
 
+// Find renders book as JSON or, if negotiated, as citation attachment.
 func (b Book) Find(rw http.ResponseWriter, req *http.Request) {
 	var book models.Book
 	book.ID = chi.URLParam(req, "id")
 
-	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
 	defer cancel()
 
-	book, err := b.logic.Fetch(ctx, book)
-	if err != nil {
-		switch {
-		case errors.Is(err, exceptions.ErrDeadline):
-			b.resp.writeJSON(rw, req, http.StatusGatewayTimeout, exceptions.ErrDeadline)
-		case errors.Is(err, exceptions.ErrRecordNotFound):
-			b.resp.writeJSON(rw, req, http.StatusExpectationFailed, exceptions.ErrRecordNotFound)
-		default:
-			b.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
+	if format, ok := negotiateCitation(req); ok {
+		out := &exportWriter{ResponseWriter: rw, name: "book", format: format}
+
+		if err := b.logic.Cite(ctx, book, format, out); err != nil {
+			b.resp.logger(req).Errorw("Failed citing book.", "format", format, "error", err)
+
+			if !out.started {
+				b.resp.writeError(rw, req, err)
+				return
+			}
+
+			panic(http.ErrAbortHandler)
 		}
 
-		b.resp.Errorw("Failed fetching book.", "error", err)
+		b.resp.logger(req).Debugw("Book cited successfully.", "format", format)
+
+		return
+	}
+
+	book, err := b.logic.Fetch(ctx, book)
+	if err != nil {
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Errorw("Failed fetching book.", "error", err)
 
 		return
 	}
 
 	b.resp.writeJSON(rw, req, http.StatusOK, book)
-	b.resp.Debugf("Book fetched successfully.")
+	b.resp.logger(req).Debugf("Book fetched successfully.")
 }
 
 // FindMany handles bulk fetching books by given OData query.
 // If $top number not specified maxOnPage will be used.
 // In case number of requested books is more than maxOnPage
 // then nextLink will be rendered in response.
+// Books negotiated as citations are rendered as attachment of single page without nextLink.
 func (b Book) FindMany(rw http.ResponseWriter, req *http.Request) {
 	filter, err := models.NewDataFilter[models.Book](req.URL)
 	if err != nil {
-		b.resp.writeJSON(rw, req, http.StatusBadRequest, ErrInvalidQuery)
-		b.resp.Errorw("Failed parsing query from request URL.", "error", err)
+		b.resp.writeError(rw, req, ErrInvalidQuery)
+		b.resp.logger(req).Errorw("Failed parsing query from request URL.", "error", err)
 
 		return
 	}
 
-	maxOnPage, err := strconv.Atoi(os.Getenv("BOOKS_MAX_ON_PAGE"))
-	if err != nil {
-		b.resp.writeJSON(rw, req, http.StatusBadRequest, exceptions.ErrUnexpected)
-		b.resp.Errorw("Failed parsing BOOKS_MAX_ON_PAGE.", "error", err)
-
-		return
-	}
+	maxOnPage := b.resp.cfg.Current().Books.MaxOnPage
 
 	delta := filter.Top - maxOnPage
 	filter.Top = maxOnPage
 
-	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
 	defer cancel()
 
-	books, err := b.logic.FetchMany(ctx, *filter)
-	if err != nil {
-		switch {
-		case errors.Is(err, exceptions.ErrDeadline):
-			b.resp.writeJSON(rw, req, http.StatusGatewayTimeout, exceptions.ErrDeadline)
-			// not sure about that.
-		case errors.Is(err, exceptions.ErrRecordNotFound):
-			b.resp.writeJSON(rw, req, http.StatusBadRequest, exceptions.ErrRecordNotFound)
-		default:
-			b.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
+	if format, ok := negotiateCitation(req); ok {
+		out := &exportWriter{ResponseWriter: rw, name: "books", format: format}
+
+		if err := b.logic.Export(ctx, models.BookExport{Filter: *filter, Format: format}, out); err != nil {
+			b.resp.logger(req).Errorw("Failed citing books.", "format", format, "error", err)
+
+			if !out.started {
+				b.resp.writeError(rw, req, err)
+				return
+			}
+
+			panic(http.ErrAbortHandler)
 		}
 
-		b.resp.Errorw("Failed fetching books.", "error", err)
+		b.resp.logger(req).Debugw("Books cited successfully.", "format", format)
+
+		return
+	}
+
+	books, err := b.logic.FetchMany(ctx, *filter)
+	if err != nil {
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Errorw("Failed fetching books.", "error", err)
 
 		return
 	}
@@ -167,145 +184,411 @@ func (b Book) FindMany(rw http.ResponseWriter, req *http.Request) {
 	}
 
 	b.resp.writeJSON(rw, req, http.StatusOK, resp)
-	b.resp.Debugf("Books fetched successfully.")
+	b.resp.logger(req).Debugf("Books fetched successfully.")
+}
+
+// Search handles search of books by text with ranking, highlighted snippets and facets.
+// Page is bounded by maxOnPage as in FindMany, nextLink is rendered while hits are left.
+func (b Book) Search(rw http.ResponseWriter, req *http.Request) {
+	query, err := models.ParseSearchQuery(req.URL)
+	if err != nil {
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Debugw("Failed validating search query.", "error", err)
+
+		return
+	}
+
+	maxOnPage := b.resp.cfg.Current().Books.MaxOnPage
+	if query.Top == 0 || query.Top > maxOnPage {
+		query.Top = maxOnPage
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	res, err := b.logic.Search(ctx, query)
+	if err != nil {
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Errorw("Failed searching books.", "error", err)
+
+		return
+	}
+
+	var nextLink string
+
+	if next := query.Skip + len(res.Hits); len(res.Hits) > 0 && next < res.Total {
+		u := *req.URL
+		values := u.Query()
+		values.Set(models.OptionSkip, strconv.Itoa(next))
+		u.RawQuery = values.Encode()
+		nextLink = u.String()
+	}
+
+	resp := struct {
+		models.SearchResult
+		NextLink string `json:"next_link,omitempty"`
+	}{
+		SearchResult: res,
+		NextLink:     nextLink,
+	}
+
+	b.resp.writeJSON(rw, req, http.StatusOK, resp)
+	b.resp.logger(req).Debugw("Books searched successfully.", "total", res.Total, "fuzzy", res.Fuzzy)
+}
+
+// Suggest handles typeahead of search box. Suggestions are cached by client for suggestCacheAge,
+// since they are requested on every keystroke and change slowly.
+func (b Book) Suggest(rw http.ResponseWriter, req *http.Request) {
+	query, err := models.ParseSuggestQuery(req.URL)
+	if err != nil {
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Debugw("Failed validating suggest query.", "error", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), suggestTimeout)
+	defer cancel()
+
+	suggestions, err := b.logic.Suggest(ctx, query)
+	if err != nil {
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Errorw("Failed suggesting completions.", "error", err)
+
+		return
+	}
+
+	resp := struct {
+		Suggestions []models.Suggestion `json:"suggestions"`
+	}{
+		Suggestions: suggestions,
+	}
+
+	rw.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(int(suggestCacheAge.Seconds())))
+	b.resp.writeJSON(rw, req, http.StatusOK, resp)
+	b.resp.logger(req).Debugw("Completions suggested successfully.", "type", query.Type, "count", len(suggestions))
 }
 
+// AddToFavorites saves work of given book, or given work, to favorites of reader.
 func (b Book) AddToFavorites(rw http.ResponseWriter, req *http.Request) {
 	var book models.Book
 	if err := b.resp.decodeBody(req, &book); err != nil {
-		b.resp.writeJSON(rw, req, http.StatusBadRequest, ErrDecoding)
-		b.resp.Errorw("Failed decoding book data from request.", "error", err)
+		b.resp.writeError(rw, req, ErrDecoding)
+		b.resp.logger(req).Errorw("Failed decoding book data from request.", "error", err)
 
 		return
 	}
 
-	token := retrieveToken[models.AccessToken](req)
-	if token == nil {
-		b.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
-		b.resp.Errorf("Failed retrieve token from context.")
-
+	reader, ok := b.reader(rw, req)
+	if !ok {
 		return
 	}
 
-	reader := models.Reader{ID: token.ReaderID}
+	if book.ID == "" && book.WorkID == "" {
+		var vErr exceptions.ValidationError
+		vErr.Add("id", "required", "is required unless work_id is given")
 
-	if book.ID == "" || reader.ID == "" {
-		msg := response{Message: "ReaderID and BookID  are required fields."}
-		b.resp.writeJSON(rw, req, http.StatusGatewayTimeout, msg)
-		b.resp.Debugf(msg.Message)
+		b.resp.writeError(rw, req, &vErr)
+		b.resp.logger(req).Debugw("Failed validating book.", "error", &vErr)
 
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
 	defer cancel()
 
 	if err := b.logic.AddToFavorites(ctx, reader, book); err != nil {
-		switch {
-		case errors.Is(err, exceptions.ErrDeadline):
-			b.resp.writeJSON(rw, req, http.StatusGatewayTimeout, exceptions.ErrDeadline)
-		case errors.Is(err, exceptions.ErrReaderNotFound):
-			b.resp.writeJSON(rw, req, http.StatusBadRequest, exceptions.ErrReaderNotFound)
-		case errors.Is(err, exceptions.ErrBookNotFound):
-			b.resp.writeJSON(rw, req, http.StatusBadRequest, exceptions.ErrBookNotFound)
-		case errors.Is(err, exceptions.ErrRecordExists):
-			b.resp.writeJSON(rw, req, http.StatusConflict, exceptions.ErrRecordExists)
-		default:
-			b.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
-		}
-
-		b.resp.Errorw("Failed adding book to favorites.", "error", err)
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Errorw("Failed adding book to favorites.", "error", err)
 
 		return
 	}
 
 	msg := response{Message: "Book successfully imported fo favorites list."}
 	b.resp.writeJSON(rw, req, http.StatusCreated, msg)
-	b.resp.Debugf(msg.Message)
+	b.resp.logger(req).Debugf(msg.Message)
 }
 
+// AddToWishlist saves work of given book, or given work, to wishlist of reader.
 func (b Book) AddToWishlist(rw http.ResponseWriter, req *http.Request) {
 	var book models.Book
 	if err := b.resp.decodeBody(req, &book); err != nil {
-		b.resp.writeJSON(rw, req, http.StatusBadRequest, ErrDecoding)
-		b.resp.Errorw("Failed decoding book data from request.", "error", err)
+		b.resp.writeError(rw, req, ErrDecoding)
+		b.resp.logger(req).Errorw("Failed decoding book data from request.", "error", err)
 
 		return
 	}
 
-	token := retrieveToken[models.AccessToken](req)
-	reader := models.Reader{ID: token.ReaderID}
+	reader, ok := b.reader(rw, req)
+	if !ok {
+		return
+	}
+
+	if book.ID == "" && book.WorkID == "" {
+		var vErr exceptions.ValidationError
+		vErr.Add("id", "required", "is required unless work_id is given")
 
-	if book.ID == "" || reader.ID == "" {
-		msg := response{Message: "ReaderID and BookID  are required fields."}
-		b.resp.writeJSON(rw, req, http.StatusGatewayTimeout, msg)
-		b.resp.Debugf(msg.Message)
+		b.resp.writeError(rw, req, &vErr)
+		b.resp.logger(req).Debugw("Failed validating book.", "error", &vErr)
 
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
 	defer cancel()
 
 	if err := b.logic.AddToWishlist(ctx, reader, book); err != nil {
-		switch {
-		case errors.Is(err, exceptions.ErrDeadline):
-			b.resp.writeJSON(rw, req, http.StatusGatewayTimeout, exceptions.ErrDeadline)
-		case errors.Is(err, exceptions.ErrReaderNotFound), errors.Is(err, exceptions.ErrBookNotFound):
-			b.resp.writeJSON(rw, req, http.StatusBadRequest, exceptions.ErrRecordNotFound)
-		case errors.Is(err, exceptions.ErrRecordExists):
-			b.resp.writeJSON(rw, req, http.StatusConflict, exceptions.ErrRecordExists)
-		default:
-			b.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
-		}
-
-		b.resp.Errorw("Failed adding book to wishlist.", "error", err)
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Errorw("Failed adding book to wishlist.", "error", err)
 
 		return
 	}
 
 	msg := response{Message: "Book successfully imported fo wishlist."}
 	b.resp.writeJSON(rw, req, http.StatusCreated, msg)
-	b.resp.Debugf(msg.Message)
+	b.resp.logger(req).Debugf(msg.Message)
 }
 
+// Download streams books matching OData query in format negotiated by $format option or Accept header.
+// Columns are selected by $select option. Rows are written as they are read,
+// so export is given its own timeout instead of queryTimeout.
+// Once first bytes are sent failure can not be reported with status,
+// so connection is aborted to let client know file is incomplete.
 func (b Book) Download(rw http.ResponseWriter, req *http.Request) {
 	filter, err := models.NewDataFilter[models.Book](req.URL)
 	if err != nil {
-		b.resp.writeJSON(rw, req, http.StatusBadRequest, ErrInvalidQuery)
-		b.resp.Errorw("Failed parsing query from request URL.", "error", err)
+		b.resp.writeError(rw, req, ErrInvalidQuery)
+		b.resp.logger(req).Errorw("Failed parsing query from request URL.", "error", err)
+
+		return
+	}
+
+	cols, err := models.ParseBookColumns(req.URL.Query().Get(models.OptionSelect))
+	if err != nil {
+		b.resp.writeError(rw, req, ErrInvalidQuery)
+		b.resp.logger(req).Debugw("Failed parsing columns from request URL.", "error", err)
+
+		return
+	}
+
+	format, ok := negotiateFormat(req)
+	if !ok {
+		b.resp.writeError(rw, req, ErrNotAcceptable)
+		b.resp.logger(req).Debugw("Failed negotiating export format.", "accept", req.Header.Get("Accept"))
 
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	timeout := b.resp.cfg.Current().Books.ExportTimeout
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
 	defer cancel()
 
-	csvArr, err := b.logic.ExportToCSV(ctx, *filter)
+	// Server write timeout is meant for regular responses and would cut long export.
+	if err := http.NewResponseController(rw).SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		b.resp.logger(req).Debugw("Failed extending write deadline.", "error", err)
+	}
+
+	out := &exportWriter{ResponseWriter: rw, name: "books", format: format}
+
+	export := models.BookExport{Filter: *filter, Format: format, Columns: cols}
+
+	if err := b.logic.Export(ctx, export, out); err != nil {
+		b.resp.logger(req).Errorw("Failed exporting books.", "format", format, "error", err)
+
+		if !out.started {
+			b.resp.writeError(rw, req, err)
+			return
+		}
+
+		panic(http.ErrAbortHandler)
+	}
+
+	b.resp.logger(req).Debugw("Books exported successfully.", "format", format)
+}
+
+// Import adds books from CSV or NDJSON body. Files with more rows than
+// configured are imported by background job, which status is polled by ImportStatus.
+// Body is read within upload timeout instead of server read timeout meant for regular requests.
+func (b Book) Import(rw http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+
+	opts := models.ImportOptions{Policy: query.Get("policy")}
+
+	if raw := query.Get("dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			b.resp.writeError(rw, req, ErrInvalidQuery)
+			b.resp.logger(req).Debugw("Failed parsing dry run flag.", "error", err)
+
+			return
+		}
+
+		opts.DryRun = dryRun
+	}
+
+	if err := opts.OK(); err != nil {
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Debugw("Failed validating import options.", "error", err)
+
+		return
+	}
+
+	token := retrieveToken[models.AccessToken](req)
+	if token == nil {
+		b.resp.writeError(rw, req, exceptions.ErrUnexpected)
+		b.resp.logger(req).Errorf("Failed retrieve token from context.")
+
+		return
+	}
+
+	cfg := b.resp.cfg.Current().Books
+
+	// Server timeouts would cut large upload, response is written after body is read and imported.
+	deadline := time.Now().Add(cfg.ImportUploadTimeout)
+	ctrl := http.NewResponseController(rw)
+
+	if err := ctrl.SetReadDeadline(deadline); err != nil {
+		b.resp.logger(req).Debugw("Failed extending read deadline.", "error", err)
+	}
+
+	if err := ctrl.SetWriteDeadline(deadline.Add(3 * queryTimeout)); err != nil {
+		b.resp.logger(req).Debugw("Failed extending write deadline.", "error", err)
+	}
+
+	rows, err := decodeImport(rw, req, cfg.ImportMaxSize)
 	if err != nil {
-		switch {
-		case errors.Is(err, exceptions.ErrDeadline):
-			b.resp.writeJSON(rw, req, http.StatusGatewayTimeout, exceptions.ErrDeadline)
-		case errors.Is(err, exceptions.ErrNoContent):
-			b.resp.writeJSON(rw, req, http.StatusNoContent, exceptions.ErrNoContent)
-		default:
-			b.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Debugw("Failed decoding import rows.", "error", err)
+
+		return
+	}
+
+	if len(rows) > cfg.ImportSyncRows {
+		ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+		defer cancel()
+
+		job := models.ImportJob{CreatedBy: token.ReaderID, Options: opts}
+
+		job, err := b.logic.StartImport(ctx, job, rows)
+		if err != nil {
+			b.resp.writeError(rw, req, err)
+			b.resp.logger(req).Errorw("Failed starting import job.", "error", err)
+
+			return
 		}
 
-		b.resp.Errorw("Failed exporting books to csv.", "error", err)
+		rw.Header().Set("Location", "/books/import/"+job.ID)
+		b.resp.writeJSON(rw, req, http.StatusAccepted, job)
+		b.resp.logger(req).Debugw("Import job started.", "job", job.ID, "rows", len(rows))
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), 3*queryTimeout)
+	defer cancel()
+
+	report, err := b.logic.BulkImport(ctx, rows, opts)
+	if err != nil {
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Errorw("Failed importing books.", "error", err)
+
+		return
+	}
+
+	code := http.StatusCreated
+
+	switch {
+	case report.Imported == 0 && report.Failed > 0:
+		code = http.StatusUnprocessableEntity
+	case opts.DryRun || report.Imported == 0:
+		code = http.StatusOK
+	}
+
+	b.resp.writeJSON(rw, req, code, report)
+	b.resp.logger(req).Debugw("Books imported.", "imported", report.Imported, "failed", report.Failed)
+}
+
+func (b Book) ImportStatus(rw http.ResponseWriter, req *http.Request) {
+	var job models.ImportJob
+	job.ID = chi.URLParam(req, "id")
+
+	if _, err := uuid.Parse(job.ID); err != nil {
+		b.resp.writeError(rw, req, exceptions.ErrRecordNotFound)
+		b.resp.logger(req).Debugw("Failed parsing import job ID.", "error", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	job, err := b.logic.FetchImport(ctx, job)
+	if err != nil {
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Errorw("Failed fetching import job.", "error", err)
+
+		return
+	}
+
+	b.resp.writeJSON(rw, req, http.StatusOK, job)
+	b.resp.logger(req).Debugw("Import job fetched.", "job", job.ID, "status", job.Status)
+}
 
+// Recommend renders books recommended to reader with explanation of every one.
+// Number of them is given by $top, bounded by maxOnPage.
+// Tokens issued to clients on their own behalf have no reader to recommend to and are forbidden.
+func (b Book) Recommend(rw http.ResponseWriter, req *http.Request) {
+	reader, ok := b.reader(rw, req)
+	if !ok {
 		return
 	}
 
-	rw.Header().Set("Content-Disposition", "attachment; filename=books.csv")
-	rw.Header().Set("Content-Type", "text/csv")
-	rw.Header().Set("Transfer-Encoding", "chunked")
+	limit := models.DefaultRecommendations
+
+	if raw := req.URL.Query().Get(models.OptionTop); raw != "" {
+		top, err := strconv.Atoi(raw)
+		if err != nil || top < 1 || top > b.resp.cfg.Current().Books.MaxOnPage {
+			b.resp.writeError(rw, req, ErrInvalidQuery)
+			b.resp.logger(req).Debugw("Failed parsing number of recommendations.", "top", raw)
+
+			return
+		}
+
+		limit = top
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
 
-	if _, err := rw.Write(csvArr); err != nil {
-		b.resp.Errorw("Failed writing response from buffer.", "error", err)
+	recs, err := b.logic.Recommend(ctx, reader, limit)
+	if err != nil {
+		b.resp.writeError(rw, req, err)
+		b.resp.logger(req).Errorw("Failed recommending books.", "error", err)
 
 		return
 	}
 
-	b.resp.Debugf("Books exported to csv successfully.")
+	resp := struct {
+		Recommendations []models.Recommendation `json:"recommendations"`
+	}{
+		Recommendations: recs,
+	}
+
+	b.resp.writeJSON(rw, req, http.StatusOK, resp)
+	b.resp.logger(req).Debugw("Books recommended successfully.", "count", len(recs))
+}
+
+// reader returns reader token of request belongs to. Request is answered with 403
+// if there is no token or it has no reader, e.g. token of client acting on its own behalf.
+func (b Book) reader(rw http.ResponseWriter, req *http.Request) (models.Reader, bool) {
+	token := retrieveToken[models.AccessToken](req)
+	if token == nil || token.ReaderID == "" {
+		b.resp.writeError(rw, req, ErrPermissions)
+		b.resp.logger(req).Infow("Failed retrieve reader from token.", "error", ErrPermissions)
+
+		return models.Reader{}, false
+	}
+
+	return models.Reader{ID: token.ReaderID}, true
 }
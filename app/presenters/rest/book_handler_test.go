@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/banderlog"
+	"github.com/go-chi/chi/v5"
+)
+
+// TestLibraryNeedsReader checks that library of reader is not touched with token having no reader.
+func TestLibraryNeedsReader(t *testing.T) {
+	cfg := testConfig()
+
+	tokens := map[string]models.AccessToken{
+		"reader":             {ReaderID: testReaderID, RefreshTokenID: testGrantID},
+		"client credentials": {RefreshTokenID: testGrantID, ClientID: "client", Scope: models.ScopeLibraryEdit + " " + models.ScopeLibraryRead},
+	}
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/readers/me/favorites"},
+		{http.MethodPost, "/readers/me/wishlist"},
+		{http.MethodGet, "/readers/me/recommendations"},
+	}
+
+	for name, token := range tokens {
+		for _, route := range routes {
+			t.Run(name+route.path, func(t *testing.T) {
+				logic := &fakeBookLogic{}
+
+				rtr := chi.NewRouter()
+				NewBook(logic, banderlog.New(banderlog.Config{Level: banderlog.ErrorLevel, Console: banderlog.ConsoleOff}), cfg).Route(rtr)
+
+				req := httptest.NewRequest(route.method, route.path, strings.NewReader(`{"id":"`+testGrantID+`"}`))
+				req.Header.Set("Authorization", "Bearer "+signAccess(t, cfg, token))
+				req.Header.Set("Content-Type", "application/json")
+
+				rec := httptest.NewRecorder()
+				rtr.ServeHTTP(rec, req)
+
+				if token.ReaderID == "" {
+					if rec.Code != http.StatusForbidden || len(logic.readers) != 0 {
+						t.Errorf("got status %d and readers %q, want %d and none", rec.Code, logic.readers, http.StatusForbidden)
+					}
+
+					return
+				}
+
+				if len(logic.readers) != 1 || logic.readers[0] != testReaderID {
+					t.Errorf("got readers %q with status %d, want %s", logic.readers, rec.Code, testReaderID)
+				}
+			})
+		}
+	}
+}
+
+// fakeBookLogic records readers library of which is accessed, other methods are not expected to be called.
+type fakeBookLogic struct {
+	BookLogic
+	readers []string
+}
+
+func (f *fakeBookLogic) AddToFavorites(_ context.Context, reader models.Reader, _ models.Book) error {
+	f.readers = append(f.readers, reader.ID)
+	return nil
+}
+
+func (f *fakeBookLogic) AddToWishlist(_ context.Context, reader models.Reader, _ models.Book) error {
+	f.readers = append(f.readers, reader.ID)
+	return nil
+}
+
+func (f *fakeBookLogic) Recommend(_ context.Context, reader models.Reader, _ int) ([]models.Recommendation, error) {
+	f.readers = append(f.readers, reader.ID)
+	return nil, nil
+}
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/app/models"
+	"github.com/go-chi/chi/v5"
+)
+
+// Config lets admins reload settings without restart.
+type Config struct {
+	resp responder
+}
+
+type configChange struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+func NewConfig(logger models.Logger, cfg *config.Reloadable) Config {
+	return Config{
+		resp: responder{Logger: logger, cfg: cfg},
+	}
+}
+
+func (c Config) Route(rtr chi.Router) {
+	rtr.With(c.resp.WithAuth, c.resp.WithAdmin).Post("/admin/config/reload", c.Reload)
+}
+
+// Reload re-reads config and responds with list of applied changes.
+func (c Config) Reload(rw http.ResponseWriter, req *http.Request) {
+	source := "admin"
+	if token := retrieveToken[models.AccessToken](req); token != nil {
+		source += ":" + token.ReaderID
+	}
+
+	changes, err := c.resp.cfg.Reload(source)
+	if err != nil {
+		c.resp.writeError(rw, req, err)
+		c.resp.logger(req).Errorw("Failed reloading config.", "error", err)
+
+		return
+	}
+
+	res := make([]configChange, len(changes))
+	for i, ch := range changes {
+		res[i] = configChange{Key: ch.Key, Old: ch.Old, New: ch.New}
+	}
+
+	c.resp.writeJSON(rw, req, http.StatusOK, struct {
+		Changes []configChange `json:"changes"`
+	}{res})
+	c.resp.logger(req).Debugf("Config reloaded successfully.")
+}
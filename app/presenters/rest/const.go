@@ -5,6 +5,13 @@ import "time"
 const bearer = "bearer"
 const accessTokenKey = "access_token"
 const refreshTokenKey = "refresh_token"
+
+// stateKey names cookie binding OpenID login to browser that started it,
+// it lives as long as state does in session storage.
+const (
+	stateKey    = "oidc_state"
+	stateExpiry = 10 * time.Minute
+)
 const xRequestID = "X-Request-ID"
 
 type contextKey int
@@ -15,3 +22,8 @@ const (
 )
 
 const queryTimeout = 3 * time.Second
+
+// suggestTimeout is short, suggestion arriving later is useless as user has typed further.
+const suggestTimeout = 500 * time.Millisecond
+
+const suggestCacheAge = time.Minute
@@ -9,3 +9,7 @@ var ErrInvalidData = errors.New("invalid data, expected nil")
 var ErrPermissions = errors.New("error permissions")
 var ErrInvalidQuery = errors.New("invalid query")
 var ErrNotAuthorized = errors.New("not authorized")
+var ErrTooManyRequests = errors.New("too many requests")
+var ErrNotAcceptable = errors.New("none of accepted media types can be produced")
+var ErrUnsupportedMediaType = errors.New("media type of request body is not supported")
+var ErrTooLarge = errors.New("request body is too large")
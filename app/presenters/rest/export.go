@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/cite"
+	"github.com/delveper/mylib/lib/marc"
+	"github.com/delveper/mylib/lib/tabular"
+)
+
+// exportMediaTypes maps media types accepted by export to formats.
+var exportMediaTypes = map[string]string{
+	"text/csv":             tabular.FormatCSV,
+	"application/jsonl":    tabular.FormatJSONL,
+	"application/x-ndjson": tabular.FormatJSONL,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": tabular.FormatXLSX,
+	"application/vnd.apache.parquet":                                    tabular.FormatParquet,
+	"application/marc":                                                  marc.FormatISO2709,
+	"application/marcxml+xml":                                           marc.FormatXML,
+	"application/x-bibtex":                                              cite.FormatBibTeX,
+	"application/x-research-info-systems":                               cite.FormatRIS,
+	"application/vnd.citationstyles.csl+json":                           cite.FormatCSL,
+	"application/dc+xml":                                                cite.FormatDC,
+}
+
+// exportFileType returns media type and file extension of export format.
+func exportFileType(format string) (contentType, ext string, ok bool) {
+	if contentType, ok := tabular.ContentTypes[format]; ok {
+		return contentType, format, true
+	}
+
+	if contentType, ok := marc.ContentTypes[format]; ok {
+		return contentType, format, true
+	}
+
+	if contentType, ok := cite.ContentTypes[format]; ok {
+		return contentType, cite.Extensions[format], true
+	}
+
+	return "", "", false
+}
+
+// negotiateFormat picks export format by $format option first and Accept header second.
+// Media types are tried in order they are listed, wildcard and missing header select CSV.
+func negotiateFormat(req *http.Request) (string, bool) {
+	if format := req.URL.Query().Get(models.OptionFormat); format != "" {
+		format = strings.ToLower(format)
+		_, _, ok := exportFileType(format)
+
+		return format, ok
+	}
+
+	accept := req.Header.Get("Accept")
+	if accept == "" {
+		return tabular.FormatCSV, true
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+
+		switch mediaType {
+		case "*/*", "text/*":
+			return tabular.FormatCSV, true
+		}
+
+		if format, ok := exportMediaTypes[mediaType]; ok {
+			return format, true
+		}
+	}
+
+	return "", false
+}
+
+// negotiateCitation tells whether book is requested as citation by $format option or Accept header.
+// JSON stays default, so citation is picked only if its media type precedes JSON and wildcards.
+func negotiateCitation(req *http.Request) (string, bool) {
+	if format := req.URL.Query().Get(models.OptionFormat); format != "" {
+		format = strings.ToLower(format)
+		_, ok := cite.ContentTypes[format]
+
+		return format, ok
+	}
+
+	for _, part := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+
+		switch mediaType {
+		case "application/json", "application/*", "*/*":
+			return "", false
+		}
+
+		if format, ok := exportMediaTypes[mediaType]; ok {
+			if _, ok := cite.ContentTypes[format]; ok {
+				return format, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// exportWriter sets headers of attachment right before first bytes are sent,
+// so that error occurred before that can still be rendered as problem.
+type exportWriter struct {
+	http.ResponseWriter
+	name    string
+	format  string
+	started bool
+}
+
+func (ew *exportWriter) Write(p []byte) (int, error) {
+	if !ew.started {
+		ew.started = true
+
+		header := ew.Header()
+		contentType, ext, _ := exportFileType(ew.format)
+
+		header.Set("Content-Type", contentType)
+		header.Set("Content-Disposition", "attachment; filename="+ew.name+"."+ext)
+		ew.WriteHeader(http.StatusOK)
+	}
+
+	return ew.ResponseWriter.Write(p)
+}
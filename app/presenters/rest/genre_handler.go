@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/app/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type Genre struct {
+	logic GenreLogic
+	resp  responder
+}
+
+func NewGenre(logic GenreLogic, logger models.Logger, cfg *config.Reloadable) Genre {
+	return Genre{
+		logic: logic,
+		resp:  responder{Logger: logger, cfg: cfg},
+	}
+}
+
+func (g Genre) Route(rtr chi.Router) {
+	rtr.With(g.resp.WithAuth).Route("/genres", func(rtr chi.Router) {
+		rtr.With(g.resp.WithPermission(models.PermissionReadBooks)).Get("/", g.FindAll)
+		rtr.With(g.resp.WithAdmin, g.resp.WithPermission(models.PermissionImportBooks)).Post("/", g.Create)
+		rtr.With(g.resp.WithAdmin, g.resp.WithPermission(models.PermissionImportBooks)).Post("/{id}/synonyms", g.AddSynonym)
+	})
+}
+
+// FindAll renders taxonomy of genres as tree.
+func (g Genre) FindAll(rw http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	genres, err := g.logic.Tree(ctx)
+	if err != nil {
+		g.resp.writeError(rw, req, err)
+		g.resp.logger(req).Errorw("Failed fetching genres.", "error", err)
+
+		return
+	}
+
+	g.resp.writeJSON(rw, req, http.StatusOK, genres)
+	g.resp.logger(req).Debugf("Genres fetched successfully.")
+}
+
+func (g Genre) Create(rw http.ResponseWriter, req *http.Request) {
+	var genre models.Genre
+	if err := g.resp.decodeBody(req, &genre); err != nil {
+		g.resp.writeError(rw, req, ErrDecoding)
+		g.resp.logger(req).Errorw("Failed decoding genre data from request.", "error", err)
+
+		return
+	}
+
+	genre.Normalize()
+
+	if err := genre.OK(); err != nil {
+		g.resp.writeError(rw, req, err)
+		g.resp.logger(req).Debugw("Failed validating genre.", "error", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	genre, err := g.logic.Create(ctx, genre)
+	if err != nil {
+		g.resp.writeError(rw, req, err)
+		g.resp.logger(req).Errorw("Failed creating genre.", "error", err)
+
+		return
+	}
+
+	g.resp.writeJSON(rw, req, http.StatusCreated, genre)
+	g.resp.logger(req).Debugf("Genre created successfully.")
+}
+
+// AddSynonym adds other name of genre, genre spelled as synonym is merged into genre.
+func (g Genre) AddSynonym(rw http.ResponseWriter, req *http.Request) {
+	var synonym models.GenreSynonym
+	if err := g.resp.decodeBody(req, &synonym); err != nil {
+		g.resp.writeError(rw, req, ErrDecoding)
+		g.resp.logger(req).Errorw("Failed decoding synonym data from request.", "error", err)
+
+		return
+	}
+
+	synonym.GenreID = chi.URLParam(req, "id")
+	synonym.Normalize()
+
+	if err := synonym.OK(); err != nil {
+		g.resp.writeError(rw, req, err)
+		g.resp.logger(req).Debugw("Failed validating synonym.", "error", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	if err := g.logic.AddSynonym(ctx, synonym); err != nil {
+		g.resp.writeError(rw, req, err)
+		g.resp.logger(req).Errorw("Failed adding genre synonym.", "error", err)
+
+		return
+	}
+
+	msg := response{Message: "Synonym added successfully."}
+	g.resp.writeJSON(rw, req, http.StatusCreated, msg)
+	g.resp.logger(req).Debugf(msg.Message)
+}
@@ -0,0 +1,168 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/delveper/mylib/app/models"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	statusOK   = "ok"
+	statusFail = "fail"
+)
+
+const (
+	healthCheckTimeout = 2 * time.Second
+	healthCacheTTL     = 5 * time.Second
+)
+
+// Check represents readiness check of single dependency.
+type Check struct {
+	Name string
+	Fn   func(context.Context) error
+}
+
+// componentStatus reports status and latency of dependency, its error is logged, not exposed.
+type componentStatus struct {
+	Status    string    `json:"status"`
+	LatencyMS float64   `json:"latency_ms"`
+	Error     error     `json:"-"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type healthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]componentStatus `json:"components,omitempty"`
+}
+
+// Health reports liveness and readiness of service.
+// Results of checks are cached to not overload dependencies with frequent probes.
+type Health struct {
+	checks   []Check
+	draining atomic.Bool
+	mu       sync.Mutex
+	cache    map[string]componentStatus
+	resp     responder
+}
+
+func NewHealth(logger models.Logger, checks ...Check) *Health {
+	return &Health{
+		checks: checks,
+		cache:  make(map[string]componentStatus, len(checks)),
+		resp:   responder{Logger: logger},
+	}
+}
+
+func (h *Health) Route(rtr chi.Router) {
+	rtr.Get("/healthz", h.Live)
+	rtr.Get("/readyz", h.Ready)
+}
+
+// Drain makes readiness fail, so that orchestrator stops routing traffic
+// to instance while in-flight requests are finishing.
+func (h *Health) Drain() {
+	h.draining.Store(true)
+	h.resp.Infof("Readiness switched to failing due shutdown.")
+}
+
+// Live reports that process is up and able to serve requests.
+func (h *Health) Live(rw http.ResponseWriter, req *http.Request) {
+	h.resp.writeJSON(rw, req, http.StatusOK, healthReport{Status: statusOK})
+}
+
+// Ready reports state of every dependency.
+func (h *Health) Ready(rw http.ResponseWriter, req *http.Request) {
+	report := healthReport{
+		Status:     statusOK,
+		Components: h.run(req.Context()),
+	}
+
+	for _, c := range report.Components {
+		if c.Status != statusOK {
+			report.Status = statusFail
+		}
+	}
+
+	if h.draining.Load() {
+		report.Status = statusFail
+	}
+
+	code := http.StatusOK
+	if report.Status != statusOK {
+		code = http.StatusServiceUnavailable
+		h.resp.logger(req).Warnw("Service is not ready.", "draining", h.draining.Load())
+
+		for name, c := range report.Components {
+			if c.Error != nil {
+				h.resp.logger(req).Errorw("Failed readiness check.", "component", name, "latency_ms", c.LatencyMS, "error", c.Error)
+			}
+		}
+	}
+
+	rw.Header().Set("Cache-Control", "no-store")
+	h.resp.writeJSON(rw, req, code, report)
+}
+
+// run runs checks concurrently skipping ones with fresh cached result.
+func (h *Health) run(ctx context.Context) map[string]componentStatus {
+	res := make(map[string]componentStatus, len(h.checks))
+
+	var wg sync.WaitGroup
+
+	for _, check := range h.checks {
+		h.mu.Lock()
+		cached, ok := h.cache[check.Name]
+		fresh := ok && time.Since(cached.CheckedAt) < healthCacheTTL
+		if fresh {
+			res[check.Name] = cached
+		}
+		h.mu.Unlock()
+
+		if fresh {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(check Check) {
+			defer wg.Done()
+
+			status := runCheck(ctx, check)
+
+			h.mu.Lock()
+			h.cache[check.Name] = status
+			res[check.Name] = status
+			h.mu.Unlock()
+		}(check)
+	}
+
+	wg.Wait()
+
+	return res
+}
+
+func runCheck(ctx context.Context, check Check) componentStatus {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Fn(ctx)
+
+	status := componentStatus{
+		Status:    statusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: time.Now(),
+	}
+
+	if err != nil {
+		status.Status = statusFail
+		status.Error = err
+	}
+
+	return status
+}
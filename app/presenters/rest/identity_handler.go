@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type Identity struct {
+	logic IdentityLogic
+	resp  responder
+}
+
+func NewIdentity(logic IdentityLogic, logger models.Logger, cfg *config.Reloadable) Identity {
+	return Identity{
+		logic: logic,
+		resp:  responder{Logger: logger, cfg: cfg},
+	}
+}
+
+func (i Identity) Route(rtr chi.Router) {
+	rtr.Route("/readers/oidc", func(rtr chi.Router) {
+		rtr.Get("/login", i.Login)
+		rtr.Get("/callback", i.Callback)
+	})
+}
+
+// Login redirects reader to external identity provider.
+// State is set in cookie, so that callback is accepted only in browser that started login.
+func (i Identity) Login(rw http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	uri, state, err := i.logic.Begin(ctx)
+	if err != nil {
+		i.resp.writeError(rw, req, err)
+		i.resp.logger(req).Errorw("Failed starting external login.", "error", err)
+
+		return
+	}
+
+	i.resp.setCookie(rw, stateKey, state, stateExpiry, "/readers/oidc")
+
+	http.Redirect(rw, req, uri, http.StatusFound)
+	i.resp.logger(req).Debugf("Reader redirected to identity provider.")
+}
+
+// Callback handles redirect back from identity provider.
+func (i Identity) Callback(rw http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+
+	if e := q.Get("error"); e != "" {
+		i.resp.writeError(rw, req, ErrNotAuthorized)
+		i.resp.logger(req).Debugw("Identity provider denied login.",
+			"error", e,
+			"error_description", q.Get("error_description"))
+
+		return
+	}
+
+	i.resp.setCookie(rw, stateKey, "", -1, "/readers/oidc")
+
+	state := q.Get("state")
+
+	cookie, err := req.Cookie(stateKey)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		i.resp.writeError(rw, req, exceptions.ErrInvalidState)
+		i.resp.logger(req).Infow("Failed matching state of external login with cookie.", "error", exceptions.ErrInvalidState)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), 3*queryTimeout)
+	defer cancel()
+
+	tokenPair, err := i.logic.Complete(ctx, state, q.Get("code"))
+	if err != nil {
+		i.resp.writeError(rw, req, err)
+		i.resp.logger(req).Debugw("Failed external login.", "error", err)
+
+		return
+	}
+
+	i.resp.setCookie(rw, refreshTokenKey, tokenPair.RefreshToken, tokenPair.ExpiresIn, "auth")
+
+	i.resp.writeJSON(rw, req, http.StatusOK, tokenPair)
+	i.resp.logger(req).Debugf("Reader authorized through identity provider successfully.")
+}
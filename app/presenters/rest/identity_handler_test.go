@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/banderlog"
+	"github.com/go-chi/chi/v5"
+)
+
+// TestIdentityCallbackState checks that callback is accepted only in browser that started login.
+func TestIdentityCallbackState(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		want   int
+	}{
+		{name: "same browser", cookie: "state", want: http.StatusOK},
+		{name: "no cookie", want: http.StatusBadRequest},
+		{name: "another login", cookie: "another", want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logic := &fakeIdentityLogic{}
+
+			rtr := chi.NewRouter()
+			NewIdentity(logic, banderlog.New(banderlog.Config{Level: banderlog.ErrorLevel, Console: banderlog.ConsoleOff}), testConfig()).Route(rtr)
+
+			rec := httptest.NewRecorder()
+			rtr.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readers/oidc/login", nil))
+
+			if rec.Code != http.StatusFound {
+				t.Fatalf("login: got status %d, want %d", rec.Code, http.StatusFound)
+			}
+
+			var cookie *http.Cookie
+			for _, c := range rec.Result().Cookies() {
+				if c.Name == stateKey {
+					cookie = c
+				}
+			}
+
+			if cookie == nil || cookie.Value != "state" || !cookie.HttpOnly {
+				t.Fatalf("login: got state cookie %+v, want HttpOnly one holding state", cookie)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/readers/oidc/callback?state=state&code=code", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: stateKey, Value: tt.cookie})
+			}
+
+			rec = httptest.NewRecorder()
+			rtr.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("callback: got status %d, want %d", rec.Code, tt.want)
+			}
+
+			if completed := logic.completed > 0; completed != (tt.want == http.StatusOK) {
+				t.Errorf("callback: login completed %d times", logic.completed)
+			}
+		})
+	}
+}
+
+type fakeIdentityLogic struct {
+	completed int
+}
+
+func (f *fakeIdentityLogic) Begin(context.Context) (string, string, error) {
+	return "https://provider.example.com/authorize?state=state", "state", nil
+}
+
+func (f *fakeIdentityLogic) Complete(context.Context, string, string) (*models.TokenPair, error) {
+	f.completed++
+
+	return &models.TokenPair{}, nil
+}
@@ -0,0 +1,289 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/marc"
+	"github.com/delveper/mylib/lib/onix"
+	"github.com/pkg/errors"
+)
+
+// importMediaTypes maps media types of import body to decoders.
+var importMediaTypes = map[string]func(io.Reader) ([]models.ImportRow, error){
+	"text/csv":                decodeCSVRows,
+	"application/jsonl":       decodeJSONRows,
+	"application/x-ndjson":    decodeJSONRows,
+	"application/marc":        decodeMARCRows(marc.FormatISO2709),
+	"application/marcxml+xml": decodeMARCRows(marc.FormatXML),
+	"application/xml":         decodeXMLRows,
+	"text/xml":                decodeXMLRows,
+}
+
+// decodeImport reads rows of import body in format given by Content-Type.
+// Rows that can not be parsed are returned with error, so that they are reported along with invalid ones.
+func decodeImport(rw http.ResponseWriter, req *http.Request, maxSize int64) ([]models.ImportRow, error) {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedMediaType, err)
+	}
+
+	decode, ok := importMediaTypes[mediaType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
+	}
+
+	body := http.MaxBytesReader(rw, req.Body, maxSize)
+	defer body.Close()
+
+	rows, err := decode(body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, fmt.Errorf("%w: %w", ErrTooLarge, err)
+		}
+
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// decodeCSVRows reads CSV with header naming columns as in export.
+// Columns id and author are produced by export only and are skipped.
+func decodeCSVRows(r io.Reader) ([]models.ImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: missing header", exceptions.ErrValidation)
+		}
+
+		return nil, fmt.Errorf("%w: %w", ErrDecoding, err)
+	}
+
+	known := make(map[string]bool, len(models.BookColumns))
+	for _, col := range models.BookColumns {
+		known[col] = true
+	}
+
+	header = append([]string(nil), header...)
+
+	for i, col := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(col))
+		if !known[header[i]] {
+			return nil, fmt.Errorf("%w: unknown column %q", exceptions.ErrValidation, col)
+		}
+	}
+
+	var rows []models.ImportRow
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		var parseErr *csv.ParseError
+
+		switch {
+		case errors.As(err, &parseErr) && !errors.Is(err, csv.ErrFieldCount):
+			return nil, fmt.Errorf("%w: %w", ErrDecoding, err)
+		case err != nil && !errors.Is(err, csv.ErrFieldCount):
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+		row := models.ImportRow{Line: line}
+
+		if err != nil {
+			row.Err = fmt.Errorf("%w: expected %d fields, got %d", exceptions.ErrValidation, len(header), len(record))
+			rows = append(rows, row)
+
+			continue
+		}
+
+		var vErr exceptions.ValidationError
+
+		for i, val := range record {
+			setBookColumn(&row.Book, header[i], val, &vErr)
+		}
+
+		row.Err = vErr.Err()
+		rows = append(rows, row)
+	}
+}
+
+func setBookColumn(book *models.Book, col, val string, vErr *exceptions.ValidationError) {
+	number := func() int {
+		n, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil {
+			vErr.Add(col, "type", "must be integer")
+		}
+
+		return n
+	}
+
+	switch col {
+	case "author_id":
+		book.AuthorID = val
+	case "title":
+		book.Title = val
+	case "isbn":
+		book.ISBN = val
+	case "genre":
+		book.Genre = val
+	case "rate":
+		book.Rate = number()
+	case "size":
+		book.Size = number()
+	case "year":
+		book.Year = number()
+	case "work_id":
+		book.WorkID = val
+	case "publisher":
+		book.Publisher = val
+	case "language":
+		book.Language = val
+	case "format":
+		book.Format = val
+	}
+}
+
+// decodeJSONRows reads one JSON book per line, blank lines are skipped.
+func decodeJSONRows(r io.Reader) ([]models.ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var rows []models.ImportRow
+
+	for line := 1; scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+
+		row := models.ImportRow{Line: line}
+
+		if err := json.Unmarshal(data, &row.Book); err != nil {
+			var typeErr *json.UnmarshalTypeError
+
+			if errors.As(err, &typeErr) {
+				var vErr exceptions.ValidationError
+				vErr.Add(typeErr.Field, "type", "must be "+typeErr.Type.String())
+				row.Err = &vErr
+			} else {
+				row.Err = fmt.Errorf("%w: malformed JSON", exceptions.ErrValidation)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecoding, err)
+	}
+
+	return rows, nil
+}
+
+// decodeMARCRows returns decoder of MARC records in given format, rows are numbered by records.
+// Malformed record fails whole body, since records that follow can not be located reliably.
+func decodeMARCRows(format string) func(io.Reader) ([]models.ImportRow, error) {
+	return func(r io.Reader) ([]models.ImportRow, error) {
+		mr, err := marc.NewReader(format, r)
+		if err != nil {
+			return nil, err
+		}
+
+		var rows []models.ImportRow
+
+		for line := 1; ; line++ {
+			rec, err := mr.Read()
+			if errors.Is(err, io.EOF) {
+				return rows, nil
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("%w: record %d: %w", ErrDecoding, line, err)
+			}
+
+			row := models.ImportRow{Line: line}
+			row.Book, row.Author = models.BookFromMARC(rec)
+
+			rows = append(rows, row)
+		}
+	}
+}
+
+// decodeXMLRows tells ONIX message from MARCXML by root element, as publishers send both as plain XML.
+// Bytes read to find root are replayed to decoder of the format.
+func decodeXMLRows(r io.Reader) ([]models.ImportRow, error) {
+	var head bytes.Buffer
+
+	dec := xml.NewDecoder(io.TeeReader(r, &head))
+
+	var root string
+
+	for root == "" {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrDecoding, err)
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			root = start.Name.Local
+		}
+	}
+
+	body := io.MultiReader(&head, r)
+
+	if root == "ONIXMessage" {
+		return decodeONIXRows(body)
+	}
+
+	return decodeMARCRows(marc.FormatXML)(body)
+}
+
+// decodeONIXRows reads products of ONIX message, rows are numbered by products.
+// Products replace books having the same ISBN, since feeds resend them on every change.
+func decodeONIXRows(r io.Reader) ([]models.ImportRow, error) {
+	or := onix.NewReader(r)
+
+	var rows []models.ImportRow
+
+	for line := 1; ; line++ {
+		product, err := or.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: product %d: %w", ErrDecoding, line, err)
+		}
+
+		row := models.ImportRow{Line: line, Replace: true}
+		row.Book, row.Author = models.BookFromONIX(product)
+
+		if product.NotificationType == onix.NotificationDelete {
+			var vErr exceptions.ValidationError
+			vErr.Add("notification_type", "enum", "deletion is not supported")
+			row.Err = &vErr
+		}
+
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,149 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+)
+
+// wantRow describes row expected from decoder, field and rule are set for rows failing validation of field.
+type wantRow struct {
+	line  int
+	err   bool
+	field string
+	rule  string
+}
+
+func TestDecodeImportRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		decode  func(io.Reader) ([]models.ImportRow, error)
+		body    string
+		wantErr error
+		want    []wantRow
+	}{
+		{
+			name:   "csv valid",
+			decode: decodeCSVRows,
+			body:   "title,year\nDune,1965\n",
+			want:   []wantRow{{line: 2}},
+		},
+		{
+			name:   "csv field count",
+			decode: decodeCSVRows,
+			body:   "title,year\nDune\nSolaris,1961\nHyperion,1989,extra\n",
+			want:   []wantRow{{line: 2, err: true}, {line: 3}, {line: 4, err: true}},
+		},
+		{
+			name:   "csv integer",
+			decode: decodeCSVRows,
+			body:   "title,year\nDune,nineteen\n",
+			want:   []wantRow{{line: 2, err: true, field: "year", rule: "type"}},
+		},
+		{
+			name:    "csv unknown column",
+			decode:  decodeCSVRows,
+			body:    "title,color\nDune,red\n",
+			wantErr: exceptions.ErrValidation,
+		},
+		{
+			name:    "csv missing header",
+			decode:  decodeCSVRows,
+			wantErr: exceptions.ErrValidation,
+		},
+		{
+			name:    "csv malformed quote",
+			decode:  decodeCSVRows,
+			body:    "title,year\n\"Dune,1965\n",
+			wantErr: ErrDecoding,
+		},
+		{
+			name:   "jsonl type",
+			decode: decodeJSONRows,
+			body:   `{"title":"Dune","year":1965}` + "\n\n" + `{"title":"Solaris","year":"1961"}` + "\n",
+			want:   []wantRow{{line: 1}, {line: 3, err: true, field: "year", rule: "type"}},
+		},
+		{
+			name:   "jsonl malformed",
+			decode: decodeJSONRows,
+			body:   `{"title":"Dune"` + "\n" + `{"title":"Solaris"}` + "\n",
+			want:   []wantRow{{line: 1, err: true}, {line: 2}},
+		},
+		{
+			name:   "onix delete notice",
+			decode: decodeXMLRows,
+			body: `<ONIXMessage release="3.0">` +
+				onixProduct("03", "9780441013593", "Dune") +
+				onixProduct("05", "9780156027601", "Solaris") +
+				`</ONIXMessage>`,
+			want: []wantRow{{line: 1}, {line: 2, err: true, field: "notification_type", rule: "enum"}},
+		},
+		{
+			name:    "onix malformed",
+			decode:  decodeXMLRows,
+			body:    `<ONIXMessage><Product><NotificationType>03</Product></ONIXMessage>`,
+			wantErr: ErrDecoding,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := tt.decode(strings.NewReader(tt.body))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("decoding: %v", err)
+			}
+
+			if len(rows) != len(tt.want) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(tt.want))
+			}
+
+			for i, want := range tt.want {
+				row := rows[i]
+
+				if row.Line != want.line {
+					t.Errorf("row %d: got line %d, want %d", i, row.Line, want.line)
+				}
+
+				if (row.Err != nil) != want.err {
+					t.Errorf("row %d: got error %v, want error %v", i, row.Err, want.err)
+					continue
+				}
+
+				if row.Err != nil && !errors.Is(row.Err, exceptions.ErrValidation) {
+					t.Errorf("row %d: got error %v, want %v", i, row.Err, exceptions.ErrValidation)
+				}
+
+				if want.field == "" {
+					continue
+				}
+
+				var vErr *exceptions.ValidationError
+				if !errors.As(row.Err, &vErr) || len(vErr.Fields) != 1 ||
+					vErr.Fields[0].Field != want.field || vErr.Fields[0].Rule != want.rule {
+					t.Errorf("row %d: got error %v, want %s of %s", i, row.Err, want.rule, want.field)
+				}
+			}
+		})
+	}
+}
+
+func onixProduct(notification, isbn, title string) string {
+	return `<Product>` +
+		`<RecordReference>` + isbn + `</RecordReference>` +
+		`<NotificationType>` + notification + `</NotificationType>` +
+		`<ProductIdentifier><ProductIDType>15</ProductIDType><IDValue>` + isbn + `</IDValue></ProductIdentifier>` +
+		`<DescriptiveDetail><TitleDetail><TitleType>01</TitleType><TitleElement><TitleText>` + title + `</TitleText></TitleElement></TitleDetail></DescriptiveDetail>` +
+		`</Product>`
+}
@@ -0,0 +1,105 @@
+package rest
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/tokay"
+)
+
+// WithRateLimit limits requests of every caller according to quota of its class.
+// Callers are identified by reader or client ID of valid access token, otherwise by IP.
+// When limiter itself fails request is let through.
+// Quotas are taken from current config, so reloaded ones apply from next request.
+func WithRateLimit(limiter RateLimiter, costs map[string]int, cfg *config.Reloadable, logger models.Logger) func(http.Handler) http.Handler {
+	resp := responder{Logger: logger, cfg: cfg}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			class, key := resp.identifyCaller(req)
+
+			quota, ok := cfg.Current().RateLimit.Quotas()[class]
+			if !ok {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			cost, ok := costs[req.Method+" "+strings.TrimSuffix(req.URL.Path, "/")]
+			if !ok {
+				cost = 1
+			}
+
+			limit, err := limiter.Allow(req.Context(), class+":"+key, quota, cost)
+			if err != nil {
+				resp.logger(req).Errorw("Failed checking rate limit.", "error", err)
+				next.ServeHTTP(rw, req)
+
+				return
+			}
+
+			header := rw.Header()
+			header.Set("RateLimit-Limit", fmt.Sprintf("%d", limit.Limit))
+			header.Set("RateLimit-Remaining", fmt.Sprintf("%d", limit.Remaining))
+			header.Set("RateLimit-Reset", fmt.Sprintf("%d", seconds(limit.Reset.Seconds())))
+
+			if !limit.Allowed {
+				header.Set("Retry-After", fmt.Sprintf("%d", seconds(limit.RetryAfter.Seconds())))
+				resp.writeError(rw, req, ErrTooManyRequests)
+				resp.logger(req).Infow("Rate limit exceeded.",
+					"caller", class,
+					"key", key,
+					"cost", cost)
+
+				return
+			}
+
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+// identifyCaller returns class and key of caller.
+// Token is only parsed here, its validity is checked by WithAuth later.
+func (r responder) identifyCaller(req *http.Request) (class, key string) {
+	if val := retrieveJWT(req); val != "" {
+		token, err := tokay.Parse[models.AccessToken](val, r.cfg.Current().JWT.Key)
+		if err == nil {
+			switch {
+			case token.ClientID != "" && token.ReaderID == "":
+				return models.CallerClient, token.ClientID
+			case strings.TrimSpace(token.Role) == "admin":
+				return models.CallerAdmin, token.ReaderID
+			case token.ReaderID != "":
+				return models.CallerReader, token.ReaderID
+			}
+		}
+	}
+
+	return models.CallerAnonymous, remoteIP(req, r.cfg.Current().Server.TrustProxy)
+}
+
+// remoteIP takes client IP from X-Forwarded-For only behind trusted proxy.
+func remoteIP(req *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if fwd := req.Header.Get("X-Forwarded-For"); fwd != "" {
+			ip, _, _ := strings.Cut(fwd, ",")
+			return strings.TrimSpace(ip)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
+
+func seconds(s float64) int {
+	return int(math.Ceil(s))
+}
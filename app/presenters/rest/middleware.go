@@ -3,13 +3,17 @@ package rest
 import (
 	"context"
 	"net/http"
-	"os"
+	"time"
 
 	"github.com/delveper/mylib/app/exceptions"
 	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/banderlog"
 	"github.com/delveper/mylib/lib/tokay"
+	"github.com/delveper/mylib/lib/tracer"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
-	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 )
 
 func ChainMiddlewares(hdl http.Handler, mds ...func(http.Handler) http.Handler) http.Handler {
@@ -30,7 +34,8 @@ func WithContextKey(key any, val any) func(http.Handler) http.Handler {
 	}
 }
 
-// WithLogRequest logs every request and sends logger instance to further handler.
+// WithLogRequest logs every request and sends request-scoped logger to further handler.
+// Logger carries request ID and trace IDs, so must be set after WithTracing.
 func WithLogRequest(logger models.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
@@ -40,17 +45,87 @@ func WithLogRequest(logger models.Logger) func(http.Handler) http.Handler {
 			}
 
 			req.Header.Set(xRequestID, id)
-			next = WithContextKey(requestContextKey, id)(next)
 
-			logger.Debugw("Request:",
-				"id", id,
+			keyVal := []any{"request_id", id}
+			if traceID, spanID := tracer.IDs(req.Context()); traceID != "" {
+				keyVal = append(keyVal, "trace_id", traceID, "span_id", spanID)
+			}
+
+			reqLogger := logger.With(keyVal...)
+
+			ctx := context.WithValue(req.Context(), requestContextKey, id)
+			ctx = banderlog.ToContext(ctx, reqLogger)
+
+			reqLogger.Debugw("Request:",
 				"method", req.Method,
 				"uri", req.RequestURI,
 				"user-agent", req.UserAgent(),
 				"remote", req.RemoteAddr,
 			)
 
-			next.ServeHTTP(rw, req)
+			next.ServeHTTP(rw, req.WithContext(ctx))
+		})
+	}
+}
+
+// WithMetrics records duration of every request labeled by chi route pattern and status.
+// Route context is created beforehand, so router fills it in and pattern is known after request.
+func WithMetrics(metrics HTTPMetrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+
+			rctx := chi.NewRouteContext()
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			rec := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+
+			next.ServeHTTP(rec, req)
+
+			route := rctx.RoutePattern()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			metrics.ObserveRequest(req.Method, route, rec.status, time.Since(start))
+		})
+	}
+}
+
+// WithTracing starts server span for every request continuing trace
+// from incoming traceparent header if there is one.
+// Span is named after chi route pattern, so must be set after WithMetrics or given route context.
+func WithTracing() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			ctx := tracer.Extract(req.Context(), req.Header)
+
+			rctx := chi.RouteContext(ctx)
+			if rctx == nil {
+				rctx = chi.NewRouteContext()
+				ctx = context.WithValue(ctx, chi.RouteCtxKey, rctx)
+			}
+
+			ctx, span := tracer.Start(ctx, "HTTP "+req.Method,
+				semconv.HTTPMethodKey.String(req.Method),
+				semconv.HTTPTargetKey.String(req.URL.Path),
+				semconv.HTTPUserAgentKey.String(req.UserAgent()),
+			)
+			defer span.End()
+
+			rec := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+
+			next.ServeHTTP(rec, req.WithContext(ctx))
+
+			if route := rctx.RoutePattern(); route != "" {
+				span.SetName(req.Method + " " + route)
+				span.SetAttributes(semconv.HTTPRouteKey.String(route))
+			}
+
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(rec.status))
+
+			if rec.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rec.status))
+			}
 		})
 	}
 }
@@ -59,49 +134,67 @@ func WithLogRequest(logger models.Logger) func(http.Handler) http.Handler {
 func (r responder) WithAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		val := retrieveJWT(req)
-		key := os.Getenv("JWT_KEY")
+		key := r.cfg.Current().JWT.Key
 
 		// TODO: Make logic token-stateful.
 		token, err := tokay.Parse[models.AccessToken](val, key)
 		if err != nil {
-			switch {
-			case errors.Is(err, exceptions.ErrTokenExpired),
-				errors.Is(err, exceptions.ErrTokenInvalid),
-				errors.Is(err, exceptions.ErrTokenNotFound),
-				errors.Is(err, exceptions.ErrTokenInvalidSigningMethod):
-				r.writeJSON(rw, req, http.StatusUnauthorized, err)
-			default:
-				r.writeJSON(rw, req, http.StatusBadRequest, err)
-			}
+			r.writeError(rw, req, err)
+			r.logger(req).Errorw("Failed to validate token.", "error", err)
+
+			return
+		}
+
+		reqLogger := banderlog.FromContext(req.Context(), r.Logger).With("reader_id", token.ReaderID)
+		reqLogger.Debugw("Token validated.", "client_id", token.ClientID)
+
+		ctx := context.WithValue(req.Context(), tokenContextKey, token)
+		ctx = banderlog.ToContext(ctx, reqLogger)
 
-			r.Errorw("Failed to validate token.",
-				"access_token", val,
-				"error", err)
+		next.ServeHTTP(rw, req.WithContext(ctx))
+	})
+}
+
+// WithReader checks that token was issued to reader directly,
+// so that session of reader can not be managed with tokens of third-party clients.
+func (r responder) WithReader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		token := retrieveToken[models.AccessToken](req)
+
+		if token == nil {
+			r.writeError(rw, req, exceptions.ErrTokenNotFound)
+			r.logger(req).Errorw("Failed retrieve token from context.", "error", exceptions.ErrTokenNotFound)
 
 			return
 		}
 
-		r.Debugw("Token validated.", "token", token)
+		if token.ClientID != "" || token.ReaderID == "" {
+			r.writeError(rw, req, ErrPermissions)
+			r.logger(req).Infow("Failed check reader token.", "client_id", token.ClientID, "error", ErrPermissions)
+
+			return
+		}
 
-		next = WithContextKey(tokenContextKey, token)(next)
 		next.ServeHTTP(rw, req)
 	})
 }
 
+// WithAdmin checks if token belongs to admin. Tokens issued to third-party clients
+// are never admin ones, even if they act on behalf of admin.
 func (r responder) WithAdmin(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		token := retrieveToken[models.AccessToken](req)
 
 		if token == nil {
-			r.writeJSON(rw, req, http.StatusUnprocessableEntity, exceptions.ErrTokenNotFound)
-			r.Errorw("Failed retrieve token from context.", "error", exceptions.ErrTokenNotFound)
+			r.writeError(rw, req, exceptions.ErrTokenNotFound)
+			r.logger(req).Errorw("Failed retrieve token from context.", "error", exceptions.ErrTokenNotFound)
 
 			return
 		}
 
-		if token.Role != "admin" {
-			r.writeJSON(rw, req, http.StatusUnauthorized, ErrPermissions)
-			r.Infow("Failed check permissions.", "error", ErrPermissions)
+		if token.Role != "admin" || token.ClientID != "" {
+			r.writeError(rw, req, ErrPermissions)
+			r.logger(req).Infow("Failed check permissions.", "client_id", token.ClientID, "error", ErrPermissions)
 
 			return
 		}
@@ -110,6 +203,35 @@ func (r responder) WithAdmin(next http.Handler) http.Handler {
 	})
 }
 
+// WithPermission checks if token grants given permission.
+// Tokens issued to third-party clients are limited by scopes they were granted.
+func (r responder) WithPermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			token := retrieveToken[models.AccessToken](req)
+
+			if token == nil {
+				r.writeError(rw, req, exceptions.ErrTokenNotFound)
+				r.logger(req).Errorw("Failed retrieve token from context.", "error", exceptions.ErrTokenNotFound)
+
+				return
+			}
+
+			if !token.Permits(permission) {
+				r.writeError(rw, req, ErrPermissions)
+				r.logger(req).Infow("Failed check scope permissions.",
+					"client_id", token.ClientID,
+					"permission", permission,
+					"error", ErrPermissions)
+
+				return
+			}
+
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
 // WithoutPanic recovers from panic.
 func WithoutPanic(logger models.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
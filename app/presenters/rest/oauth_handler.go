@@ -0,0 +1,294 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type OAuth struct {
+	logic OAuthLogic
+	resp  responder
+}
+
+func NewOAuth(logic OAuthLogic, logger models.Logger, cfg *config.Reloadable) OAuth {
+	return OAuth{
+		logic: logic,
+		resp:  responder{Logger: logger, cfg: cfg},
+	}
+}
+
+// oauthError represents error response of token endpoint (RFC 6749 section 5.2).
+type oauthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// oauthErrorCodes are codes of problemKinds that RFC 6749 defines for token endpoint.
+var oauthErrorCodes = map[string]bool{
+	"invalid_request":        true,
+	"invalid_client":         true,
+	"invalid_grant":          true,
+	"unsupported_grant_type": true,
+	"invalid_scope":          true,
+	"access_denied":          true,
+}
+
+func (o OAuth) Route(rtr chi.Router) {
+	rtr.Route("/oauth", func(rtr chi.Router) {
+		rtr.With(o.resp.WithAuth).Get("/authorize", o.Consent)
+		rtr.With(o.resp.WithAuth).Post("/authorize", o.Authorize)
+		rtr.Post("/token", o.Token)
+		rtr.Post("/introspect", o.Introspect)
+		rtr.Post("/revoke", o.Revoke)
+		rtr.With(o.resp.WithAuth, o.resp.WithAdmin).Post("/clients", o.Register)
+	})
+}
+
+// Register registers third-party client.
+func (o OAuth) Register(rw http.ResponseWriter, req *http.Request) {
+	var client models.Client
+	if err := o.resp.decodeBody(req, &client); err != nil {
+		o.resp.writeError(rw, req, ErrDecoding)
+		o.resp.logger(req).Errorw("Failed decoding client data from request.", "error", err)
+
+		return
+	}
+
+	client.Normalize()
+
+	if err := client.OK(); err != nil {
+		o.resp.writeError(rw, req, err)
+		o.resp.logger(req).Debugw("Failed validating client.", "error", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	client, err := o.logic.Register(ctx, client)
+	if err != nil {
+		o.resp.writeError(rw, req, err)
+		o.resp.logger(req).Errorw("Failed registering client.", "error", err)
+
+		return
+	}
+
+	o.resp.writeJSON(rw, req, http.StatusCreated, client)
+	o.resp.logger(req).Debugf("Client registered successfully.")
+}
+
+// Consent renders data for consent screen of authorization request.
+func (o OAuth) Consent(rw http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	consent, err := o.logic.Consent(ctx, authRequestFromQuery(req.URL.Query()))
+	if err != nil {
+		o.resp.writeError(rw, req, err)
+		o.resp.logger(req).Debugw("Failed validating authorization request.", "error", err)
+
+		return
+	}
+
+	o.resp.writeJSON(rw, req, http.StatusOK, consent)
+	o.resp.logger(req).Debugf("Consent rendered successfully.")
+}
+
+// Authorize handles reader decision on consent screen.
+// Response contains URI reader agent has to be redirected to.
+func (o OAuth) Authorize(rw http.ResponseWriter, req *http.Request) {
+	var decision struct {
+		models.AuthRequest
+		Approve bool `json:"approve"`
+	}
+
+	if err := o.resp.decodeBody(req, &decision); err != nil {
+		o.resp.writeError(rw, req, ErrDecoding)
+		o.resp.logger(req).Errorw("Failed decoding consent decision from request.", "error", err)
+
+		return
+	}
+
+	token := retrieveToken[models.AccessToken](req)
+	if token == nil {
+		o.resp.writeError(rw, req, exceptions.ErrUnexpected)
+		o.resp.logger(req).Errorf("Failed retrieve token from context.")
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	authorize := o.logic.Deny
+	if decision.Approve {
+		authorize = o.logic.Authorize
+	}
+
+	redirect, err := authorize(ctx, *token, decision.AuthRequest)
+	if err != nil {
+		o.resp.writeError(rw, req, err)
+		o.resp.logger(req).Debugw("Failed handling authorization decision.", "error", err)
+
+		return
+	}
+
+	resp := struct {
+		RedirectTo string `json:"redirect_to"`
+	}{
+		RedirectTo: redirect,
+	}
+
+	o.resp.writeJSON(rw, req, http.StatusOK, resp)
+	o.resp.logger(req).Debugf("Authorization decision handled successfully.")
+}
+
+// Token handles token endpoint.
+func (o OAuth) Token(rw http.ResponseWriter, req *http.Request) {
+	tokenReq, err := tokenRequestFromForm(req)
+	if err != nil {
+		o.writeTokenError(rw, req, exceptions.ErrInvalidRequest)
+		o.resp.logger(req).Debugw("Failed parsing token request.", "error", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	tokenPair, err := o.logic.Exchange(ctx, tokenReq)
+	if err != nil {
+		o.writeTokenError(rw, req, err)
+		o.resp.logger(req).Debugw("Failed exchanging grant.",
+			"grant_type", tokenReq.GrantType,
+			"client_id", tokenReq.ClientID,
+			"error", err)
+
+		return
+	}
+
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.Header().Set("Pragma", "no-cache")
+
+	o.resp.writeJSON(rw, req, http.StatusOK, tokenPair)
+	o.resp.logger(req).Debugf("Token issued successfully.")
+}
+
+// Introspect handles token introspection endpoint (RFC 7662).
+func (o OAuth) Introspect(rw http.ResponseWriter, req *http.Request) {
+	tokenReq, err := tokenRequestFromForm(req)
+	if err != nil {
+		o.writeTokenError(rw, req, exceptions.ErrInvalidRequest)
+		o.resp.logger(req).Debugw("Failed parsing introspection request.", "error", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	info, err := o.logic.Introspect(ctx, tokenReq, req.PostForm.Get("token"))
+	if err != nil {
+		o.writeTokenError(rw, req, err)
+		o.resp.logger(req).Debugw("Failed introspecting token.", "client_id", tokenReq.ClientID, "error", err)
+
+		return
+	}
+
+	o.resp.writeJSON(rw, req, http.StatusOK, info)
+	o.resp.logger(req).Debugf("Token introspected successfully.")
+}
+
+// Revoke handles token revocation endpoint (RFC 7009).
+func (o OAuth) Revoke(rw http.ResponseWriter, req *http.Request) {
+	tokenReq, err := tokenRequestFromForm(req)
+	if err != nil {
+		o.writeTokenError(rw, req, exceptions.ErrInvalidRequest)
+		o.resp.logger(req).Debugw("Failed parsing revocation request.", "error", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	if err := o.logic.Revoke(ctx, tokenReq, req.PostForm.Get("token")); err != nil {
+		o.writeTokenError(rw, req, err)
+		o.resp.logger(req).Debugw("Failed revoking token.", "client_id", tokenReq.ClientID, "error", err)
+
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+	o.resp.logger(req).Debugf("Token revoked successfully.")
+}
+
+// writeTokenError renders errors of token, introspection and revocation endpoints in format defined by RFC 6749.
+// Status and code are taken from problemKinds, errors having no OAuth code are reported as server_error.
+func (o OAuth) writeTokenError(rw http.ResponseWriter, req *http.Request, err error) {
+	kind := problemKindOf(err)
+
+	resp := oauthError{Error: "server_error"}
+	if oauthErrorCodes[kind.code] {
+		resp = oauthError{Error: kind.code, Description: kind.err.Error()}
+	}
+
+	if kind.code == "invalid_client" {
+		rw.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	o.resp.writeJSON(rw, req, kind.status, resp)
+}
+
+func authRequestFromQuery(q url.Values) models.AuthRequest {
+	return models.AuthRequest{
+		ResponseType:        q.Get("response_type"),
+		ClientID:            q.Get("client_id"),
+		RedirectURI:         q.Get("redirect_uri"),
+		Scope:               q.Get("scope"),
+		State:               q.Get("state"),
+		CodeChallenge:       q.Get("code_challenge"),
+		CodeChallengeMethod: q.Get("code_challenge_method"),
+	}
+}
+
+// tokenRequestFromForm parses form encoded request.
+// Client credentials are taken from basic auth header or form body.
+func tokenRequestFromForm(req *http.Request) (models.TokenRequest, error) {
+	if err := req.ParseForm(); err != nil {
+		return models.TokenRequest{}, err
+	}
+
+	form := req.PostForm
+
+	tokenReq := models.TokenRequest{
+		GrantType:    form.Get("grant_type"),
+		ClientID:     form.Get("client_id"),
+		ClientSecret: form.Get("client_secret"),
+		Code:         form.Get("code"),
+		RedirectURI:  form.Get("redirect_uri"),
+		CodeVerifier: form.Get("code_verifier"),
+		RefreshToken: form.Get("refresh_token"),
+		Scope:        form.Get("scope"),
+	}
+
+	if id, secret, ok := req.BasicAuth(); ok {
+		var err error
+		if tokenReq.ClientID, err = url.QueryUnescape(id); err != nil {
+			return models.TokenRequest{}, err
+		}
+
+		if tokenReq.ClientSecret, err = url.QueryUnescape(secret); err != nil {
+			return models.TokenRequest{}, err
+		}
+	}
+
+	return tokenReq, nil
+}
@@ -0,0 +1,148 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/banderlog"
+	"github.com/go-chi/chi/v5"
+)
+
+// TestOAuthErrors checks that only token endpoints answer in format of RFC 6749,
+// the rest answer with problem as any other endpoint does.
+func TestOAuthErrors(t *testing.T) {
+	cfg := testConfig()
+	access := signAccess(t, cfg, models.AccessToken{ReaderID: testReaderID, RefreshTokenID: testGrantID})
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		err         error
+		wantStatus  int
+		wantType    string
+		wantCode    string
+		wantWWWAuth bool
+	}{
+		{
+			name: "token invalid grant", method: http.MethodPost, path: "/oauth/token",
+			err:        fmt.Errorf("%w: %w", exceptions.ErrInvalidGrant, exceptions.ErrTokenNotFound),
+			wantStatus: http.StatusBadRequest, wantType: "application/json", wantCode: "invalid_grant",
+		},
+		{
+			name: "token invalid client", method: http.MethodPost, path: "/oauth/token",
+			err:        fmt.Errorf("%w: %w", exceptions.ErrInvalidClient, exceptions.ErrClientNotFound),
+			wantStatus: http.StatusUnauthorized, wantType: "application/json", wantCode: "invalid_client", wantWWWAuth: true,
+		},
+		{
+			name: "token unexpected", method: http.MethodPost, path: "/oauth/token",
+			err:        exceptions.ErrUnexpected,
+			wantStatus: http.StatusInternalServerError, wantType: "application/json", wantCode: "server_error",
+		},
+		{
+			name: "introspect invalid client", method: http.MethodPost, path: "/oauth/introspect",
+			err:        exceptions.ErrInvalidClient,
+			wantStatus: http.StatusUnauthorized, wantType: "application/json", wantCode: "invalid_client", wantWWWAuth: true,
+		},
+		{
+			name: "revoke invalid request", method: http.MethodPost, path: "/oauth/revoke",
+			err:        exceptions.ErrInvalidRequest,
+			wantStatus: http.StatusBadRequest, wantType: "application/json", wantCode: "invalid_request",
+		},
+		{
+			name: "consent invalid client", method: http.MethodGet, path: "/oauth/authorize?client_id=x",
+			err:        fmt.Errorf("%w: %w", exceptions.ErrInvalidClient, exceptions.ErrClientNotFound),
+			wantStatus: http.StatusUnauthorized, wantType: problemContentType, wantCode: "invalid_client",
+		},
+		{
+			name: "authorize access denied", method: http.MethodPost, path: "/oauth/authorize",
+			err:        exceptions.ErrAccessDenied,
+			wantStatus: http.StatusForbidden, wantType: problemContentType, wantCode: "access_denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rtr := chi.NewRouter()
+			NewOAuth(fakeOAuthLogic{err: tt.err}, banderlog.New(banderlog.Config{Level: banderlog.ErrorLevel, Console: banderlog.ConsoleOff}), cfg).Route(rtr)
+
+			body := "grant_type=refresh_token&client_id=client"
+			if tt.method == http.MethodPost && tt.path == "/oauth/authorize" {
+				body = `{"approve":true}`
+			}
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+access)
+			if strings.HasPrefix(body, "grant_type") {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+
+			rec := httptest.NewRecorder()
+			rtr.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.wantType) {
+				t.Errorf("got content type %q, want %q", got, tt.wantType)
+			}
+
+			if got := rec.Header().Get("WWW-Authenticate") != ""; got != tt.wantWWWAuth {
+				t.Errorf("got WWW-Authenticate %q", rec.Header().Get("WWW-Authenticate"))
+			}
+
+			var resp struct {
+				Error string `json:"error"`
+				Code  string `json:"code"`
+			}
+
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+
+			if got := resp.Error + resp.Code; got != tt.wantCode {
+				t.Errorf("got code %q, want %q", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+type fakeOAuthLogic struct {
+	err error
+}
+
+func (f fakeOAuthLogic) Register(context.Context, models.Client) (models.Client, error) {
+	return models.Client{}, f.err
+}
+
+func (f fakeOAuthLogic) Consent(context.Context, models.AuthRequest) (*models.Consent, error) {
+	return nil, f.err
+}
+
+func (f fakeOAuthLogic) Authorize(context.Context, models.AccessToken, models.AuthRequest) (string, error) {
+	return "", f.err
+}
+
+func (f fakeOAuthLogic) Deny(context.Context, models.AccessToken, models.AuthRequest) (string, error) {
+	return "", f.err
+}
+
+func (f fakeOAuthLogic) Exchange(context.Context, models.TokenRequest) (*models.TokenPair, error) {
+	return nil, f.err
+}
+
+func (f fakeOAuthLogic) Introspect(context.Context, models.TokenRequest, string) (*models.Introspection, error) {
+	return nil, f.err
+}
+
+func (f fakeOAuthLogic) Revoke(context.Context, models.TokenRequest, string) error {
+	return f.err
+}
@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/pkg/errors"
+)
+
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix makes type member of problem URI as RFC 7807 requires.
+const problemTypePrefix = "urn:mylib:problem:"
+
+// problem is error response body defined by RFC 7807.
+// Code is stable machine-readable counterpart of Type that clients can switch on.
+type problem struct {
+	Type      string                  `json:"type"`
+	Title     string                  `json:"title"`
+	Status    int                     `json:"status"`
+	Detail    string                  `json:"detail,omitempty"`
+	Instance  string                  `json:"instance,omitempty"`
+	Code      string                  `json:"code"`
+	RequestID string                  `json:"request_id,omitempty"`
+	Errors    []exceptions.FieldError `json:"errors,omitempty"`
+}
+
+// problemKind maps sentinel error to response.
+// Detail is always taken from sentinel, so that wrapped internal messages never reach client.
+type problemKind struct {
+	err    error
+	status int
+	code   string
+}
+
+// problemKinds is ordered from specific to generic, first match wins.
+var problemKinds = []problemKind{
+	{exceptions.ErrDeadline, http.StatusGatewayTimeout, "deadline_exceeded"},
+	{exceptions.ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{ErrTooLarge, http.StatusRequestEntityTooLarge, "body_too_large"},
+	{ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+	{ErrDecoding, http.StatusBadRequest, "malformed_body"},
+	{ErrInvalidQuery, http.StatusBadRequest, "invalid_query"},
+	{exceptions.ErrInvalidRequest, http.StatusBadRequest, "invalid_request"},
+	{exceptions.ErrInvalidClient, http.StatusUnauthorized, "invalid_client"},
+	{exceptions.ErrInvalidGrant, http.StatusBadRequest, "invalid_grant"},
+	{exceptions.ErrUnsupportedGrant, http.StatusBadRequest, "unsupported_grant_type"},
+	{exceptions.ErrInvalidScope, http.StatusBadRequest, "invalid_scope"},
+	{exceptions.ErrInvalidState, http.StatusBadRequest, "invalid_state"},
+	{exceptions.ErrIdentityNotVerified, http.StatusBadRequest, "identity_not_verified"},
+	{exceptions.ErrDuplicateEmail, http.StatusConflict, "email_taken"},
+	{exceptions.ErrDuplicateEdition, http.StatusConflict, "duplicate_edition"},
+	{exceptions.ErrDuplicateISBN, http.StatusConflict, "duplicate_isbn"},
+	{exceptions.ErrDuplicateID, http.StatusConflict, "duplicate_id"},
+	{exceptions.ErrRecordExists, http.StatusConflict, "already_exists"},
+	{config.ErrNotReloadable, http.StatusConflict, "not_reloadable"},
+	{exceptions.ErrBookNotFound, http.StatusNotFound, "book_not_found"},
+	{exceptions.ErrReaderNotFound, http.StatusNotFound, "reader_not_found"},
+	{exceptions.ErrClientNotFound, http.StatusNotFound, "client_not_found"},
+	{exceptions.ErrRecordNotFound, http.StatusNotFound, "not_found"},
+	{exceptions.ErrNoContent, http.StatusNotFound, "no_results"},
+	{exceptions.ErrInvalidCredits, http.StatusUnauthorized, "invalid_credentials"},
+	{ErrNotAuthorized, http.StatusUnauthorized, "not_authorized"},
+	{exceptions.ErrTokenExpired, http.StatusUnauthorized, "token_expired"},
+	{exceptions.ErrTokenInvalid, http.StatusUnauthorized, "token_invalid"},
+	{exceptions.ErrTokenInvalidSigningMethod, http.StatusUnauthorized, "token_invalid"},
+	{exceptions.ErrTokenNotFound, http.StatusUnauthorized, "token_missing"},
+	{ErrNotAcceptable, http.StatusNotAcceptable, "not_acceptable"},
+	{ErrPermissions, http.StatusForbidden, "forbidden"},
+	{exceptions.ErrAccessDenied, http.StatusForbidden, "access_denied"},
+	{ErrTooManyRequests, http.StatusTooManyRequests, "rate_limited"},
+	{exceptions.ErrTokenNotCreated, http.StatusBadGateway, "token_not_issued"},
+}
+
+var unexpectedProblem = problemKind{exceptions.ErrUnexpected, http.StatusInternalServerError, "internal_error"}
+
+func problemKindOf(err error) problemKind {
+	for _, kind := range problemKinds {
+		if errors.Is(err, kind.err) {
+			return kind
+		}
+	}
+
+	return unexpectedProblem
+}
+
+// writeError renders err as problem+json with status and code taken from problemKinds.
+// Errors that match no kind are reported as internal ones.
+func (r responder) writeError(rw http.ResponseWriter, req *http.Request, err error) {
+	kind := problemKindOf(err)
+
+	prob := problem{
+		Type:     problemTypePrefix + kind.code,
+		Title:    http.StatusText(kind.status),
+		Status:   kind.status,
+		Detail:   kind.err.Error(),
+		Instance: req.URL.Path,
+		Code:     kind.code,
+		Errors:   fieldErrors(err),
+	}
+
+	if id, ok := req.Context().Value(requestContextKey).(string); ok {
+		prob.RequestID = id
+	}
+
+	var buf bytes.Buffer
+
+	if err := json.NewEncoder(&buf).Encode(prob); err != nil {
+		r.logger(req).Errorw("Failed encoding problem to JSON.", "object", prob, "error", err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+
+		return
+	}
+
+	rw.Header().Set("Content-Type", problemContentType)
+	rw.WriteHeader(kind.status)
+
+	if _, err := buf.WriteTo(rw); err != nil {
+		r.logger(req).Errorw("Failed writing response from buffer.",
+			"object", prob,
+			"error", fmt.Errorf("%w: %w", ErrWritingResponse, err),
+		)
+	}
+}
+
+// fieldErrors lists invalid fields if err is validation one.
+func fieldErrors(err error) []exceptions.FieldError {
+	var vErr *exceptions.ValidationError
+	if !errors.As(err, &vErr) {
+		return nil
+	}
+
+	return vErr.Fields
+}
@@ -4,10 +4,10 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/delveper/mylib/app/config"
 	"github.com/delveper/mylib/app/exceptions"
 	"github.com/delveper/mylib/app/models"
 	"github.com/go-chi/chi/v5"
-	"github.com/pkg/errors"
 )
 
 type Reader struct {
@@ -15,10 +15,10 @@ type Reader struct {
 	resp  responder
 }
 
-func NewReader(logic ReaderLogic, logger models.Logger) Reader {
+func NewReader(logic ReaderLogic, logger models.Logger, cfg *config.Reloadable) Reader {
 	return Reader{
 		logic: logic,
-		resp:  responder{logger},
+		resp:  responder{Logger: logger, cfg: cfg},
 	}
 }
 
@@ -26,8 +26,8 @@ func (r Reader) Route(rtr chi.Router) {
 	rtr.Route("/readers", func(rtr chi.Router) {
 		rtr.Post("/signup", r.Register)
 		rtr.Post("/login", r.Login)
-		rtr.With(r.resp.WithAuth).Post("/token", r.Refresh)
-		rtr.With(r.resp.WithAuth).Post("/logout", r.Logout)
+		rtr.With(r.resp.WithAuth, r.resp.WithReader).Post("/token", r.Refresh)
+		rtr.With(r.resp.WithAuth, r.resp.WithReader).Post("/logout", r.Logout)
 	})
 }
 
@@ -35,15 +35,15 @@ func (r Reader) Route(rtr chi.Router) {
 func (r Reader) Register(rw http.ResponseWriter, req *http.Request) {
 	var reader models.Reader
 	if err := r.resp.decodeBody(req, &reader); err != nil {
-		r.resp.writeJSON(rw, req, http.StatusBadRequest, ErrDecoding)
-		r.resp.Errorw("Failed decoding reader data from request.", "error", err)
+		r.resp.writeError(rw, req, ErrDecoding)
+		r.resp.logger(req).Errorw("Failed decoding reader data from request.", "error", err)
 
 		return
 	}
 
 	if err := reader.OK(); err != nil {
-		r.resp.writeJSON(rw, req, http.StatusBadRequest, err)
-		r.resp.Debugw("Failed validating reader.", "error", err)
+		r.resp.writeError(rw, req, err)
+		r.resp.logger(req).Debugw("Failed validating reader.", "error", err)
 
 		return
 	}
@@ -51,43 +51,33 @@ func (r Reader) Register(rw http.ResponseWriter, req *http.Request) {
 	reader.Normalize()
 
 	if err := reader.HashPassword(); err != nil {
-		r.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrHashing)
-		r.resp.Errorw("Failed hashing readers password.", "error", err)
+		r.resp.writeError(rw, req, exceptions.ErrHashing)
+		r.resp.logger(req).Errorw("Failed hashing readers password.", "error", err)
 
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
 	defer cancel()
 
 	if err := r.logic.SignUp(ctx, reader); err != nil {
-		switch {
-		case errors.Is(err, exceptions.ErrDeadline):
-			r.resp.writeJSON(rw, req, http.StatusGatewayTimeout, exceptions.ErrDeadline)
-		case errors.Is(err, exceptions.ErrDuplicateEmail):
-			r.resp.writeJSON(rw, req, http.StatusConflict, exceptions.ErrDuplicateEmail)
-		case errors.Is(err, exceptions.ErrDuplicateID):
-			r.resp.writeJSON(rw, req, http.StatusConflict, exceptions.ErrDuplicateID)
-		default:
-			r.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
-		}
-
-		r.resp.Errorw("Failed creating reader.", "error", err)
+		r.resp.writeError(rw, req, err)
+		r.resp.logger(req).Errorw("Failed creating reader.", "error", err)
 
 		return
 	}
 
 	msg := response{Message: "Reader successfully created."}
 	r.resp.writeJSON(rw, req, http.StatusCreated, msg)
-	r.resp.Debugf(msg.Message)
+	r.resp.logger(req).Debugf(msg.Message)
 }
 
 // Login handles authorization process of created models.Reader.
 func (r Reader) Login(rw http.ResponseWriter, req *http.Request) {
 	var creds models.Credentials
 	if err := r.resp.decodeBody(req, &creds); err != nil {
-		r.resp.writeJSON(rw, req, http.StatusBadRequest, ErrDecoding)
-		r.resp.Errorw("Failed decoding reader data from request.", "error", err)
+		r.resp.writeError(rw, req, ErrDecoding)
+		r.resp.logger(req).Errorw("Failed decoding reader data from request.", "error", err)
 
 		return
 	}
@@ -95,117 +85,91 @@ func (r Reader) Login(rw http.ResponseWriter, req *http.Request) {
 	creds.Normalize()
 
 	if err := creds.OK(); err != nil {
-		r.resp.writeJSON(rw, req, http.StatusBadRequest, err)
-		r.resp.Debugf("Failed validating %T: %v", creds, err)
+		r.resp.writeError(rw, req, err)
+		r.resp.logger(req).Debugf("Failed validating %T: %v", creds, err)
 
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
 	defer cancel()
 
 	tokenPair, err := r.logic.SignIn(ctx, creds)
 	if err != nil {
-		switch {
-		case errors.Is(err, exceptions.ErrDeadline):
-			r.resp.writeJSON(rw, req, http.StatusGatewayTimeout, exceptions.ErrDeadline)
-		case errors.Is(err, exceptions.ErrTokenNotCreated):
-			r.resp.writeJSON(rw, req, http.StatusBadGateway, exceptions.ErrTokenNotCreated)
-		case errors.Is(err, exceptions.ErrRecordNotFound),
-			errors.Is(err, exceptions.ErrInvalidCredits):
-			r.resp.writeJSON(rw, req, http.StatusUnauthorized, ErrNotAuthorized)
-		default:
-			r.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
-		}
-
-		r.resp.Debugw("Failed signup reader.", "error", err)
+		r.resp.writeError(rw, req, err)
+		r.resp.logger(req).Debugw("Failed signup reader.", "error", err)
 
 		return
 	}
 
-	setCookie(rw, refreshTokenKey, tokenPair.RefreshToken, tokenPair.ExpiresIn, "auth")
+	r.resp.setCookie(rw, refreshTokenKey, tokenPair.RefreshToken, tokenPair.ExpiresIn, "auth")
 
 	r.resp.writeJSON(rw, req.WithContext(ctx), http.StatusOK, tokenPair)
-	r.resp.Debugf("Reader authorized successfully.")
+	r.resp.logger(req).Debugf("Reader authorized successfully.")
 }
 
 // Logout handles logout process.
 func (r Reader) Logout(rw http.ResponseWriter, req *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
 	defer cancel()
 
 	accessToken := retrieveToken[models.AccessToken](req)
 	if accessToken == nil {
-		r.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
-		r.resp.Errorf("Failed retrieve token from context.")
+		r.resp.writeError(rw, req, exceptions.ErrUnexpected)
+		r.resp.logger(req).Errorf("Failed retrieve token from context.")
 
 		return
 	}
 
 	if err := r.logic.SignOut(ctx, *accessToken); err != nil {
-		switch {
-		case errors.Is(err, exceptions.ErrDeadline):
-			r.resp.writeJSON(rw, req, http.StatusGatewayTimeout, exceptions.ErrDeadline)
-		case errors.Is(err, exceptions.ErrTokenExpired),
-			errors.Is(err, exceptions.ErrTokenInvalid),
-			errors.Is(err, exceptions.ErrTokenInvalidSigningMethod):
-			r.resp.writeJSON(rw, req, http.StatusUnauthorized, err)
-		default:
-			r.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
-		}
-
-		r.resp.Debugw("Failed signup reader.",
-			accessTokenKey, accessToken,
-			"error", err)
+		r.resp.writeError(rw, req, err)
+		r.resp.logger(req).Debugw("Failed logout reader.", "client_id", accessToken.ClientID, "error", err)
 
 		return
 	}
 
-	setCookie(rw, refreshTokenKey, "", -1, "")
+	r.resp.setCookie(rw, refreshTokenKey, "", -1, "")
 
 	msg := response{Message: "Reader logout successfully."}
 	r.resp.writeJSON(rw, req, http.StatusOK, msg)
-	r.resp.Debugf(msg.Message)
+	r.resp.logger(req).Debugf(msg.Message)
 }
 
 // Refresh handles process of token pair refreshment.
+// Refresh token is taken in signed form only, as it was issued.
 func (r Reader) Refresh(rw http.ResponseWriter, req *http.Request) {
-	var refreshToken models.RefreshToken
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := r.resp.decodeBody(req, &body); err != nil || body.RefreshToken == "" {
+		r.resp.writeError(rw, req, ErrDecoding)
+		r.resp.logger(req).Errorw("Failed decoding refresh token from request.", "error", err)
 
-	if err := r.resp.decodeBody(req, &refreshToken); err != nil {
-		r.resp.writeJSON(rw, req, http.StatusBadRequest, ErrDecoding)
-		r.resp.Errorw("Failed decoding refresh token from request.", "error", err)
+		return
+	}
+
+	accessToken := retrieveToken[models.AccessToken](req)
+	if accessToken == nil {
+		r.resp.writeError(rw, req, exceptions.ErrUnexpected)
+		r.resp.logger(req).Errorf("Failed retrieve token from context.")
 
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
 	defer cancel()
 
-	tokenPair, err := r.logic.Refresh(ctx, refreshToken)
+	tokenPair, err := r.logic.Refresh(ctx, *accessToken, body.RefreshToken)
 	if err != nil {
-		switch {
-		case errors.Is(err, exceptions.ErrDeadline):
-			r.resp.writeJSON(rw, req, http.StatusGatewayTimeout, exceptions.ErrDeadline)
-		case errors.Is(err, exceptions.ErrTokenNotFound):
-			r.resp.writeJSON(rw, req, http.StatusBadRequest, exceptions.ErrTokenNotFound)
-		case errors.Is(err, exceptions.ErrTokenNotCreated):
-			r.resp.writeJSON(rw, req, http.StatusBadGateway, exceptions.ErrTokenNotCreated)
-		case errors.Is(err, exceptions.ErrTokenInvalid):
-			r.resp.writeJSON(rw, req, http.StatusForbidden, exceptions.ErrTokenInvalid)
-		default:
-			r.resp.writeJSON(rw, req, http.StatusInternalServerError, exceptions.ErrUnexpected)
-		}
-
-		r.resp.Debugw("Failed refresh readers tokens.",
-			refreshTokenKey, refreshToken,
-			"error", err)
+		r.resp.writeError(rw, req, err)
+		r.resp.logger(req).Debugw("Failed refresh readers tokens.", "error", err)
 
 		return
 	}
 
-	setCookie(rw, refreshTokenKey, tokenPair.RefreshToken, tokenPair.ExpiresIn, "auth")
+	r.resp.setCookie(rw, refreshTokenKey, tokenPair.RefreshToken, tokenPair.ExpiresIn, "auth")
 
 	r.resp.writeJSON(rw, req.WithContext(ctx), http.StatusOK, tokenPair)
-	r.resp.Debugf("Readers tokens refreshed successfully.")
+	r.resp.logger(req).Debugf("Readers tokens refreshed successfully.")
 }
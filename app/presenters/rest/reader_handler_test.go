@@ -0,0 +1,199 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/banderlog"
+	"github.com/delveper/mylib/lib/tokay"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	testReaderID = "5b0f7a4e-3c1d-4e8f-9a2b-6c7d8e9f0a1b"
+	testGrantID  = "9e8d7c6b-5a4f-4e3d-8c2b-1a0f9e8d7c6b"
+)
+
+// TestReaderSessionLogs drives token checks, refresh and logout
+// and checks that neither tokens nor IDs of their grants are logged.
+func TestReaderSessionLogs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	logger := banderlog.New(banderlog.Config{Level: banderlog.DebugLevel, Console: banderlog.ConsoleOff, File: file})
+
+	cfg := testConfig()
+
+	access := signAccess(t, cfg, models.AccessToken{ReaderID: testReaderID, RefreshTokenID: testGrantID, Role: "reader"})
+	refresh := signRefresh(t, cfg, models.RefreshToken{ID: testGrantID, ReaderID: testReaderID})
+
+	logic := &fakeReaderLogic{pair: &models.TokenPair{AccessToken: access, RefreshToken: refresh}}
+
+	rtr := chi.NewRouter()
+	NewReader(logic, logger, cfg).Route(rtr)
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		fail bool
+		want int
+	}{
+		{name: "refresh", path: "/readers/token", body: `{"refresh_token":"` + refresh + `"}`, want: http.StatusOK},
+		{name: "failed refresh", path: "/readers/token", body: `{"refresh_token":"` + refresh + `"}`, fail: true, want: http.StatusUnauthorized},
+		{name: "logout", path: "/readers/logout", want: http.StatusOK},
+		{name: "failed logout", path: "/readers/logout", fail: true, want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		logic.fail = tt.fail
+
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Authorization", "Bearer "+access)
+
+		rec := httptest.NewRecorder()
+		rtr.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: got status %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("flushing logger: %v", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("reading log file: %v", err)
+	}
+
+	out := string(data)
+
+	for _, msg := range []string{"Token validated.", "Failed refresh readers tokens.", "Failed logout reader.", testReaderID} {
+		if !strings.Contains(out, msg) {
+			t.Errorf("log misses %q:\n%s", msg, out)
+		}
+	}
+
+	for name, secret := range map[string]string{"access token": access, "refresh token": refresh, "grant ID": testGrantID} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log contains %s:\n%s", name, out)
+		}
+	}
+}
+
+// TestReaderSessionRejectsClientTokens checks that tokens issued to OAuth clients
+// can not refresh or end session of reader.
+func TestReaderSessionRejectsClientTokens(t *testing.T) {
+	cfg := testConfig()
+
+	tokens := map[string]models.AccessToken{
+		"on behalf of reader": {ReaderID: testReaderID, RefreshTokenID: testGrantID, ClientID: "client", Scope: models.ScopeLibraryRead},
+		"client credentials":  {RefreshTokenID: testGrantID, ClientID: "client", Scope: models.ScopeLibraryRead},
+	}
+
+	for name, token := range tokens {
+		for _, path := range []string{"/readers/token", "/readers/logout"} {
+			t.Run(name+path, func(t *testing.T) {
+				logic := &fakeReaderLogic{pair: &models.TokenPair{}}
+
+				rtr := chi.NewRouter()
+				NewReader(logic, banderlog.New(banderlog.Config{Level: banderlog.ErrorLevel, Console: banderlog.ConsoleOff}), cfg).Route(rtr)
+
+				req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"refresh_token":"x"}`))
+				req.Header.Set("Authorization", "Bearer "+signAccess(t, cfg, token))
+
+				rec := httptest.NewRecorder()
+				rtr.ServeHTTP(rec, req)
+
+				if rec.Code != http.StatusForbidden {
+					t.Errorf("got status %d, want %d", rec.Code, http.StatusForbidden)
+				}
+
+				if logic.calls != 0 {
+					t.Errorf("logic was called %d times, want none", logic.calls)
+				}
+			})
+		}
+	}
+}
+
+func testConfig() *config.Reloadable {
+	return config.NewReloadable(&config.Config{JWT: config.JWT{
+		Alg:        "HS256",
+		Key:        "key",
+		AccessExp:  time.Minute,
+		RefreshExp: time.Hour,
+	}}, nil)
+}
+
+func signAccess(t *testing.T, cfg *config.Reloadable, token models.AccessToken) string {
+	t.Helper()
+
+	jwt := cfg.Current().JWT
+
+	val, err := tokay.Make[models.AccessToken](jwt.Alg, jwt.Key, jwt.AccessExp, token)
+	if err != nil {
+		t.Fatalf("making access token: %v", err)
+	}
+
+	return val
+}
+
+func signRefresh(t *testing.T, cfg *config.Reloadable, token models.RefreshToken) string {
+	t.Helper()
+
+	jwt := cfg.Current().JWT
+
+	val, err := tokay.Make[models.RefreshToken](jwt.Alg, jwt.Key, jwt.RefreshExp, token)
+	if err != nil {
+		t.Fatalf("making refresh token: %v", err)
+	}
+
+	return val
+}
+
+// fakeReaderLogic fails refresh with error quoting token it was given,
+// as careless lower layer could.
+type fakeReaderLogic struct {
+	pair  *models.TokenPair
+	fail  bool
+	calls int
+}
+
+func (f *fakeReaderLogic) Auth(context.Context, models.AccessToken) error { return nil }
+
+func (f *fakeReaderLogic) SignUp(context.Context, models.Reader) error { return nil }
+
+func (f *fakeReaderLogic) SignIn(context.Context, models.Credentials) (*models.TokenPair, error) {
+	return f.pair, nil
+}
+
+func (f *fakeReaderLogic) Refresh(_ context.Context, _ models.AccessToken, val string) (*models.TokenPair, error) {
+	f.calls++
+
+	if f.fail {
+		return nil, fmt.Errorf("%w: refresh token %s", exceptions.ErrTokenInvalid, val)
+	}
+
+	return f.pair, nil
+}
+
+func (f *fakeReaderLogic) SignOut(context.Context, models.AccessToken) error {
+	f.calls++
+
+	if f.fail {
+		return fmt.Errorf("error destroying access token: %w", exceptions.ErrTokenInvalid)
+	}
+
+	return nil
+}
@@ -6,21 +6,40 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/delveper/mylib/app/config"
 	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/banderlog"
+	"github.com/go-chi/chi/v5"
 )
 
 // responder designed to do all the heavy lifting on transport level.
-type responder struct{ models.Logger }
+type responder struct {
+	models.Logger
+	cfg *config.Reloadable
+}
 
 type response struct {
 	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+}
+
+// logger returns logger scoped to request, so that request ID, trace, reader and route
+// are present on every line. Route pattern is complete only after routing is done.
+func (r responder) logger(req *http.Request) models.Logger {
+	logger := banderlog.FromContext(req.Context(), r.Logger)
+
+	if rctx := chi.RouteContext(req.Context()); rctx != nil {
+		if route := rctx.RoutePattern(); route != "" {
+			logger = logger.With("route", route)
+		}
+	}
+
+	return logger
 }
 
 func (r responder) decodeBody(req *http.Request, data any) (err error) {
 	defer func() {
 		if e := req.Body.Close(); e != nil {
-			r.Errorw("error while closing request body", "error", err)
+			r.logger(req).Errorw("error while closing request body", "error", err)
 		}
 	}()
 
@@ -33,27 +52,23 @@ func (r responder) decodeBody(req *http.Request, data any) (err error) {
 
 func (r responder) writeJSON(rw http.ResponseWriter, req *http.Request, code int, data any) {
 	if data == nil && code != http.StatusNoContent {
-		r.Errorw("Failed writing response due nil data.",
+		r.logger(req).Errorw("Failed writing response due nil data.",
 			"object", nil,
 			"error", ErrInvalidData,
 		)
-		r.writeJSON(rw, req, http.StatusBadRequest, ErrInvalidData)
+		r.writeError(rw, req, ErrInvalidData)
 
 		return
 	}
 
-	if err, ok := data.(error); ok {
-		data = response{Message: http.StatusText(code), Details: err.Error()}
-	}
-
 	var buf bytes.Buffer
 
 	err := json.NewEncoder(&buf).Encode(data)
 	if err != nil {
-		r.Errorw("Failed encoding data to JSON.",
+		r.logger(req).Errorw("Failed encoding data to JSON.",
 			"object", data,
 			"error", err)
-		r.writeJSON(rw, req, http.StatusInternalServerError, ErrEncoding)
+		r.writeError(rw, req, ErrEncoding)
 
 		return
 	}
@@ -62,7 +77,7 @@ func (r responder) writeJSON(rw http.ResponseWriter, req *http.Request, code int
 	rw.WriteHeader(code)
 
 	if _, err := buf.WriteTo(rw); err != nil {
-		r.Errorw("Failed writing response from buffer.",
+		r.logger(req).Errorw("Failed writing response from buffer.",
 			"object", data,
 			"error", fmt.Errorf("%w: %w", ErrWritingResponse, err),
 		)
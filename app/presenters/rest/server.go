@@ -1,63 +1,205 @@
 package rest
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
+	"net/http"
+	"time"
 
+	"github.com/delveper/mylib/app/config"
+	"github.com/pkg/errors"
 	"golang.org/x/crypto/acme/autocert"
+)
 
-	"net/http"
-	"os"
-	"time"
+// TLS modes server can run in.
+const (
+	TLSOff  = "off"
+	TLSFile = "file"
+	TLSACME = "acme"
 )
 
-type Server struct{ *http.Server }
+const defaultDrainTimeout = 15 * time.Second
 
-func NewServer(hdl http.Handler) (*Server, error) {
-	addr := os.Getenv("SRV_HOST") + ":" + os.Getenv("SRV_PORT")
+type Server struct {
+	*http.Server
+	redirect     *http.Server
+	mode         string
+	certFile     string
+	keyFile      string
+	drainTimeout time.Duration
+	drainDelay   time.Duration
+	onDrain      []func()
+}
 
-	readTimeout, err := time.ParseDuration(os.Getenv("SRV_READ_TIMEOUT"))
-	if err != nil {
-		return nil, fmt.Errorf("failed parse read timeout: %w", err)
+func NewServer(hdl http.Handler, cfg config.Server) (*Server, error) {
+	srv := &Server{
+		Server: &http.Server{
+			Addr:         cfg.Addr(),
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+			Handler:      hdl,
+		},
+		mode:         cfg.TLSMode,
+		certFile:     cfg.CertFile,
+		keyFile:      cfg.KeyFile,
+		drainTimeout: cfg.DrainTimeout,
+		drainDelay:   cfg.DrainDelay,
 	}
 
-	writeTimeout, err := time.ParseDuration(os.Getenv("SRV_WRITE_TIMEOUT"))
-	if err != nil {
-		return nil, fmt.Errorf("failed parse write timeout: %w", err)
+	if srv.drainTimeout <= 0 {
+		srv.drainTimeout = defaultDrainTimeout
 	}
 
-	idleTimeout, err := time.ParseDuration(os.Getenv("SRV_IDLE_TIMEOUT"))
-	if err != nil {
-		return nil, fmt.Errorf("failed parse idle timeout: %w", err)
+	// redirectHandler serves plain HTTP listener
+	// and is wrapped by ACME challenge handler if needed.
+	var redirectHandler http.Handler = http.HandlerFunc(redirectToHTTPS(cfg.Port))
+
+	switch srv.mode {
+	case TLSOff, "":
+		srv.mode = TLSOff
+	case TLSFile:
+		if srv.certFile == "" || srv.keyFile == "" {
+			return nil, fmt.Errorf("SRV_CERT_FILE and SRV_KEY_FILE are required in %s tls mode", TLSFile)
+		}
+
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	case TLSACME:
+		if len(cfg.Domains) == 0 {
+			return nil, fmt.Errorf("SRV_DOMAIN is required in %s tls mode", TLSACME)
+		}
+
+		certManager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+			Cache:      autocert.DirCache(cfg.CertDir),
+		}
+
+		srv.TLSConfig = certManager.TLSConfig()
+		srv.TLSConfig.MinVersion = tls.VersionTLS12
+		redirectHandler = certManager.HTTPHandler(redirectHandler)
+	default:
+		return nil, fmt.Errorf("unknown tls mode: %q", srv.mode)
+	}
+
+	if cfg.RedirectAddr != "" && srv.mode != TLSOff {
+		srv.redirect = &http.Server{
+			Addr:         cfg.RedirectAddr,
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+			Handler:      redirectHandler,
+		}
 	}
 
-	dirCache := os.Getenv("SRV_CERT_DIR")
-	certManager := autocert.Manager{
-		Prompt: autocert.AcceptTOS,
-		Cache:  autocert.DirCache(dirCache),
+	return srv, nil
+}
+
+// Run serves requests until ctx is done, then shuts server down gracefully.
+// Drain hooks are called first and server keeps accepting requests for drain delay,
+// so that orchestrator notices failing readiness and stops routing traffic before listeners are closed.
+// In-flight requests are waited for no longer than drain timeout.
+func (srv *Server) Run(ctx context.Context) error {
+	errCh := make(chan error, 2)
+
+	go func() {
+		errCh <- srv.listen()
+	}()
+
+	if srv.redirect != nil {
+		go func() {
+			if err := srv.redirect.ListenAndServe(); err != nil {
+				errCh <- fmt.Errorf("error running the redirect server: %w", err)
+			}
+		}()
 	}
-	tlsConfig := &tls.Config{GetCertificate: certManager.GetCertificate}
 
-	srv := &http.Server{
-		Addr:         addr,
-		ReadTimeout:  readTimeout,
-		WriteTimeout: writeTimeout,
-		IdleTimeout:  idleTimeout,
-		Handler:      hdl,
-		TLSConfig:    tlsConfig,
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			if e := srv.Shutdown(); e != nil {
+				return fmt.Errorf("%w: %w", err, e)
+			}
+
+			return err
+		}
+	case <-ctx.Done():
+		for _, fn := range srv.onDrain {
+			fn()
+		}
+
+		time.Sleep(srv.drainDelay)
 	}
 
-	return &Server{srv}, nil
+	return srv.Shutdown()
+}
+
+// RegisterOnDrain registers fn called when shutdown is requested, before drain delay starts.
+// Unlike RegisterOnShutdown hooks, it runs while listeners still accept connections.
+func (srv *Server) RegisterOnDrain(fn func()) {
+	srv.onDrain = append(srv.onDrain, fn)
 }
 
-func (srv *Server) Run() error {
-	if err := srv.ListenAndServe(); err != nil {
-		return fmt.Errorf("error running the server: %w", err)
+// Shutdown stops accepting new connections and waits for active ones to finish.
+func (srv *Server) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), srv.drainTimeout)
+	defer cancel()
+
+	if srv.redirect != nil {
+		if err := srv.redirect.Shutdown(ctx); err != nil {
+			return fmt.Errorf("error shutting down the redirect server: %w", err)
+		}
 	}
 
-	if err := srv.ListenAndServeTLS("", ""); err != nil {
-		return fmt.Errorf("error running the tls server: %w", err)
+	if err := srv.Server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("error shutting down the server: %w", err)
 	}
 
 	return nil
 }
+
+// Mode returns TLS mode server runs in.
+func (srv *Server) Mode() string {
+	return srv.mode
+}
+
+func (srv *Server) listen() error {
+	switch srv.mode {
+	case TLSFile:
+		if err := srv.ListenAndServeTLS(srv.certFile, srv.keyFile); err != nil {
+			return fmt.Errorf("error running the tls server: %w", err)
+		}
+	case TLSACME:
+		// certificates are provided by TLSConfig.GetCertificate.
+		if err := srv.ListenAndServeTLS("", ""); err != nil {
+			return fmt.Errorf("error running the tls server: %w", err)
+		}
+	default:
+		if err := srv.ListenAndServe(); err != nil {
+			return fmt.Errorf("error running the server: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// redirectToHTTPS redirects plain HTTP requests to the same URL on TLS port.
+func redirectToHTTPS(port string) func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		target := "https://" + host + req.URL.RequestURI()
+
+		rw.Header().Set("Connection", "close")
+		http.Redirect(rw, req, target, http.StatusPermanentRedirect)
+	}
+}
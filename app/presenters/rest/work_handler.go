@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/app/models"
+	"github.com/go-chi/chi/v5"
+)
+
+type Work struct {
+	logic WorkLogic
+	resp  responder
+}
+
+func NewWork(logic WorkLogic, logger models.Logger, cfg *config.Reloadable) Work {
+	return Work{
+		logic: logic,
+		resp:  responder{Logger: logger, cfg: cfg},
+	}
+}
+
+func (w Work) Route(rtr chi.Router) {
+	rtr.With(w.resp.WithAuth).Route("/works", func(rtr chi.Router) {
+		rtr.With(w.resp.WithAdmin, w.resp.WithPermission(models.PermissionImportBooks)).Post("/", w.Create)
+		rtr.With(w.resp.WithPermission(models.PermissionReadBooks)).Get("/{id}", w.Find)
+	})
+
+	rtr.With(w.resp.WithAuth).Route("/series", func(rtr chi.Router) {
+		rtr.With(w.resp.WithAdmin, w.resp.WithPermission(models.PermissionImportBooks)).Post("/", w.CreateSeries)
+		rtr.With(w.resp.WithPermission(models.PermissionReadBooks)).Get("/{id}", w.FindSeries)
+	})
+}
+
+func (w Work) Create(rw http.ResponseWriter, req *http.Request) {
+	var work models.Work
+	if err := w.resp.decodeBody(req, &work); err != nil {
+		w.resp.writeError(rw, req, ErrDecoding)
+		w.resp.logger(req).Errorw("Failed decoding work data from request.", "error", err)
+
+		return
+	}
+
+	work.Normalize()
+
+	if err := work.OK(); err != nil {
+		w.resp.writeError(rw, req, err)
+		w.resp.logger(req).Debugw("Failed validating work.", "error", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	work, err := w.logic.Create(ctx, work)
+	if err != nil {
+		w.resp.writeError(rw, req, err)
+		w.resp.logger(req).Errorw("Failed creating work.", "error", err)
+
+		return
+	}
+
+	w.resp.writeJSON(rw, req, http.StatusCreated, work)
+	w.resp.logger(req).Debugf("Work created successfully.")
+}
+
+// Find renders work with its editions.
+func (w Work) Find(rw http.ResponseWriter, req *http.Request) {
+	work := models.Work{ID: chi.URLParam(req, "id")}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	work, err := w.logic.Fetch(ctx, work)
+	if err != nil {
+		w.resp.writeError(rw, req, err)
+		w.resp.logger(req).Errorw("Failed fetching work.", "error", err)
+
+		return
+	}
+
+	w.resp.writeJSON(rw, req, http.StatusOK, work)
+	w.resp.logger(req).Debugf("Work fetched successfully.")
+}
+
+func (w Work) CreateSeries(rw http.ResponseWriter, req *http.Request) {
+	var series models.Series
+	if err := w.resp.decodeBody(req, &series); err != nil {
+		w.resp.writeError(rw, req, ErrDecoding)
+		w.resp.logger(req).Errorw("Failed decoding series data from request.", "error", err)
+
+		return
+	}
+
+	series.Normalize()
+
+	if err := series.OK(); err != nil {
+		w.resp.writeError(rw, req, err)
+		w.resp.logger(req).Debugw("Failed validating series.", "error", err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	series, err := w.logic.CreateSeries(ctx, series)
+	if err != nil {
+		w.resp.writeError(rw, req, err)
+		w.resp.logger(req).Errorw("Failed creating series.", "error", err)
+
+		return
+	}
+
+	w.resp.writeJSON(rw, req, http.StatusCreated, series)
+	w.resp.logger(req).Debugf("Series created successfully.")
+}
+
+// FindSeries renders series with its works in order, every work with its editions.
+func (w Work) FindSeries(rw http.ResponseWriter, req *http.Request) {
+	series := models.Series{ID: chi.URLParam(req, "id")}
+
+	ctx, cancel := context.WithTimeout(req.Context(), queryTimeout)
+	defer cancel()
+
+	series, err := w.logic.FetchSeries(ctx, series)
+	if err != nil {
+		w.resp.writeError(rw, req, err)
+		w.resp.logger(req).Errorw("Failed fetching series.", "error", err)
+
+		return
+	}
+
+	w.resp.writeJSON(rw, req, http.StatusOK, series)
+	w.resp.logger(req).Debugf("Series fetched successfully.")
+}
@@ -42,3 +42,119 @@ func (a Author) GetByID(ctx context.Context, author models.Author) (models.Autho
 
 	return author, nil
 }
+
+// Existing returns which of given IDs belong to authors in repository.
+func (a Author) Existing(ctx context.Context, ids []string) (map[string]bool, error) {
+	const SQL = `SELECT id
+				 FROM authors
+				 WHERE id = ANY(CAST($1 AS TEXT[])::UUID[]);`
+
+	rows, err := queries(ctx, a.DB).QueryContext(ctx, SQL, ids)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return nil, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	defer rows.Close()
+
+	existing := make(map[string]bool, len(ids))
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+		}
+
+		existing[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred during iteration: %w", err)
+	}
+
+	return existing, nil
+}
+
+// FindByNames returns authors having any of given names regardless of case.
+func (a Author) FindByNames(ctx context.Context, authors []models.Author) ([]models.Author, error) {
+	const SQL = `SELECT id, first_name, last_name
+				 FROM authors
+				 WHERE (LOWER(last_name), LOWER(first_name)) IN (
+					SELECT LOWER(last), LOWER(first) FROM UNNEST(CAST($1 AS TEXT[]), CAST($2 AS TEXT[])) AS names(first, last)
+				 );`
+
+	firstNames, lastNames := names(authors)
+
+	rows, err := queries(ctx, a.DB).QueryContext(ctx, SQL, firstNames, lastNames)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return nil, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	defer rows.Close()
+
+	return scanAuthors(rows)
+}
+
+// AddMany adds authors at once and returns them with assigned IDs.
+func (a Author) AddMany(ctx context.Context, authors []models.Author) ([]models.Author, error) {
+	const SQL = `INSERT INTO authors (first_name, last_name, created_at)
+					SELECT first, last, NOW() FROM UNNEST(CAST($1 AS TEXT[]), CAST($2 AS TEXT[])) AS names(first, last)
+				 RETURNING id, first_name, last_name;`
+
+	firstNames, lastNames := names(authors)
+
+	rows, err := queries(ctx, a.DB).QueryContext(ctx, SQL, firstNames, lastNames)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return nil, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	defer rows.Close()
+
+	return scanAuthors(rows)
+}
+
+func names(authors []models.Author) (firstNames, lastNames []string) {
+	firstNames = make([]string, len(authors))
+	lastNames = make([]string, len(authors))
+
+	for i, author := range authors {
+		firstNames[i], lastNames[i] = author.FirstName, author.LastName
+	}
+
+	return firstNames, lastNames
+}
+
+func scanAuthors(rows *sql.Rows) ([]models.Author, error) {
+	var authors []models.Author
+
+	for rows.Next() {
+		var author models.Author
+
+		if err := rows.Scan(&author.ID, &author.FirstName, &author.LastName); err != nil {
+			return nil, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+		}
+
+		authors = append(authors, author)
+	}
+
+	if err := rows.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return nil, fmt.Errorf("error occurred during iteration: %w", err)
+	}
+
+	return authors, nil
+}
@@ -3,11 +3,16 @@ package psql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/delveper/mylib/app/exceptions"
 	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/marc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/pkg/errors"
 )
 
@@ -18,16 +23,28 @@ func NewBook(db *sql.DB) *Book {
 }
 
 func (b Book) Add(ctx context.Context, book models.Book) error {
-	const SQL = `INSERT INTO books (id, author_id, title, genre, rate, size, year) 
-					VALUES (GEN_RANDOM_UUID(), $1, $2, $3, $4, $5, $6);`
-
-	_, err := b.ExecContext(ctx, SQL,
-		book.AuthorID, // $1
-		book.Title,    // $2
-		book.Genre,    // $3
-		book.Rate,     // $4
-		book.Size,     // $5
-		book.Year,     // $6
+	const SQL = `INSERT INTO books (id, work_id, author_id, title, genre, rate, size, year, isbn, publisher, language, format, subjects, marc) 
+					VALUES (GEN_RANDOM_UUID(), NULLIF($1, '')::UUID, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, $11, $12, $13);`
+
+	record, err := marcValue(book.MARC)
+	if err != nil {
+		return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	_, err = b.ExecContext(ctx, SQL,
+		book.WorkID,             // $1
+		book.AuthorID,           // $2
+		book.Title,              // $3
+		book.Genre,              // $4
+		book.Rate,               // $5
+		book.Size,               // $6
+		book.Year,               // $7
+		book.ISBN,               // $8
+		book.Publisher,          // $9
+		book.Language,           // $10
+		book.Format,             // $11
+		subjects(book.Subjects), // $12
+		record,                  // $13
 	)
 
 	if err != nil {
@@ -38,10 +55,14 @@ func (b Book) Add(ctx context.Context, book models.Book) error {
 		var pgxErr *pgconn.PgError
 		if errors.As(err, &pgxErr) {
 			switch pgxErr.ConstraintName {
-			case "books_title_key":
-				return fmt.Errorf("%w: %w", exceptions.ErrDuplicateTitle, err)
+			case "books_edition_key":
+				return fmt.Errorf("%w: %w", exceptions.ErrDuplicateEdition, err)
+			case "books_isbn_key":
+				return fmt.Errorf("%w: %w", exceptions.ErrDuplicateISBN, err)
 			case "books_pkey":
 				return fmt.Errorf("%w: %w", exceptions.ErrDuplicateID, err)
+			case "books_work_id_fkey":
+				return fmt.Errorf("%w: %w", exceptions.ErrRecordNotFound, err)
 			}
 		}
 
@@ -52,20 +73,29 @@ func (b Book) Add(ctx context.Context, book models.Book) error {
 }
 
 func (b Book) GetByID(ctx context.Context, book models.Book) (models.Book, error) {
-	const SQL = `SELECT id, author_id, title, genre, rate, size, year
+	const SQL = `SELECT id, work_id, author_id, title, genre, rate, size, year, COALESCE(isbn, ''), publisher, language, format, subjects, covers
 				 FROM books 
 				 WHERE id=$1;`
 
 	row := b.QueryRowContext(ctx, SQL, book.ID)
 
+	types := pgtype.NewMap()
+
 	err := row.Scan(
 		&book.ID,
+		&book.WorkID,
 		&book.AuthorID,
 		&book.Title,
 		&book.Genre,
 		&book.Rate,
 		&book.Size,
 		&book.Year,
+		&book.ISBN,
+		&book.Publisher,
+		&book.Language,
+		&book.Format,
+		types.SQLScanner(&book.Subjects),
+		types.SQLScanner(&book.Covers),
 	)
 	if err != nil {
 		switch {
@@ -82,7 +112,7 @@ func (b Book) GetByID(ctx context.Context, book models.Book) (models.Book, error
 }
 
 func (b Book) GetMany(ctx context.Context, filter models.DataFilter) ([]models.Book, error) {
-	const SQL = `SELECT id, author_ID, title, genre, rate, size, year
+	const SQL = `SELECT id, work_id, author_ID, title, genre, rate, size, year, COALESCE(isbn, ''), publisher, language, format, subjects, covers
 				 FROM books
 				 `
 
@@ -104,17 +134,26 @@ func (b Book) GetMany(ctx context.Context, filter models.DataFilter) ([]models.B
 
 	var books []models.Book
 
+	types := pgtype.NewMap()
+
 	for rows.Next() {
 		var book models.Book
 
 		err := rows.Scan(
 			&book.ID,
+			&book.WorkID,
 			&book.AuthorID,
 			&book.Title,
 			&book.Genre,
 			&book.Rate,
 			&book.Size,
 			&book.Year,
+			&book.ISBN,
+			&book.Publisher,
+			&book.Language,
+			&book.Format,
+			types.SQLScanner(&book.Subjects),
+			types.SQLScanner(&book.Covers),
 		)
 
 		if err != nil {
@@ -142,46 +181,380 @@ func (b Book) GetMany(ctx context.Context, filter models.DataFilter) ([]models.B
 	return books, nil
 }
 
-func (b Book) AddToFavorites(ctx context.Context, reader models.Reader, book models.Book) error {
-	const SQL = `INSERT INTO favorites (reader_id, book_id, created_at) 
-					VALUES ($1, $2, NOW());`
+// copyBatchRows is number of rows sent by single COPY.
+const copyBatchRows = 1000
 
-	_, err := b.ExecContext(ctx, SQL,
-		reader.ID, // $1
-		book.ID,   // $2
-	)
+// AddMany adds books with COPY in single transaction, so either all of them are added or none.
+// Transaction of ctx is joined if there is one.
+func (b Book) AddMany(ctx context.Context, books []models.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	err := inPgxTx(ctx, b.DB, func(tx pgxQuerier) error {
+		columns := []string{"work_id", "author_id", "title", "genre", "rate", "size", "year", "isbn", "publisher", "language", "format", "subjects", "marc"}
+
+		for start := 0; start < len(books); start += copyBatchRows {
+			end := start + copyBatchRows
+			if end > len(books) {
+				end = len(books)
+			}
+
+			rows := make([][]any, 0, end-start)
+
+			for _, book := range books[start:end] {
+				authorID, err := uuid.Parse(book.AuthorID)
+				if err != nil {
+					return fmt.Errorf("%w: author id %q: %w", exceptions.ErrValidation, book.AuthorID, err)
+				}
+
+				var workID, isbn any
+
+				if book.WorkID != "" {
+					if workID, err = uuid.Parse(book.WorkID); err != nil {
+						return fmt.Errorf("%w: work id %q: %w", exceptions.ErrValidation, book.WorkID, err)
+					}
+				}
+
+				if book.ISBN != "" {
+					isbn = book.ISBN
+				}
+
+				record, err := marcValue(book.MARC)
+				if err != nil {
+					return err
+				}
+
+				rows = append(rows, []any{
+					workID, authorID, book.Title, book.Genre, book.Rate, book.Size, book.Year, isbn,
+					book.Publisher, book.Language, book.Format, subjects(book.Subjects), record,
+				})
+			}
+
+			if _, err := tx.CopyFrom(ctx, pgx.Identifier{"books"}, columns, pgx.CopyFromRows(rows)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 
+	if err != nil {
+		return batchError(err)
+	}
+
+	return nil
+}
+
+// ReplaceMany adds books in single transaction, or joins transaction of ctx if there is one.
+// Books having the same ISBN are updated instead.
+// Rate is kept on update, since it is not part of imported data.
+func (b Book) ReplaceMany(ctx context.Context, books []models.Book) error {
+	const SQL = `INSERT INTO books (id, work_id, author_id, title, genre, rate, size, year, isbn, publisher, language, format, subjects, marc)
+					VALUES (GEN_RANDOM_UUID(), NULLIF($1, '')::UUID, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+				 ON CONFLICT (isbn) DO UPDATE
+				 SET author_id=EXCLUDED.author_id, title=EXCLUDED.title, genre=EXCLUDED.genre,
+					size=EXCLUDED.size, year=EXCLUDED.year, publisher=EXCLUDED.publisher,
+					language=EXCLUDED.language, format=EXCLUDED.format, subjects=EXCLUDED.subjects,
+					marc=COALESCE(EXCLUDED.marc, books.marc);`
+
+	if len(books) == 0 {
+		return nil
+	}
+
+	err := inPgxTx(ctx, b.DB, func(tx pgxQuerier) error {
+		var batch pgx.Batch
+
+		for _, book := range books {
+			record, err := marcValue(book.MARC)
+			if err != nil {
+				return err
+			}
+
+			batch.Queue(SQL,
+				book.WorkID,             // $1
+				book.AuthorID,           // $2
+				book.Title,              // $3
+				book.Genre,              // $4
+				book.Rate,               // $5
+				book.Size,               // $6
+				book.Year,               // $7
+				book.ISBN,               // $8
+				book.Publisher,          // $9
+				book.Language,           // $10
+				book.Format,             // $11
+				subjects(book.Subjects), // $12
+				record,                  // $13
+			)
+		}
+
+		return tx.SendBatch(ctx, &batch).Close()
+	})
+
+	if err != nil {
+		return batchError(err)
+	}
+
+	return nil
+}
+
+// batchError maps error of adding many books.
+func batchError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+	}
+
+	var pgxErr *pgconn.PgError
+	if errors.As(err, &pgxErr) {
+		switch pgxErr.ConstraintName {
+		case "books_edition_key":
+			return fmt.Errorf("%w: %w", exceptions.ErrDuplicateEdition, err)
+		case "books_isbn_key":
+			return fmt.Errorf("%w: %w", exceptions.ErrDuplicateISBN, err)
+		case "books_author_id_fkey", "books_work_id_fkey":
+			return fmt.Errorf("%w: %w", exceptions.ErrRecordNotFound, err)
+		}
+	}
+
+	if errors.Is(err, exceptions.ErrValidation) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+}
+
+// Taken returns editions without ISBN and ISBNs of given books that are already in catalog, editions are keyed
+// as by models.Book.Edition, both by their work and by author and title of their work, since book may give either.
+func (b Book) Taken(ctx context.Context, books []models.Book) (editions, isbns map[string]bool, err error) {
+	const SQL = `SELECT b.work_id, COALESCE(w.author_id::TEXT, ''), w.title, b.publisher, b.year, b.language, b.format, COALESCE(b.isbn, '')
+				 FROM books b
+					JOIN works w ON w.id = b.work_id
+				 WHERE ((w.title = ANY($1) OR b.work_id = ANY(CAST($2 AS TEXT[])::UUID[])) AND b.isbn IS NULL) OR b.isbn = ANY($3);`
+
+	titleList := make([]string, 0, len(books))
+	workList := make([]string, 0, len(books))
+	isbnList := make([]string, 0, len(books))
+
+	for _, book := range books {
+		titleList = append(titleList, book.Title)
+
+		if book.WorkID != "" {
+			workList = append(workList, book.WorkID)
+		}
+
+		if book.ISBN != "" {
+			isbnList = append(isbnList, book.ISBN)
+		}
+	}
+
+	rows, err := b.QueryContext(ctx, SQL, titleList, workList, isbnList)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
-			return fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+			return nil, nil, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
 		}
 
-		var pgxErr *pgconn.PgError
-		if errors.As(err, &pgxErr) {
-			switch pgxErr.ConstraintName {
-			case "favorites_reader_id_book_id_key":
-				return fmt.Errorf("%w: %w", exceptions.ErrRecordExists, err)
-			case "favorites_book_id_fkey":
-				return fmt.Errorf("%w: %w", exceptions.ErrBookNotFound, err)
-			case "favorites_reader_id_fkey":
-				return fmt.Errorf("%w: %w", exceptions.ErrReaderNotFound, err)
-			default:
-			}
+		return nil, nil, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	defer rows.Close()
+
+	editions, isbns = make(map[string]bool), make(map[string]bool)
+
+	for rows.Next() {
+		var (
+			book models.Book
+			isbn string
+		)
+
+		err := rows.Scan(&book.WorkID, &book.AuthorID, &book.Title, &book.Publisher, &book.Year, &book.Language, &book.Format, &isbn)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+		}
+
+		if isbn != "" {
+			isbns[isbn] = true
+			continue
+		}
+
+		editions[book.Edition()] = true
+
+		book.WorkID = ""
+		editions[book.Edition()] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error occurred during iteration: %w", err)
+	}
+
+	return editions, isbns, nil
+}
+
+// recordSQL selects book records, author is resolved by subquery, so that columns of filter stay unambiguous.
+const recordSQL = `SELECT id, work_id, author_id, title, genre, rate, size, year, COALESCE(isbn, ''), publisher, language, format,
+					subjects, covers, marc,
+					COALESCE((SELECT first_name FROM authors WHERE authors.id = books.author_id), ''),
+					COALESCE((SELECT last_name FROM authors WHERE authors.id = books.author_id), '')
+				   FROM books
+				   `
+
+// Stream calls fn for every book matching filter as soon as row is read,
+// so that whole result set is never kept in memory.
+func (b Book) Stream(ctx context.Context, filter models.DataFilter, fn func(models.BookRecord) error) error {
+	query := recordSQL + "\n" + evalQuery(filter)
+
+	rows, err := b.QueryContext(ctx, query)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
 		}
 
 		return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
 	}
 
+	defer rows.Close()
+
+	types := pgtype.NewMap()
+
+	for rows.Next() {
+		rec, err := scanRecord(rows, types)
+		if err != nil {
+			return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+		}
+
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return fmt.Errorf("error occurred during iteration: %w", err)
+	}
+
 	return nil
 }
 
+// GetRecord returns book record with author and kept MARC fields.
+func (b Book) GetRecord(ctx context.Context, book models.Book) (models.BookRecord, error) {
+	row := b.QueryRowContext(ctx, recordSQL+"WHERE id=$1;", book.ID)
+
+	rec, err := scanRecord(row, pgtype.NewMap())
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return models.BookRecord{}, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		case errors.Is(err, sql.ErrNoRows):
+			return models.BookRecord{}, fmt.Errorf("%w: %w", exceptions.ErrRecordNotFound, err)
+		default:
+			return models.BookRecord{}, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+		}
+	}
+
+	return rec, nil
+}
+
+// Incomplete returns records of books missing any field that can be filled in from bibliographic data.
+func (b Book) Incomplete(ctx context.Context) ([]models.BookRecord, error) {
+	const where = `WHERE size = 0 OR year = 0 OR CARDINALITY(subjects) = 0 OR CARDINALITY(covers) = 0;`
+
+	rows, err := b.QueryContext(ctx, recordSQL+where)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return nil, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	defer rows.Close()
+
+	var recs []models.BookRecord
+
+	types := pgtype.NewMap()
+
+	for rows.Next() {
+		rec, err := scanRecord(rows, types)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+		}
+
+		recs = append(recs, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error occurred during iteration: %w", err)
+	}
+
+	return recs, nil
+}
+
+func scanRecord(row interface{ Scan(...any) error }, types *pgtype.Map) (models.BookRecord, error) {
+	var (
+		rec    models.BookRecord
+		record []byte
+	)
+
+	err := row.Scan(
+		&rec.ID,
+		&rec.WorkID,
+		&rec.AuthorID,
+		&rec.Title,
+		&rec.Genre,
+		&rec.Rate,
+		&rec.Size,
+		&rec.Year,
+		&rec.ISBN,
+		&rec.Publisher,
+		&rec.Language,
+		&rec.Format,
+		types.SQLScanner(&rec.Subjects),
+		types.SQLScanner(&rec.Covers),
+		&record,
+		&rec.Author.FirstName,
+		&rec.Author.LastName,
+	)
+	if err != nil {
+		return models.BookRecord{}, err
+	}
+
+	if record != nil {
+		rec.Book.MARC = new(marc.Record)
+		if err := json.Unmarshal(record, rec.Book.MARC); err != nil {
+			return models.BookRecord{}, fmt.Errorf("error decoding MARC record: %w", err)
+		}
+	}
+
+	return rec, nil
+}
+
+// savedWorkSQL selects work to be saved with edition reader saved, if any, from book with ID $2 or work with ID $3.
+const savedWorkSQL = `SELECT $1::UUID, work_id, id, NOW() FROM books WHERE id = NULLIF($2, '')::UUID
+					  UNION ALL
+					  SELECT $1::UUID, id, NULL::UUID, NOW() FROM works WHERE id = NULLIF($3, '')::UUID AND $2 = ''`
+
+// AddToFavorites saves work of book, or work itself if book is not given.
+func (b Book) AddToFavorites(ctx context.Context, reader models.Reader, book models.Book) error {
+	const SQL = `INSERT INTO favorites (reader_id, work_id, book_id, created_at) ` + savedWorkSQL + `;`
+
+	return b.save(ctx, SQL, "favorites", reader, book)
+}
+
+// AddToWishlist saves work of book, or work itself if book is not given.
 func (b Book) AddToWishlist(ctx context.Context, reader models.Reader, book models.Book) error {
-	const SQL = `INSERT INTO wishlist (reader_id, book_id, created_at) 
-					VALUES ($1, $2, NOW());`
+	const SQL = `INSERT INTO wishlist (reader_id, work_id, book_id, created_at) ` + savedWorkSQL + `;`
+
+	return b.save(ctx, SQL, "wishlist", reader, book)
+}
 
-	_, err := b.ExecContext(ctx, SQL,
-		reader.ID, // $1
-		book.ID,   // $2
+// save adds work to list of reader, table names constraints of list.
+func (b Book) save(ctx context.Context, query, table string, reader models.Reader, book models.Book) error {
+	res, err := b.ExecContext(ctx, query,
+		reader.ID,   // $1
+		book.ID,     // $2
+		book.WorkID, // $3
 	)
 
 	if err != nil {
@@ -192,11 +565,9 @@ func (b Book) AddToWishlist(ctx context.Context, reader models.Reader, book mode
 		var pgxErr *pgconn.PgError
 		if errors.As(err, &pgxErr) {
 			switch pgxErr.ConstraintName {
-			case "wishlist_reader_id_book_id_key":
+			case table + "_reader_id_work_id_key":
 				return fmt.Errorf("%w: %w", exceptions.ErrRecordExists, err)
-			case "wishlist_book_id_fkey":
-				return fmt.Errorf("%w: %w", exceptions.ErrBookNotFound, err)
-			case "wishlist_reader_id_fkey":
+			case table + "_reader_id_fkey":
 				return fmt.Errorf("%w: %w", exceptions.ErrReaderNotFound, err)
 			default:
 			}
@@ -205,5 +576,37 @@ func (b Book) AddToWishlist(ctx context.Context, reader models.Reader, book mode
 		return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
 	}
 
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: %w", exceptions.ErrBookNotFound, sql.ErrNoRows)
+	}
+
 	return nil
 }
+
+// subjects returns empty list instead of nil, column is not nullable.
+func subjects(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+
+	return list
+}
+
+// marcValue encodes kept fields of MARC record as MARC-in-JSON, record without fields is stored as NULL.
+func marcValue(rec *marc.Record) (any, error) {
+	if rec == nil || len(rec.Fields) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding MARC record: %w", err)
+	}
+
+	return string(data), nil
+}
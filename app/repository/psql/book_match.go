@@ -0,0 +1,119 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pkg/errors"
+)
+
+type BookMatch struct{ *sql.DB }
+
+func NewBookMatch(db *sql.DB) *BookMatch {
+	return &BookMatch{db}
+}
+
+// AddMany records matches in single transaction and fills in books from applied ones.
+// Match of the same edition is updated, keeping its review status, so that enrichment can be run again.
+// Only fields that are still missing are filled in, values set meanwhile are never overwritten.
+func (m BookMatch) AddMany(ctx context.Context, matches []models.BookMatch) error {
+	const matchSQL = `INSERT INTO book_matches (id, book_id, source, edition_key, work_key, method, confidence, fields, applied, status, created_at)
+						VALUES (GEN_RANDOM_UUID(), $1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+					  ON CONFLICT (book_id, source, edition_key) DO UPDATE
+					  SET work_key=EXCLUDED.work_key, method=EXCLUDED.method, confidence=EXCLUDED.confidence,
+						fields=EXCLUDED.fields, applied=book_matches.applied OR EXCLUDED.applied;`
+
+	const bookSQL = `UPDATE books
+					 SET size=CASE WHEN size = 0 THEN $2 ELSE size END,
+						year=CASE WHEN year = 0 THEN $3 ELSE year END,
+						subjects=CASE WHEN CARDINALITY(subjects) = 0 THEN $4 ELSE subjects END,
+						covers=CASE WHEN CARDINALITY(covers) = 0 THEN $5 ELSE covers END
+					 WHERE id=$1;`
+
+	if len(matches) == 0 {
+		return nil
+	}
+
+	conn, err := m.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn any) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+
+		tx, err := pgxConn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Rollback is no-op after commit.
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		var batch pgx.Batch
+
+		for _, match := range matches {
+			fields, err := json.Marshal(match.Fields)
+			if err != nil {
+				return err
+			}
+
+			batch.Queue(matchSQL,
+				match.BookID,     // $1
+				match.Source,     // $2
+				match.EditionKey, // $3
+				match.WorkKey,    // $4
+				match.Method,     // $5
+				match.Confidence, // $6
+				string(fields),   // $7
+				match.Applied,    // $8
+				match.Status,     // $9
+			)
+
+			if !match.Applied {
+				continue
+			}
+
+			batch.Queue(bookSQL,
+				match.BookID,                    // $1
+				match.Fields.Size,               // $2
+				match.Fields.Year,               // $3
+				subjects(match.Fields.Subjects), // $4
+				covers(match.Fields.Covers),     // $5
+			)
+		}
+
+		if err := tx.SendBatch(ctx, &batch).Close(); err != nil {
+			return err
+		}
+
+		return tx.Commit(ctx)
+	})
+
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	return nil
+}
+
+// covers returns empty list instead of nil, column is not nullable.
+func covers(list []int) []int {
+	if list == nil {
+		return []int{}
+	}
+
+	return list
+}
@@ -0,0 +1,77 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pkg/errors"
+)
+
+type Client struct{ *sql.DB }
+
+func NewClient(db *sql.DB) *Client {
+	return &Client{db}
+}
+
+// Add adds models.Client entity and returns it with generated ID.
+func (c Client) Add(ctx context.Context, client models.Client) (models.Client, error) {
+	const SQL = `INSERT INTO clients (id, secret, name, redirect_uris, scopes, grant_types, confidential, created_at)
+					VALUES (GEN_RANDOM_UUID(), NULLIF($1, ''), $2, $3, $4, $5, $6, NOW())
+				 RETURNING id, created_at;`
+
+	row := c.QueryRowContext(ctx, SQL,
+		client.Secret,       // $1
+		client.Name,         // $2
+		client.RedirectURIs, // $3
+		client.Scopes,       // $4
+		client.GrantTypes,   // $5
+		client.Confidential, // $6
+	)
+
+	if err := row.Scan(&client.ID, &client.CreatedAt); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.Client{}, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return models.Client{}, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	return client, nil
+}
+
+// GetByID retrieves models.Client by given ID.
+func (c Client) GetByID(ctx context.Context, client models.Client) (models.Client, error) {
+	const SQL = `SELECT id, COALESCE(secret, ''), name, redirect_uris, scopes, grant_types, confidential, created_at
+				 FROM clients
+				 WHERE id=$1;`
+
+	m := pgtype.NewMap()
+	row := c.QueryRowContext(ctx, SQL, client.ID)
+
+	err := row.Scan(
+		&client.ID,
+		&client.Secret,
+		&client.Name,
+		m.SQLScanner(&client.RedirectURIs),
+		m.SQLScanner(&client.Scopes),
+		m.SQLScanner(&client.GrantTypes),
+		&client.Confidential,
+		&client.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return models.Client{}, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		case errors.Is(err, sql.ErrNoRows):
+			return models.Client{}, fmt.Errorf("%w: %w", exceptions.ErrClientNotFound, err)
+		default:
+			return models.Client{}, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+		}
+	}
+
+	return client, nil
+}
@@ -3,28 +3,23 @@ package psql
 import (
 	"database/sql"
 	"fmt"
-	"os"
 
-	_ "github.com/jackc/pgx/v5/stdlib" // "github.com/jackc/pgx/v5/pgxpool"
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/lib/tracer"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
-func Connect() (*sql.DB, error) {
-	DSN := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_EXPOSE_PORT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_SSL_MODE"),
-	)
-
-	d := os.Getenv("DB_DIALECT") // pgx
-
-	db, err := sql.Open(d, DSN)
+func Connect(dbCfg config.DB) (*sql.DB, error) {
+	cfg, err := pgx.ParseConfig(dbCfg.DSN())
 	if err != nil {
-		return nil, fmt.Errorf("error onening connection to repo: %w", err)
+		return nil, fmt.Errorf("error parsing connection config: %w", err)
 	}
 
+	cfg.Tracer = tracer.QueryTracer()
+
+	db := stdlib.OpenDB(*cfg)
+
 	err = db.Ping()
 	if err != nil {
 		return nil, fmt.Errorf("error pinging connection: %w", err)
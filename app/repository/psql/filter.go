@@ -13,6 +13,14 @@ func evalQuery(filter models.DataFilter) string {
 		query = "WHERE "
 
 		eval := func(node *models.FilterNode) string {
+			if node.Operator == models.OperatorUnder {
+				return fmt.Sprintf("%v IN (SELECT genre_descendants(%v)) %v ",
+					node.Field,
+					node.Value,
+					node.Conjunction,
+				)
+			}
+
 			return fmt.Sprintf("%v%v%v %v ",
 				node.Field,
 				node.Operator,
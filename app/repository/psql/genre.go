@@ -0,0 +1,177 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pkg/errors"
+)
+
+type Genre struct{ *sql.DB }
+
+func NewGenre(db *sql.DB) *Genre {
+	return &Genre{db}
+}
+
+// GetAll returns every genre of taxonomy with its synonyms and number of its books, ordered by name.
+func (g Genre) GetAll(ctx context.Context) ([]models.Genre, error) {
+	const SQL = `SELECT g.id, COALESCE(g.parent_id::TEXT, ''), g.name,
+					ARRAY(SELECT s.name FROM genre_synonyms s WHERE s.genre_id = g.id ORDER BY s.name),
+					(SELECT COUNT(*) FROM books b WHERE b.genre_id = g.id)
+				 FROM genres g
+				 ORDER BY g.name;`
+
+	rows, err := g.QueryContext(ctx, SQL)
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	defer rows.Close()
+
+	var genres []models.Genre
+
+	types := pgtype.NewMap()
+
+	for rows.Next() {
+		var genre models.Genre
+
+		err := rows.Scan(
+			&genre.ID,
+			&genre.ParentID,
+			&genre.Name,
+			types.SQLScanner(&genre.Synonyms),
+			&genre.Books,
+		)
+		if err != nil {
+			return nil, queryError(err)
+		}
+
+		genres = append(genres, genre)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, queryError(err)
+	}
+
+	return genres, nil
+}
+
+// Add creates genre beneath its parent, or at top level if parent is not given.
+// Genre spelled as existing genre or its synonym already exists.
+func (g Genre) Add(ctx context.Context, genre models.Genre) (models.Genre, error) {
+	const SQL = `INSERT INTO genres (id, parent_id, name)
+				 SELECT GEN_RANDOM_UUID(), NULLIF($1, '')::UUID, $2
+				 WHERE genre_of($2) IS NULL
+				 RETURNING id;`
+
+	err := g.QueryRowContext(ctx, SQL, genre.ParentID, genre.Name).Scan(&genre.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Genre{}, fmt.Errorf("%w: %w", exceptions.ErrRecordExists, err)
+		}
+
+		return models.Genre{}, genreError(err)
+	}
+
+	return genre, nil
+}
+
+// AddSynonym makes synonym other name of genre. Genre spelled as synonym is merged into genre:
+// its books, synonyms and genres beneath it are moved and it is removed, all in single transaction.
+// Synonym of another genre is moved, books already resolved keep their genre.
+func (g Genre) AddSynonym(ctx context.Context, synonym models.GenreSynonym) error {
+	const (
+		genreSQL = `SELECT genre_key($2) = key, (SELECT id FROM genres WHERE key = genre_key($2) AND id <> $1)
+					FROM genres
+					WHERE id = $1
+					FOR UPDATE;`
+
+		// Genre is lifted out first if it is beneath merged one, so that taxonomy stays a tree.
+		liftSQL = `WITH RECURSIVE tree AS (
+						SELECT id FROM genres WHERE parent_id = $2
+						UNION
+						SELECT genres.id FROM genres JOIN tree ON genres.parent_id = tree.id
+					)
+					UPDATE genres
+					SET parent_id = (SELECT parent_id FROM genres WHERE id = $2)
+					WHERE id = $1 AND id IN (SELECT id FROM tree);`
+
+		childrenSQL = `UPDATE genres SET parent_id = $1 WHERE parent_id = $2 AND id <> $1;`
+		synonymsSQL = `UPDATE genre_synonyms SET genre_id = $1 WHERE genre_id = $2;`
+		booksSQL    = `UPDATE books SET genre = (SELECT name FROM genres WHERE id = $1) WHERE genre_id = $2;`
+		deleteSQL   = `DELETE FROM genres WHERE id = $2 AND id <> $1;`
+
+		addSQL = `INSERT INTO genre_synonyms (genre_id, name)
+					VALUES ($1, $2)
+				  ON CONFLICT (key) DO UPDATE
+				  SET genre_id=EXCLUDED.genre_id, name=EXCLUDED.name;`
+	)
+
+	tx, err := g.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	// Rollback is no-op after commit.
+	defer func() { _ = tx.Rollback() }()
+
+	var (
+		own    bool
+		merged sql.NullString
+	)
+
+	if err := tx.QueryRowContext(ctx, genreSQL, synonym.GenreID, synonym.Name).Scan(&own, &merged); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %w", exceptions.ErrRecordNotFound, err)
+		}
+
+		return genreError(err)
+	}
+
+	if own {
+		return fmt.Errorf("%w: synonym is name of genre itself", exceptions.ErrRecordExists)
+	}
+
+	if merged.Valid {
+		for _, query := range []string{liftSQL, childrenSQL, synonymsSQL, booksSQL, deleteSQL} {
+			if _, err := tx.ExecContext(ctx, query, synonym.GenreID, merged.String); err != nil {
+				return genreError(err)
+			}
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, addSQL, synonym.GenreID, synonym.Name); err != nil {
+		return genreError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return genreError(err)
+	}
+
+	return nil
+}
+
+// genreError maps error of writing genres.
+func genreError(err error) error {
+	var pgxErr *pgconn.PgError
+	if errors.As(err, &pgxErr) {
+		switch pgxErr.ConstraintName {
+		case "genres_key_key", "genre_synonyms_key_key":
+			return fmt.Errorf("%w: %w", exceptions.ErrRecordExists, err)
+		case "genres_parent_id_fkey":
+			return fmt.Errorf("%w: %w", exceptions.ErrRecordNotFound, err)
+		}
+
+		// Invalid text representation, IDs are not validated by handler.
+		if pgxErr.Code == "22P02" {
+			return fmt.Errorf("%w: %w", exceptions.ErrRecordNotFound, err)
+		}
+	}
+
+	return queryError(err)
+}
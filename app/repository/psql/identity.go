@@ -0,0 +1,78 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+type Identity struct{ *sql.DB }
+
+func NewIdentity(db *sql.DB) *Identity {
+	return &Identity{db}
+}
+
+// Add links models.Reader to external identity.
+func (i Identity) Add(ctx context.Context, identity models.Identity) error {
+	const SQL = `INSERT INTO identities (reader_id, issuer, subject, created_at)
+					VALUES ($1, $2, $3, NOW());`
+
+	_, err := i.ExecContext(ctx, SQL,
+		identity.ReaderID, // $1
+		identity.Issuer,   // $2
+		identity.Subject,  // $3
+	)
+
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		var pgxErr *pgconn.PgError
+		if errors.As(err, &pgxErr) {
+			switch pgxErr.ConstraintName {
+			case "identities_issuer_subject_key":
+				return fmt.Errorf("%w: %w", exceptions.ErrRecordExists, err)
+			case "identities_reader_id_fkey":
+				return fmt.Errorf("%w: %w", exceptions.ErrReaderNotFound, err)
+			}
+		}
+
+		return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	return nil
+}
+
+// GetBySubject retrieves models.Identity by issuer and subject.
+func (i Identity) GetBySubject(ctx context.Context, identity models.Identity) (models.Identity, error) {
+	const SQL = `SELECT reader_id, issuer, subject, created_at
+				 FROM identities
+				 WHERE issuer=$1 AND subject=$2;`
+
+	row := i.QueryRowContext(ctx, SQL, identity.Issuer, identity.Subject)
+
+	err := row.Scan(
+		&identity.ReaderID,
+		&identity.Issuer,
+		&identity.Subject,
+		&identity.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return models.Identity{}, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		case errors.Is(err, sql.ErrNoRows):
+			return models.Identity{}, fmt.Errorf("%w: %w", exceptions.ErrRecordNotFound, err)
+		default:
+			return models.Identity{}, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+		}
+	}
+
+	return identity, nil
+}
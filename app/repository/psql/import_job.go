@@ -0,0 +1,153 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/pkg/errors"
+)
+
+type ImportJob struct{ *sql.DB }
+
+func NewImportJob(db *sql.DB) *ImportJob {
+	return &ImportJob{db}
+}
+
+// Add adds models.ImportJob and returns it with generated ID.
+func (j ImportJob) Add(ctx context.Context, job models.ImportJob) (models.ImportJob, error) {
+	const SQL = `INSERT INTO import_jobs (id, created_by, status, policy, dry_run, total, created_at)
+					VALUES (GEN_RANDOM_UUID(), NULLIF($1, '')::UUID, $2, $3, $4, $5, NOW())
+				 RETURNING id, created_at;`
+
+	row := j.QueryRowContext(ctx, SQL,
+		job.CreatedBy,      // $1
+		job.Status,         // $2
+		job.Options.Policy, // $3
+		job.Options.DryRun, // $4
+		job.Report.Total,   // $5
+	)
+
+	if err := row.Scan(&job.ID, &job.CreatedAt); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return models.ImportJob{}, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return models.ImportJob{}, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	return job, nil
+}
+
+// Update saves status and report of models.ImportJob.
+func (j ImportJob) Update(ctx context.Context, job models.ImportJob) error {
+	const SQL = `UPDATE import_jobs
+				 SET status=$2, total=$3, imported=$4, failed=$5, errors=$6, message=$7, finished_at=$8, updated=$9
+				 WHERE id=$1;`
+
+	errs, err := json.Marshal(job.Report.Errors)
+	if err != nil {
+		return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	_, err = j.ExecContext(ctx, SQL,
+		job.ID,              // $1
+		job.Status,          // $2
+		job.Report.Total,    // $3
+		job.Report.Imported, // $4
+		job.Report.Failed,   // $5
+		string(errs),        // $6
+		job.Message,         // $7
+		job.FinishedAt,      // $8
+		job.Report.Updated,  // $9
+	)
+
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves models.ImportJob by given ID.
+func (j ImportJob) GetByID(ctx context.Context, job models.ImportJob) (models.ImportJob, error) {
+	const SQL = `SELECT id, COALESCE(created_by::TEXT, ''), status, policy, dry_run,
+					total, imported, updated, failed, errors, message, created_at, finished_at
+				 FROM import_jobs
+				 WHERE id=$1;`
+
+	var errs []byte
+
+	row := j.QueryRowContext(ctx, SQL, job.ID)
+
+	err := row.Scan(
+		&job.ID,
+		&job.CreatedBy,
+		&job.Status,
+		&job.Options.Policy,
+		&job.Options.DryRun,
+		&job.Report.Total,
+		&job.Report.Imported,
+		&job.Report.Updated,
+		&job.Report.Failed,
+		&errs,
+		&job.Message,
+		&job.CreatedAt,
+		&job.FinishedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			return models.ImportJob{}, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		case errors.Is(err, sql.ErrNoRows):
+			return models.ImportJob{}, fmt.Errorf("%w: %w", exceptions.ErrRecordNotFound, err)
+		default:
+			return models.ImportJob{}, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+		}
+	}
+
+	if err := json.Unmarshal(errs, &job.Report.Errors); err != nil {
+		return models.ImportJob{}, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	job.Report.Valid = job.Report.Total - job.Report.Failed
+
+	return job, nil
+}
+
+// FailStale marks jobs pending or running for longer than age as failed and returns their number.
+func (j ImportJob) FailStale(ctx context.Context, age time.Duration, message string) (int, error) {
+	const SQL = `UPDATE import_jobs
+				 SET status=$1, message=$2, finished_at=NOW()
+				 WHERE status IN ($3, $4) AND created_at < NOW() - $5::FLOAT8 * INTERVAL '1 second';`
+
+	res, err := j.ExecContext(ctx, SQL,
+		models.ImportFailed,  // $1
+		message,              // $2
+		models.ImportPending, // $3
+		models.ImportRunning, // $4
+		age.Seconds(),        // $5
+	)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return 0, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return 0, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	return int(n), nil
+}
@@ -0,0 +1,224 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// savedSQL selects works reader has in favorites or wishlist, $1 is ID of reader.
+const savedSQL = `saved AS (
+					SELECT work_id FROM favorites WHERE reader_id = $1
+					UNION
+					SELECT work_id FROM wishlist WHERE reader_id = $1
+				  )`
+
+// recommendColumns selects book b with author a, columns are scanned by scanRecommendation.
+const recommendColumns = `b.id, b.author_id, b.title, b.genre, b.rate, b.size, b.year, COALESCE(b.isbn, ''),
+							b.subjects, b.covers, COALESCE(a.first_name || ' ' || a.last_name, '')`
+
+// RefreshSimilarity replaces similarity of works with cosine similarity of readers who saved them,
+// so that "readers who saved X saved Y as well" is looked up instead of computed on every request.
+// It returns number of pairs kept.
+func (b Book) RefreshSimilarity(ctx context.Context, opts models.SimilarityOptions) (int64, error) {
+	const SQL = `WITH saved AS (
+					SELECT reader_id, work_id FROM favorites
+					UNION
+					SELECT reader_id, work_id FROM wishlist
+				 ),
+				 readers AS (
+					SELECT work_id, COUNT(*) AS n FROM saved GROUP BY work_id
+				 ),
+				 pairs AS (
+					SELECT x.work_id, y.work_id AS similar_id, COUNT(*) AS shared
+					FROM saved x
+						JOIN saved y ON y.reader_id = x.reader_id AND y.work_id <> x.work_id
+					GROUP BY x.work_id, y.work_id
+					HAVING COUNT(*) >= $1
+				 ),
+				 ranked AS (
+					SELECT p.work_id, p.similar_id, p.shared, p.shared / SQRT(rx.n * ry.n) AS score,
+						ROW_NUMBER() OVER (PARTITION BY p.work_id ORDER BY p.shared / SQRT(rx.n * ry.n) DESC) AS n
+					FROM pairs p
+						JOIN readers rx ON rx.work_id = p.work_id
+						JOIN readers ry ON ry.work_id = p.similar_id
+				 )
+				 INSERT INTO work_similarity (work_id, similar_id, score, shared)
+				 SELECT work_id, similar_id, score, shared
+				 FROM ranked
+				 WHERE n <= $2;`
+
+	tx, err := b.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	// Rollback is no-op after commit.
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM work_similarity;`); err != nil {
+		return 0, queryError(err)
+	}
+
+	res, err := tx.ExecContext(ctx, SQL, opts.MinShared, opts.PerBook)
+	if err != nil {
+		return 0, queryError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, queryError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	return n, nil
+}
+
+// AlsoSaved returns works similar to ones reader saved, scored by sum of their similarity.
+// Source is the saved work most similar to recommended one, work is recommended by its latest edition.
+func (b Book) AlsoSaved(ctx context.Context, reader models.Reader, limit int) ([]models.Recommendation, error) {
+	const SQL = `WITH ` + savedSQL + `,
+				 ranked AS (
+					SELECT s.similar_id, src.title AS source,
+						ROW_NUMBER() OVER (PARTITION BY s.similar_id ORDER BY s.score DESC) AS n,
+						SUM(s.score) OVER (PARTITION BY s.similar_id) AS total
+					FROM work_similarity s
+						JOIN saved ON saved.work_id = s.work_id
+						JOIN works src ON src.id = s.work_id
+					WHERE s.similar_id NOT IN (SELECT work_id FROM saved)
+				 )
+				 SELECT ` + recommendColumns + `, r.total, r.source
+				 FROM ranked r
+					JOIN books b ON b.id = latest_edition(r.similar_id)
+					LEFT JOIN authors a ON a.id = b.author_id
+				 WHERE r.n = 1
+				 ORDER BY r.total DESC, b.title
+				 LIMIT $2;`
+
+	return b.recommend(ctx, models.ReasonAlsoSaved, SQL, reader.ID, limit)
+}
+
+// Related returns works sharing author or genre with ones reader saved, the same author goes first.
+// Work is recommended by its latest edition.
+func (b Book) Related(ctx context.Context, reader models.Reader, limit int) ([]models.Recommendation, error) {
+	const SQL = `WITH ` + savedSQL + `,
+				 candidates AS (
+					SELECT b.id, COALESCE(b.author_id = src.author_id, FALSE) AS same_author, src.title AS source,
+						ROW_NUMBER() OVER (PARTITION BY b.id ORDER BY COALESCE(b.author_id = src.author_id, FALSE) DESC, src.title) AS n
+					FROM books b
+						JOIN books src ON src.author_id = b.author_id OR src.genre = b.genre
+					WHERE src.work_id IN (SELECT work_id FROM saved) AND b.work_id NOT IN (SELECT work_id FROM saved)
+						AND b.id = latest_edition(b.work_id)
+				 )
+				 SELECT ` + recommendColumns + `, c.same_author, c.source
+				 FROM candidates c
+					JOIN books b ON b.id = c.id
+					LEFT JOIN authors a ON a.id = b.author_id
+				 WHERE c.n = 1
+				 ORDER BY c.same_author DESC, b.popularity DESC, b.rate DESC, b.title
+				 LIMIT $2;`
+
+	rows, err := b.QueryContext(ctx, SQL, reader.ID, limit)
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	defer rows.Close()
+
+	var recs []models.Recommendation
+
+	types := pgtype.NewMap()
+
+	for rows.Next() {
+		var (
+			rec        models.Recommendation
+			sameAuthor bool
+		)
+
+		if err := scanRecommendation(rows, types, &rec, &sameAuthor, &rec.Source); err != nil {
+			return nil, queryError(err)
+		}
+
+		rec.Reason = models.ReasonSameGenre
+		if sameAuthor {
+			rec.Reason = models.ReasonSameAuthor
+		}
+
+		recs = append(recs, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, queryError(err)
+	}
+
+	return recs, nil
+}
+
+// Popular returns the most popular works reader has not saved by their latest editions,
+// score is number of readers who saved them.
+func (b Book) Popular(ctx context.Context, reader models.Reader, limit int) ([]models.Recommendation, error) {
+	const SQL = `WITH ` + savedSQL + `
+				 SELECT ` + recommendColumns + `, b.popularity, ''
+				 FROM books b
+					LEFT JOIN authors a ON a.id = b.author_id
+				 WHERE b.work_id NOT IN (SELECT work_id FROM saved) AND b.id = latest_edition(b.work_id)
+				 ORDER BY b.popularity DESC, b.rate DESC, b.title
+				 LIMIT $2;`
+
+	return b.recommend(ctx, models.ReasonPopular, SQL, reader.ID, limit)
+}
+
+// recommend runs query selecting recommendColumns followed by score and source.
+func (b Book) recommend(ctx context.Context, reason, query string, args ...any) ([]models.Recommendation, error) {
+	rows, err := b.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	defer rows.Close()
+
+	var recs []models.Recommendation
+
+	types := pgtype.NewMap()
+
+	for rows.Next() {
+		rec := models.Recommendation{Reason: reason}
+
+		if err := scanRecommendation(rows, types, &rec, &rec.Score, &rec.Source); err != nil {
+			return nil, queryError(err)
+		}
+
+		recs = append(recs, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, queryError(err)
+	}
+
+	return recs, nil
+}
+
+func scanRecommendation(rows *sql.Rows, types *pgtype.Map, rec *models.Recommendation, extra ...any) error {
+	dest := []any{
+		&rec.Book.ID,
+		&rec.Book.AuthorID,
+		&rec.Book.Title,
+		&rec.Book.Genre,
+		&rec.Book.Rate,
+		&rec.Book.Size,
+		&rec.Book.Year,
+		&rec.Book.ISBN,
+		types.SQLScanner(&rec.Book.Subjects),
+		types.SQLScanner(&rec.Book.Covers),
+		&rec.Author,
+	}
+
+	return rows.Scan(append(dest, extra...)...)
+}
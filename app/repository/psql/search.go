@@ -0,0 +1,196 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pkg/errors"
+)
+
+// maxFacetGenres bounds genres counted in facets, the most frequent ones are kept.
+const maxFacetGenres = 20
+
+// searchMode holds SQL fragments of search, $1 is search text. Full-text search uses search
+// document kept by books_search trigger, fuzzy one compares trigrams of title and name of author,
+// so that misspelled words are still found.
+type searchMode struct {
+	match    string
+	rank     string
+	headline string
+}
+
+var (
+	fullTextSearch = searchMode{
+		match: `b.search @@ WEBSEARCH_TO_TSQUERY('english', $1)`,
+		rank:  `TS_RANK(b.search, WEBSEARCH_TO_TSQUERY('english', $1))`,
+		headline: `TS_HEADLINE('english',
+					CONCAT_WS('; ', b.title, a.first_name || ' ' || a.last_name, b.genre, ARRAY_TO_STRING(b.subjects, ', ')),
+					WEBSEARCH_TO_TSQUERY('english', $1), 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2')`,
+	}
+
+	fuzzySearch = searchMode{
+		match:    `($1 <% b.title OR $1 <% (a.first_name || ' ' || a.last_name))`,
+		rank:     `GREATEST(WORD_SIMILARITY($1, b.title), WORD_SIMILARITY($1, a.first_name || ' ' || a.last_name))`,
+		headline: `b.title`,
+	}
+)
+
+// Search returns page of books matching text ordered by relevance, with total and facets of all of them.
+func (b Book) Search(ctx context.Context, q models.SearchQuery) (models.SearchResult, error) {
+	mode := fullTextSearch
+	if q.Fuzzy {
+		mode = fuzzySearch
+	}
+
+	res := models.SearchResult{Fuzzy: q.Fuzzy, Hits: []models.SearchHit{}}
+
+	if err := b.searchHits(ctx, q, mode, &res); err != nil {
+		return models.SearchResult{}, queryError(err)
+	}
+
+	if err := b.searchFacets(ctx, q, mode, &res); err != nil {
+		return models.SearchResult{}, queryError(err)
+	}
+
+	return res, nil
+}
+
+func (b Book) searchHits(ctx context.Context, q models.SearchQuery, mode searchMode, res *models.SearchResult) error {
+	query := fmt.Sprintf(`SELECT b.id, b.author_id, b.title, b.genre, b.rate, b.size, b.year, COALESCE(b.isbn, ''),
+							b.subjects, b.covers, COALESCE(a.first_name || ' ' || a.last_name, ''),
+							%s AS rank, %s
+						  FROM books b
+							LEFT JOIN authors a ON a.id = b.author_id
+						  WHERE %s AND ($2 = '' OR b.genre = $2) AND ($3 = 0 OR b.year >= $3) AND ($4 = 0 OR b.year <= $4)
+						  ORDER BY rank DESC, b.title
+						  OFFSET $5 LIMIT $6;`, mode.rank, mode.headline, mode.match)
+
+	rows, err := b.QueryContext(ctx, query,
+		q.Text,     // $1
+		q.Genre,    // $2
+		q.YearFrom, // $3
+		q.YearTo,   // $4
+		q.Skip,     // $5
+		q.Top,      // $6
+	)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	types := pgtype.NewMap()
+
+	for rows.Next() {
+		var hit models.SearchHit
+
+		err := rows.Scan(
+			&hit.Book.ID,
+			&hit.Book.AuthorID,
+			&hit.Book.Title,
+			&hit.Book.Genre,
+			&hit.Book.Rate,
+			&hit.Book.Size,
+			&hit.Book.Year,
+			&hit.Book.ISBN,
+			types.SQLScanner(&hit.Book.Subjects),
+			types.SQLScanner(&hit.Book.Covers),
+			&hit.Author,
+			&hit.Rank,
+			&hit.Headline,
+		)
+		if err != nil {
+			return err
+		}
+
+		res.Hits = append(res.Hits, hit)
+	}
+
+	return rows.Err()
+}
+
+func (b Book) searchFacets(ctx context.Context, q models.SearchQuery, mode searchMode, res *models.SearchResult) error {
+	query := fmt.Sprintf(`WITH matched AS (
+							SELECT b.genre, b.year,
+								($2 = '' OR b.genre = $2) AND ($3 = 0 OR b.year >= $3) AND ($4 = 0 OR b.year <= $4) AS narrowed
+							FROM books b
+								LEFT JOIN authors a ON a.id = b.author_id
+							WHERE %s
+						  )
+						  SELECT NULL, NULL::INTEGER, COUNT(*) FILTER (WHERE narrowed) FROM matched
+						  UNION ALL
+						  SELECT genre, NULL, COUNT(*) FROM matched GROUP BY genre
+						  UNION ALL
+						  SELECT NULL, year / 10 * 10, COUNT(*) FROM matched WHERE year <> 0 GROUP BY year / 10 * 10
+						  ORDER BY 3 DESC, 1;`, mode.match)
+
+	rows, err := b.QueryContext(ctx, query,
+		q.Text,     // $1
+		q.Genre,    // $2
+		q.YearFrom, // $3
+		q.YearTo,   // $4
+	)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	res.Facets = models.SearchFacets{Genres: []models.FacetCount{}, Years: []models.FacetCount{}}
+
+	var decades []int
+
+	counts := make(map[int]int)
+
+	for rows.Next() {
+		var (
+			genre  sql.NullString
+			decade sql.NullInt64
+			count  int
+		)
+
+		if err := rows.Scan(&genre, &decade, &count); err != nil {
+			return err
+		}
+
+		switch {
+		case !genre.Valid && !decade.Valid:
+			res.Total = count
+		case genre.Valid:
+			if len(res.Facets.Genres) < maxFacetGenres {
+				res.Facets.Genres = append(res.Facets.Genres, models.FacetCount{Value: genre.String, Count: count})
+			}
+		default:
+			decades = append(decades, int(decade.Int64))
+			counts[int(decade.Int64)] = count
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sort.Ints(decades)
+
+	for _, decade := range decades {
+		value := strconv.Itoa(decade) + "-" + strconv.Itoa(decade+9)
+		res.Facets.Years = append(res.Facets.Years, models.FacetCount{Value: value, Count: counts[decade]})
+	}
+
+	return nil
+}
+
+// queryError maps error of read only query.
+func queryError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+	}
+
+	return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+}
@@ -0,0 +1,85 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/pkg/errors"
+)
+
+// suggestSQL holds queries of suggestions by kind, $1 is LIKE pattern of prefix and $2 is limit.
+// Text starting with prefix goes before text having only word starting with it, then the more popular first.
+// Both patterns are served by trigram indexes of suggest_key. Works are counted once, by their latest editions.
+var suggestSQL = map[string]string{
+	models.SuggestTitle: `SELECT id::TEXT, title, popularity
+						  FROM books
+						  WHERE (suggest_key(title) LIKE suggest_key($1) || '%'
+							OR suggest_key(title) LIKE '% ' || suggest_key($1) || '%')
+							AND id = latest_edition(work_id)
+						  ORDER BY suggest_key(title) LIKE suggest_key($1) || '%' DESC, popularity DESC, title
+						  LIMIT $2;`,
+
+	models.SuggestAuthor: `SELECT a.id::TEXT, a.first_name || ' ' || a.last_name, COALESCE(SUM(b.popularity), 0)::INTEGER
+						   FROM authors a
+							 LEFT JOIN books b ON b.author_id = a.id AND b.id = latest_edition(b.work_id)
+						   WHERE suggest_key(a.first_name || ' ' || a.last_name) LIKE suggest_key($1) || '%'
+							 OR suggest_key(a.first_name || ' ' || a.last_name) LIKE '% ' || suggest_key($1) || '%'
+						   GROUP BY a.id
+						   ORDER BY suggest_key(a.first_name || ' ' || a.last_name) LIKE suggest_key($1) || '%' DESC, 3 DESC, 2
+						   LIMIT $2;`,
+
+	models.SuggestGenre: `SELECT '', genre, SUM(popularity)::INTEGER
+						  FROM books
+						  WHERE (suggest_key(genre) LIKE suggest_key($1) || '%'
+							OR suggest_key(genre) LIKE '% ' || suggest_key($1) || '%')
+							AND id = latest_edition(work_id)
+						  GROUP BY genre
+						  ORDER BY suggest_key(genre) LIKE suggest_key($1) || '%' DESC, 3 DESC, 2
+						  LIMIT $2;`,
+}
+
+// likeEscaper escapes wildcards of LIKE, so that prefix is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Suggest returns completions of prefix, matching ignores case and diacritics.
+func (b Book) Suggest(ctx context.Context, q models.SuggestQuery) ([]models.Suggestion, error) {
+	query, ok := suggestSQL[q.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown kind of suggestion %q", exceptions.ErrValidation, q.Type)
+	}
+
+	rows, err := b.QueryContext(ctx, query, likeEscaper.Replace(q.Prefix), q.Top)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return nil, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+	}
+
+	defer rows.Close()
+
+	suggestions := []models.Suggestion{}
+
+	for rows.Next() {
+		var s models.Suggestion
+		if err := rows.Scan(&s.ID, &s.Value, &s.Popularity); err != nil {
+			return nil, fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+		}
+
+		suggestions = append(suggestions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %w", exceptions.ErrDeadline, err)
+		}
+
+		return nil, fmt.Errorf("error occurred during iteration: %w", err)
+	}
+
+	return suggestions, nil
+}
@@ -0,0 +1,119 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// txKey keys transaction in context, repositories given such context run their queries in it.
+type txKey struct{}
+
+type txConn struct {
+	conn *sql.Conn
+	tx   *sql.Tx
+}
+
+// Tx runs work of several repositories in single transaction.
+type Tx struct{ *sql.DB }
+
+func NewTx(db *sql.DB) *Tx {
+	return &Tx{db}
+}
+
+// InTx runs fn in single transaction, which is committed if fn succeeds and rolled back otherwise.
+// Repositories called with context passed to fn take part in transaction.
+func (t Tx) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	conn, err := t.Conn(ctx)
+	if err != nil {
+		return queryError(err)
+	}
+
+	defer conn.Close()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return queryError(err)
+	}
+
+	// Rollback is no-op after commit.
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txConn{conn: conn, tx: tx})); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return queryError(err)
+	}
+
+	return nil
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// queries returns transaction of ctx if there is one, db otherwise.
+func queries(ctx context.Context, db *sql.DB) querier {
+	if tc, ok := ctx.Value(txKey{}).(*txConn); ok {
+		return tc.tx
+	}
+
+	return db
+}
+
+// pgxQuerier is implemented by both *pgx.Conn and pgx.Tx, it is needed for COPY and batches.
+type pgxQuerier interface {
+	CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, src pgx.CopyFromSource) (int64, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// inPgxTx runs fn on pgx connection in transaction. Connection of transaction of ctx is used
+// as is if there is one, so that fn takes part in it, otherwise fn is given its own transaction.
+func inPgxTx(ctx context.Context, db *sql.DB, fn func(pgxQuerier) error) error {
+	conn := (*sql.Conn)(nil)
+
+	tc, inTx := ctx.Value(txKey{}).(*txConn)
+	if inTx {
+		conn = tc.conn
+	} else {
+		c, err := db.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("%w: %w", exceptions.ErrUnexpected, err)
+		}
+
+		defer c.Close()
+
+		conn = c
+	}
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+
+		if inTx {
+			return fn(pgxConn)
+		}
+
+		tx, err := pgxConn.Begin(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Rollback is no-op after commit.
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		return tx.Commit(ctx)
+	})
+}
@@ -0,0 +1,201 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pkg/errors"
+)
+
+type Work struct{ *sql.DB }
+
+func NewWork(db *sql.DB) *Work {
+	return &Work{db}
+}
+
+func (w Work) Add(ctx context.Context, work models.Work) (models.Work, error) {
+	const SQL = `INSERT INTO works (id, author_id, title, series_id, series_number, created_at)
+					VALUES (GEN_RANDOM_UUID(), $1, $2, NULLIF($3, '')::UUID, NULLIF($4::NUMERIC, 0), NOW())
+				 RETURNING id;`
+
+	err := w.QueryRowContext(ctx, SQL,
+		work.AuthorID,     // $1
+		work.Title,        // $2
+		work.SeriesID,     // $3
+		work.SeriesNumber, // $4
+	).Scan(&work.ID)
+
+	if err != nil {
+		return models.Work{}, workError(err)
+	}
+
+	return work, nil
+}
+
+// GetByID returns work with its editions, the oldest first.
+func (w Work) GetByID(ctx context.Context, work models.Work) (models.Work, error) {
+	const SQL = `SELECT id, COALESCE(author_id::TEXT, ''), title, COALESCE(series_id::TEXT, ''), COALESCE(series_number, 0)::FLOAT8
+				 FROM works
+				 WHERE id=$1;`
+
+	err := w.QueryRowContext(ctx, SQL, work.ID).Scan(
+		&work.ID,
+		&work.AuthorID,
+		&work.Title,
+		&work.SeriesID,
+		&work.SeriesNumber,
+	)
+	if err != nil {
+		return models.Work{}, workError(err)
+	}
+
+	editions, err := w.editions(ctx, []string{work.ID})
+	if err != nil {
+		return models.Work{}, workError(err)
+	}
+
+	work.Editions = editions[work.ID]
+
+	return work, nil
+}
+
+func (w Work) AddSeries(ctx context.Context, series models.Series) (models.Series, error) {
+	const SQL = `INSERT INTO series (id, title, created_at)
+					VALUES (GEN_RANDOM_UUID(), $1, NOW())
+				 RETURNING id;`
+
+	if err := w.QueryRowContext(ctx, SQL, series.Title).Scan(&series.ID); err != nil {
+		return models.Series{}, workError(err)
+	}
+
+	return series, nil
+}
+
+// GetSeries returns series with its works in order, every work with its editions.
+func (w Work) GetSeries(ctx context.Context, series models.Series) (models.Series, error) {
+	const (
+		seriesSQL = `SELECT id, title FROM series WHERE id=$1;`
+
+		worksSQL = `SELECT id, COALESCE(author_id::TEXT, ''), title, series_id::TEXT, series_number::FLOAT8
+					FROM works
+					WHERE series_id=$1
+					ORDER BY series_number;`
+	)
+
+	if err := w.QueryRowContext(ctx, seriesSQL, series.ID).Scan(&series.ID, &series.Title); err != nil {
+		return models.Series{}, workError(err)
+	}
+
+	rows, err := w.QueryContext(ctx, worksSQL, series.ID)
+	if err != nil {
+		return models.Series{}, workError(err)
+	}
+
+	defer rows.Close()
+
+	series.Works = []models.Work{}
+
+	var ids []string
+
+	for rows.Next() {
+		var work models.Work
+
+		if err := rows.Scan(&work.ID, &work.AuthorID, &work.Title, &work.SeriesID, &work.SeriesNumber); err != nil {
+			return models.Series{}, workError(err)
+		}
+
+		series.Works = append(series.Works, work)
+		ids = append(ids, work.ID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.Series{}, workError(err)
+	}
+
+	editions, err := w.editions(ctx, ids)
+	if err != nil {
+		return models.Series{}, workError(err)
+	}
+
+	for i := range series.Works {
+		series.Works[i].Editions = editions[series.Works[i].ID]
+	}
+
+	return series, nil
+}
+
+// editions returns editions of given works by ID of work, the oldest first.
+func (w Work) editions(ctx context.Context, ids []string) (map[string][]models.Book, error) {
+	const SQL = `SELECT id, work_id, author_id, title, genre, rate, size, year, COALESCE(isbn, ''), publisher, language, format, subjects, covers
+				 FROM books
+				 WHERE work_id = ANY(CAST($1 AS TEXT[])::UUID[])
+				 ORDER BY year, publisher, id;`
+
+	rows, err := w.QueryContext(ctx, SQL, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	editions := make(map[string][]models.Book, len(ids))
+
+	types := pgtype.NewMap()
+
+	for rows.Next() {
+		var book models.Book
+
+		err := rows.Scan(
+			&book.ID,
+			&book.WorkID,
+			&book.AuthorID,
+			&book.Title,
+			&book.Genre,
+			&book.Rate,
+			&book.Size,
+			&book.Year,
+			&book.ISBN,
+			&book.Publisher,
+			&book.Language,
+			&book.Format,
+			types.SQLScanner(&book.Subjects),
+			types.SQLScanner(&book.Covers),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		editions[book.WorkID] = append(editions[book.WorkID], book)
+	}
+
+	return editions, rows.Err()
+}
+
+// workError maps error of works and series.
+func workError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", exceptions.ErrRecordNotFound, err)
+	}
+
+	var pgxErr *pgconn.PgError
+	if errors.As(err, &pgxErr) {
+		switch pgxErr.ConstraintName {
+		case "works_series_id_series_number_key":
+			return fmt.Errorf("%w: %w", exceptions.ErrRecordExists, err)
+		case "works_author_id_fkey", "works_series_id_fkey":
+			return fmt.Errorf("%w: %w", exceptions.ErrRecordNotFound, err)
+		}
+
+		// Invalid text representation, IDs are not validated by handler.
+		if pgxErr.Code == "22P02" {
+			return fmt.Errorf("%w: %w", exceptions.ErrRecordNotFound, err)
+		}
+	}
+
+	return queryError(err)
+}
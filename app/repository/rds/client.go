@@ -3,22 +3,15 @@ package rds
 import (
 	"context"
 	"fmt"
-	"os"
 
+	"github.com/delveper/mylib/app/config"
 	"github.com/go-redis/redis/v8"
 )
 
-func Connect() (*redis.Client, error) {
-	addr := fmt.Sprintf("%s:%s",
-		os.Getenv("SESSION_HOST"),
-		os.Getenv("SESSION_PORT"),
-	)
-
-	pwd := os.Getenv("SESSION_PASSWORD")
-
+func Connect(sessCfg config.Session) (*redis.Client, error) {
 	cfg := &redis.Options{
-		Addr:     addr,
-		Password: pwd,
+		Addr:     sessCfg.Addr(),
+		Password: sessCfg.Password,
 		DB:       0,
 	}
 
@@ -0,0 +1,54 @@
+package rds
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+)
+
+const codePrefix = "oauth:code:"
+
+type AuthCode struct {
+	client *redis.Client
+}
+
+func NewAuthCode(client *redis.Client) *AuthCode {
+	return &AuthCode{client}
+}
+
+func (a AuthCode) Create(ctx context.Context, code models.AuthCode) error {
+	val, err := json.Marshal(code)
+	if err != nil {
+		return fmt.Errorf("error encoding code: %w", err)
+	}
+
+	if err := a.client.Set(ctx, codePrefix+code.Code, val, code.Expiry).Err(); err != nil {
+		return fmt.Errorf("recording code: %w", err)
+	}
+
+	return nil
+}
+
+// Consume retrieves code and removes it at once,
+// so that every code can be exchanged only once.
+func (a AuthCode) Consume(ctx context.Context, code models.AuthCode) (models.AuthCode, error) {
+	val, err := a.client.GetDel(ctx, codePrefix+code.Code).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return models.AuthCode{}, fmt.Errorf("nil record: %w", exceptions.ErrInvalidGrant)
+	}
+
+	if err != nil {
+		return models.AuthCode{}, fmt.Errorf("error fetching record: %w", exceptions.ErrUnexpected)
+	}
+
+	if err := json.Unmarshal(val, &code); err != nil {
+		return models.AuthCode{}, fmt.Errorf("error decoding code: %w", exceptions.ErrUnexpected)
+	}
+
+	return code, nil
+}
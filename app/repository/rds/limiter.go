@@ -0,0 +1,68 @@
+package rds
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/delveper/mylib/app/models"
+	"github.com/go-redis/redis/v8"
+)
+
+const limiterPrefix = "ratelimit:"
+
+// tokenBucket refills and takes tokens atomically using redis server time,
+// so that every node of cluster sees the same bucket.
+var tokenBucket = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + (now - ts) * capacity / period)
+local allowed = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], period)
+return {allowed, tostring(tokens)}
+`)
+
+type Limiter struct {
+	client *redis.Client
+}
+
+func NewLimiter(client *redis.Client) *Limiter {
+	return &Limiter{client}
+}
+
+// Allow takes cost tokens from bucket of given key if there are enough of them.
+func (l Limiter) Allow(ctx context.Context, key string, quota models.Quota, cost int) (models.RateLimit, error) {
+	res, err := tokenBucket.Run(ctx, l.client, []string{limiterPrefix + key},
+		quota.Limit,
+		quota.Period.Milliseconds(),
+		cost,
+	).Slice()
+	if err != nil {
+		return models.RateLimit{}, fmt.Errorf("error running token bucket script: %w", err)
+	}
+
+	if len(res) != 2 {
+		return models.RateLimit{}, fmt.Errorf("unexpected token bucket result: %v", res)
+	}
+
+	allowed, _ := res[0].(int64)
+	val, _ := res[1].(string)
+
+	tokens, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return models.RateLimit{}, fmt.Errorf("error parsing tokens left: %w", err)
+	}
+
+	return quota.Result(tokens, cost, allowed == 1), nil
+}
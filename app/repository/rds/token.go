@@ -41,6 +41,23 @@ func (t Token) Find(ctx context.Context, token models.Token) (models.Token, erro
 	return token, nil
 }
 
+// Consume retrieves token and removes it at once,
+// so that every token can be used only once.
+func (t Token) Consume(ctx context.Context, token models.Token) (models.Token, error) {
+	uid, err := t.client.GetDel(ctx, token.ID).Result()
+	if errors.Is(err, redis.Nil) {
+		return models.Token{}, fmt.Errorf("nil record: %w", exceptions.ErrTokenNotFound)
+	}
+
+	if err != nil {
+		return models.Token{}, fmt.Errorf("error fetching record: %w", exceptions.ErrUnexpected)
+	}
+
+	token.UID = uid
+
+	return token, nil
+}
+
 func (t Token) Destroy(ctx context.Context, token models.Token) error {
 	_, err := t.client.Del(ctx, token.ID).Result()
 
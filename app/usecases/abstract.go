@@ -2,8 +2,10 @@ package usecases
 
 import (
 	"context"
+	"time"
 
 	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/oidc"
 )
 
 type ReaderRepository interface {
@@ -16,16 +18,81 @@ type TokenRepository interface {
 	Create(context.Context, models.Token) error
 	Find(context.Context, models.Token) (models.Token, error)
 	Destroy(context.Context, models.Token) error
+	Consume(context.Context, models.Token) (models.Token, error)
 }
 
 type AuthorRepository interface {
 	GetByID(context.Context, models.Author) (models.Author, error)
+	Existing(context.Context, []string) (map[string]bool, error)
+	FindByNames(context.Context, []models.Author) ([]models.Author, error)
+	AddMany(context.Context, []models.Author) ([]models.Author, error)
 }
 
 type BookRepository interface {
 	Add(context.Context, models.Book) error
+	AddMany(context.Context, []models.Book) error
+	ReplaceMany(context.Context, []models.Book) error
+	Taken(context.Context, []models.Book) (editions, isbns map[string]bool, err error)
 	GetByID(context.Context, models.Book) (models.Book, error)
+	GetRecord(context.Context, models.Book) (models.BookRecord, error)
 	GetMany(context.Context, models.DataFilter) ([]models.Book, error)
+	Stream(context.Context, models.DataFilter, func(models.BookRecord) error) error
+	Incomplete(context.Context) ([]models.BookRecord, error)
+	Search(context.Context, models.SearchQuery) (models.SearchResult, error)
+	Suggest(context.Context, models.SuggestQuery) ([]models.Suggestion, error)
+	RefreshSimilarity(context.Context, models.SimilarityOptions) (int64, error)
+	AlsoSaved(context.Context, models.Reader, int) ([]models.Recommendation, error)
+	Related(context.Context, models.Reader, int) ([]models.Recommendation, error)
+	Popular(context.Context, models.Reader, int) ([]models.Recommendation, error)
 	AddToFavorites(context.Context, models.Reader, models.Book) error
 	AddToWishlist(context.Context, models.Reader, models.Book) error
 }
+
+type GenreRepository interface {
+	GetAll(context.Context) ([]models.Genre, error)
+	Add(context.Context, models.Genre) (models.Genre, error)
+	AddSynonym(context.Context, models.GenreSynonym) error
+}
+
+type WorkRepository interface {
+	Add(context.Context, models.Work) (models.Work, error)
+	GetByID(context.Context, models.Work) (models.Work, error)
+	AddSeries(context.Context, models.Series) (models.Series, error)
+	GetSeries(context.Context, models.Series) (models.Series, error)
+}
+
+type BookMatchRepository interface {
+	AddMany(context.Context, []models.BookMatch) error
+}
+
+type ImportJobRepository interface {
+	Add(context.Context, models.ImportJob) (models.ImportJob, error)
+	Update(context.Context, models.ImportJob) error
+	GetByID(context.Context, models.ImportJob) (models.ImportJob, error)
+	FailStale(ctx context.Context, age time.Duration, message string) (int, error)
+}
+
+// Transactor runs fn in single transaction, repositories called with context passed to fn take part in it.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type ClientRepository interface {
+	Add(context.Context, models.Client) (models.Client, error)
+	GetByID(context.Context, models.Client) (models.Client, error)
+}
+
+type AuthCodeRepository interface {
+	Create(context.Context, models.AuthCode) error
+	Consume(context.Context, models.AuthCode) (models.AuthCode, error)
+}
+
+type IdentityRepository interface {
+	Add(context.Context, models.Identity) error
+	GetBySubject(context.Context, models.Identity) (models.Identity, error)
+}
+
+type IdentityProvider interface {
+	AuthCodeURL(state, nonce string) string
+	Exchange(ctx context.Context, code, nonce string) (*oidc.Claims, error)
+}
@@ -1,28 +1,45 @@
 package usecases
 
 import (
-	"bytes"
 	"context"
-	"encoding/csv"
 	"fmt"
+	"io"
 
-	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/config"
 	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/cite"
+	"github.com/delveper/mylib/lib/marc"
+	"github.com/delveper/mylib/lib/tabular"
+	"github.com/delveper/mylib/lib/tracer"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Book struct {
-	repo   BookRepository
-	author AuthorRepository
+	repo    BookRepository
+	author  AuthorRepository
+	jobs    ImportJobRepository
+	tx      Transactor
+	metrics models.Metrics
+	cfg     *config.Reloadable
+	imports *importRunner
 }
 
-func NewBook(repo BookRepository, author AuthorRepository) Book {
+func NewBook(repo BookRepository, author AuthorRepository, jobs ImportJobRepository, tx Transactor, metrics models.Metrics, cfg *config.Reloadable) Book {
 	return Book{
-		repo:   repo,
-		author: author,
+		repo:    repo,
+		author:  author,
+		jobs:    jobs,
+		tx:      tx,
+		metrics: metrics,
+		cfg:     cfg,
+		imports: newImportRunner(),
 	}
 }
 
 func (b Book) Import(ctx context.Context, book models.Book) error {
+	ctx, span := tracer.Start(ctx, "usecases.Book.Import")
+	defer span.End()
+
 	author := models.Author{ID: book.AuthorID}
 	if _, err := b.author.GetByID(ctx, author); err != nil {
 		return fmt.Errorf("error getting author record: %w", err)
@@ -32,10 +49,15 @@ func (b Book) Import(ctx context.Context, book models.Book) error {
 		return fmt.Errorf("error adding book record: %w", err)
 	}
 
+	b.metrics.Inc(models.EventBookImported)
+
 	return nil
 }
 
 func (b Book) Fetch(ctx context.Context, book models.Book) (models.Book, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Book.Fetch")
+	defer span.End()
+
 	book, err := b.repo.GetByID(ctx, book)
 
 	if err != nil {
@@ -46,6 +68,9 @@ func (b Book) Fetch(ctx context.Context, book models.Book) (models.Book, error)
 }
 
 func (b Book) FetchMany(ctx context.Context, filter models.DataFilter) ([]models.Book, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Book.FetchMany")
+	defer span.End()
+
 	books, err := b.repo.GetMany(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching book records: %w", err)
@@ -54,58 +79,178 @@ func (b Book) FetchMany(ctx context.Context, filter models.DataFilter) ([]models
 	return books, nil
 }
 
-func (b Book) ExportToCSV(ctx context.Context, filter models.DataFilter) ([]byte, error) {
-	books, err := b.repo.GetMany(ctx, filter)
+// Search finds books by text, if nothing matches text as typed, books with similar title
+// or name of author are searched instead, so that misspelled query still finds something.
+func (b Book) Search(ctx context.Context, query models.SearchQuery) (models.SearchResult, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Book.Search")
+	defer span.End()
+
+	res, err := b.repo.Search(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching book records: %w", err)
+		return models.SearchResult{}, fmt.Errorf("error searching books: %w", err)
 	}
 
-	if len(books) == 0 {
-		return nil, fmt.Errorf("no content to show: %w", exceptions.ErrNoContent)
+	if res.Total > 0 {
+		return res, nil
 	}
 
-	var buf bytes.Buffer
-	writer := csv.NewWriter(&buf)
+	query.Fuzzy = true
 
-	header := []string{"ID", "AuthorID", "Title", "Genre", "Rate", "Size", "Year"}
-	if err := writer.Write(header); err != nil {
-		return nil, fmt.Errorf("error writing header to csv: %w", err)
+	res, err = b.repo.Search(ctx, query)
+	if err != nil {
+		return models.SearchResult{}, fmt.Errorf("error searching similar books: %w", err)
 	}
 
-	for i, book := range books {
-		row := []string{
-			book.ID,
-			book.AuthorID,
-			book.Title,
-			book.Genre,
-			fmt.Sprintf("%d", book.Rate),
-			fmt.Sprintf("%d", book.Size),
-			fmt.Sprintf("%d", book.Year),
+	return res, nil
+}
+
+// Suggest completes prefix typed in search box with titles, names of authors or genres.
+func (b Book) Suggest(ctx context.Context, query models.SuggestQuery) ([]models.Suggestion, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Book.Suggest", attribute.String("type", query.Type))
+	defer span.End()
+
+	suggestions, err := b.repo.Suggest(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error suggesting completions: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// bookColumnKinds lists columns holding numbers, the rest are strings.
+var bookColumnKinds = map[string]tabular.Kind{
+	"rate": tabular.Int,
+	"size": tabular.Int,
+	"year": tabular.Int,
+}
+
+// Export writes books matching filter to w in requested format as they are read from repository.
+func (b Book) Export(ctx context.Context, export models.BookExport, w io.Writer) error {
+	ctx, span := tracer.Start(ctx, "usecases.Book.Export", attribute.String("format", export.Format))
+	defer span.End()
+
+	write, done, err := exportWriter(export, w)
+	if err != nil {
+		return fmt.Errorf("error creating %s writer: %w", export.Format, err)
+	}
+
+	var n int
+
+	err = b.repo.Stream(ctx, export.Filter, func(rec models.BookRecord) error {
+		n++
+
+		if err := write(rec); err != nil {
+			return fmt.Errorf("error writing %d row: %w", n, err)
 		}
-		if err := writer.Write(row); err != nil {
-			return nil, fmt.Errorf("error writing %d row to csv: %w", i+1, err)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error exporting book records: %w", err)
+	}
+
+	if err := done(); err != nil {
+		return fmt.Errorf("error completing %s: %w", export.Format, err)
+	}
+
+	span.SetAttributes(attribute.Int("rows", n))
+	b.metrics.Inc(models.EventBooksExported)
+
+	return nil
+}
+
+// Cite writes book with its author as citation in given format.
+func (b Book) Cite(ctx context.Context, book models.Book, format string, w io.Writer) error {
+	ctx, span := tracer.Start(ctx, "usecases.Book.Cite", attribute.String("format", format))
+	defer span.End()
+
+	rec, err := b.repo.GetRecord(ctx, book)
+	if err != nil {
+		return fmt.Errorf("error fetching book record: %w", err)
+	}
+
+	cw, err := cite.NewWriter(format, w)
+	if err != nil {
+		return fmt.Errorf("error creating %s writer: %w", format, err)
+	}
+
+	if err := cw.Write(rec.Citation()); err != nil {
+		return fmt.Errorf("error writing citation: %w", err)
+	}
+
+	if err := cw.Close(); err != nil {
+		return fmt.Errorf("error completing %s: %w", format, err)
+	}
+
+	return nil
+}
+
+// exportWriter returns functions writing book in export format and completing output.
+// MARC and citation formats write whole records, columns do not apply to them.
+func exportWriter(export models.BookExport, w io.Writer) (write func(models.BookRecord) error, done func() error, err error) {
+	if _, ok := marc.ContentTypes[export.Format]; ok {
+		mw, err := marc.NewWriter(export.Format, w)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return func(rec models.BookRecord) error { return mw.Write(rec.MARC()) }, mw.Close, nil
+	}
+
+	if _, ok := cite.ContentTypes[export.Format]; ok {
+		cw, err := cite.NewWriter(export.Format, w)
+		if err != nil {
+			return nil, nil, err
 		}
+
+		return func(rec models.BookRecord) error { return cw.Write(rec.Citation()) }, cw.Close, nil
+	}
+
+	cols := make([]tabular.Column, len(export.Columns))
+	for i, name := range export.Columns {
+		cols[i] = tabular.Column{Name: name, Kind: bookColumnKinds[name]}
+	}
+
+	tw, err := tabular.NewWriter(export.Format, w, cols)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if err := writer.Error(); err != nil {
-		return nil, fmt.Errorf("error flushing csv writer: %w", err)
+	row := make([]any, len(cols))
+
+	write = func(rec models.BookRecord) error {
+		for i, name := range export.Columns {
+			row[i] = rec.Value(name)
+		}
+
+		return tw.Write(row)
 	}
 
-	return buf.Bytes(), nil
+	return write, tw.Close, nil
 }
 
 func (b Book) AddToFavorites(ctx context.Context, reader models.Reader, book models.Book) error {
+	ctx, span := tracer.Start(ctx, "usecases.Book.AddToFavorites")
+	defer span.End()
+
 	if err := b.repo.AddToFavorites(ctx, reader, book); err != nil {
 		return fmt.Errorf("error adding book to favorites: %w", err)
 	}
 
+	b.metrics.Inc(models.EventFavoriteAdded)
+
 	return nil
 }
 
 func (b Book) AddToWishlist(ctx context.Context, reader models.Reader, book models.Book) error {
+	ctx, span := tracer.Start(ctx, "usecases.Book.AddToWishlist")
+	defer span.End()
+
 	if err := b.repo.AddToWishlist(ctx, reader, book); err != nil {
 		return fmt.Errorf("error adding book to wishlist: %w", err)
 	}
 
+	b.metrics.Inc(models.EventWishlistAdded)
+
 	return nil
 }
@@ -0,0 +1,416 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/openlib"
+	"github.com/delveper/mylib/lib/tracer"
+	"github.com/pkg/errors"
+)
+
+// Confidence of match by method, title match is more confident if first name of author agrees as well.
+const (
+	confidenceISBN        = 1.0
+	confidenceFullName    = 0.9
+	confidenceTitleAuthor = 0.8
+	confidenceTitle       = 0.5
+)
+
+// maxTitleCandidates bounds editions kept per book matched by title, common titles have thousands of them.
+const maxTitleCandidates = 50
+
+// ctxCheckRecords is number of dump records read between checks of context.
+const ctxCheckRecords = 10000
+
+// EnrichSource holds dumps enrichment reads from, Works and Authors are optional.
+// Without works subjects and covers are taken from editions only, without authors books
+// that have no ISBN in common with any edition are matched by title alone.
+type EnrichSource struct {
+	Editions io.Reader
+	Works    io.Reader
+	Authors  io.Reader
+}
+
+type Enrich struct {
+	repo    BookRepository
+	matches BookMatchRepository
+}
+
+func NewEnrich(repo BookRepository, matches BookMatchRepository) Enrich {
+	return Enrich{
+		repo:    repo,
+		matches: matches,
+	}
+}
+
+// Run matches incomplete books to editions of Open Library dumps and records every match.
+// Dumps are read once each, editions first, so that only works and authors of candidate editions are kept.
+func (e Enrich) Run(ctx context.Context, src EnrichSource, opts models.EnrichOptions) (models.EnrichReport, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Enrich.Run")
+	defer span.End()
+
+	books, err := e.repo.Incomplete(ctx)
+	if err != nil {
+		return models.EnrichReport{}, fmt.Errorf("error fetching incomplete books: %w", err)
+	}
+
+	report := models.EnrichReport{Incomplete: len(books), Methods: make(map[string]int)}
+
+	if len(books) == 0 {
+		return report, nil
+	}
+
+	m := newMatcher(books)
+
+	dumps := []struct {
+		name string
+		r    io.Reader
+		typ  string
+		fn   func(openlib.Record) error
+	}{
+		{"editions", src.Editions, openlib.TypeEdition, m.edition},
+		{"works", src.Works, openlib.TypeWork, m.work},
+		{"authors", src.Authors, openlib.TypeAuthor, m.author},
+	}
+
+	for _, dump := range dumps {
+		if dump.r == nil {
+			continue
+		}
+
+		skipped, err := readDump(ctx, dump.r, dump.typ, dump.fn)
+		if err != nil {
+			return models.EnrichReport{}, fmt.Errorf("error reading %s: %w", dump.name, err)
+		}
+
+		report.Skipped += skipped
+	}
+
+	matches := m.matches()
+
+	for i := range matches {
+		matches[i].Applied = matches[i].Confidence >= opts.MinConfidence && matches[i].Fields.Count() > 0
+
+		report.Matched++
+		report.Methods[matches[i].Method]++
+
+		if matches[i].Applied {
+			report.Applied++
+		}
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	if err := e.matches.AddMany(ctx, matches); err != nil {
+		return models.EnrichReport{}, fmt.Errorf("error adding book matches: %w", err)
+	}
+
+	return report, nil
+}
+
+// readDump calls fn for every record of given type and returns number of records fn could not decode.
+func readDump(ctx context.Context, r io.Reader, typ string, fn func(openlib.Record) error) (skipped int, err error) {
+	dr, err := openlib.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+
+	for n := 1; ; n++ {
+		if n%ctxCheckRecords == 0 {
+			if err := ctx.Err(); err != nil {
+				return skipped, err
+			}
+		}
+
+		rec, err := dr.Read()
+		if errors.Is(err, io.EOF) {
+			return skipped, nil
+		}
+
+		if err != nil {
+			return skipped, err
+		}
+
+		if rec.Type != typ {
+			continue
+		}
+
+		if err := fn(rec); err != nil {
+			if !errors.Is(err, openlib.ErrMalformed) {
+				return skipped, err
+			}
+
+			skipped++
+		}
+	}
+}
+
+// candidate is edition that may describe book.
+type candidate struct {
+	edition openlib.Edition
+	byISBN  bool
+}
+
+// matcher indexes incomplete books and collects editions matching them.
+type matcher struct {
+	books  []models.BookRecord
+	isbns  map[string]int
+	titles map[string][]int
+
+	found map[int][]candidate
+	// works and authors are keyed by keys wanted by candidates, values are set once they are read.
+	works   map[string]*openlib.Work
+	authors map[string]string
+}
+
+func newMatcher(books []models.BookRecord) *matcher {
+	m := matcher{
+		books:   books,
+		isbns:   make(map[string]int),
+		titles:  make(map[string][]int),
+		found:   make(map[int][]candidate),
+		works:   make(map[string]*openlib.Work),
+		authors: make(map[string]string),
+	}
+
+	for i, book := range books {
+		if book.ISBN != "" {
+			m.isbns[models.ISBN13(book.ISBN)] = i
+		}
+
+		if title := models.NormalizeTitle(book.Title); title != "" {
+			m.titles[title] = append(m.titles[title], i)
+		}
+	}
+
+	return &m
+}
+
+// edition keeps edition sharing ISBN with book, or having its title.
+// Edition having only other ISBNs than book is another edition of the same work,
+// its number of pages and year would be wrong, so it is not kept.
+func (m *matcher) edition(rec openlib.Record) error {
+	var ed openlib.Edition
+	if err := rec.Decode(&ed); err != nil {
+		return err
+	}
+
+	isbns := make(map[string]bool)
+
+	for _, list := range [][]string{ed.ISBN13, ed.ISBN10} {
+		for _, isbn := range list {
+			isbn = models.ISBN13(models.NormalizeISBN(isbn))
+			isbns[isbn] = true
+
+			if i, ok := m.isbns[isbn]; ok {
+				m.keep(i, candidate{edition: ed, byISBN: true})
+				return nil
+			}
+		}
+	}
+
+	seen := make(map[int]bool)
+
+	for _, title := range []string{ed.Title, ed.Title + " " + ed.Subtitle} {
+		for _, i := range m.titles[models.NormalizeTitle(title)] {
+			if seen[i] || (m.books[i].ISBN != "" && len(isbns) > 0) {
+				continue
+			}
+
+			seen[i] = true
+
+			if len(m.found[i]) < maxTitleCandidates {
+				m.keep(i, candidate{edition: ed})
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *matcher) keep(i int, c candidate) {
+	m.found[i] = append(m.found[i], c)
+
+	for _, work := range c.edition.Works {
+		m.works[work.Key] = nil
+	}
+
+	for _, author := range c.edition.Authors {
+		m.authors[author.Key] = ""
+	}
+}
+
+func (m *matcher) work(rec openlib.Record) error {
+	if _, ok := m.works[rec.Key]; !ok {
+		return nil
+	}
+
+	var work openlib.Work
+	if err := rec.Decode(&work); err != nil {
+		return err
+	}
+
+	m.works[rec.Key] = &work
+
+	for _, author := range work.Authors {
+		m.authors[author.Author.Key] = ""
+	}
+
+	return nil
+}
+
+func (m *matcher) author(rec openlib.Record) error {
+	if _, ok := m.authors[rec.Key]; !ok {
+		return nil
+	}
+
+	var author openlib.Author
+	if err := rec.Decode(&author); err != nil {
+		return err
+	}
+
+	name := author.Name
+	if name == "" {
+		name = author.PersonalName
+	}
+
+	m.authors[rec.Key] = name
+
+	return nil
+}
+
+// matches picks the most confident candidate of every book, the one filling in more fields wins a tie.
+func (m *matcher) matches() []models.BookMatch {
+	var matches []models.BookMatch
+
+	for i, book := range m.books {
+		var best *models.BookMatch
+
+		for _, c := range m.found[i] {
+			method, confidence := m.score(book, c)
+			if method == "" {
+				continue
+			}
+
+			work := m.workOf(c.edition)
+
+			match := models.BookMatch{
+				BookID:     book.ID,
+				Source:     models.SourceOpenLibrary,
+				EditionKey: c.edition.Key,
+				Method:     method,
+				Confidence: confidence,
+				Fields:     book.Gaps(models.EnrichmentFromOpenLibrary(c.edition, work)),
+				Status:     models.MatchPending,
+			}
+
+			if work != nil {
+				match.WorkKey = work.Key
+			}
+
+			if best == nil || match.Confidence > best.Confidence ||
+				(match.Confidence == best.Confidence && match.Fields.Count() > best.Fields.Count()) {
+				best = &match
+			}
+		}
+
+		if best != nil {
+			matches = append(matches, *best)
+		}
+	}
+
+	return matches
+}
+
+// score tells how candidate matches book, empty method means that author of candidate is another one.
+func (m *matcher) score(book models.BookRecord, c candidate) (method string, confidence float64) {
+	if c.byISBN {
+		return models.MatchISBN, confidenceISBN
+	}
+
+	names := m.names(c.edition)
+	if len(names) == 0 {
+		return models.MatchTitle, confidenceTitle
+	}
+
+	last := strings.Fields(models.NormalizeName(book.Author.LastName))
+	first := strings.Fields(models.NormalizeName(book.Author.FirstName))
+
+	confidence = 0
+
+	for _, name := range names {
+		words := make(map[string]bool)
+		for _, word := range strings.Fields(models.NormalizeName(name)) {
+			words[word] = true
+		}
+
+		if !hasWords(words, last) {
+			continue
+		}
+
+		if hasWords(words, first) {
+			confidence = confidenceFullName
+		} else if confidence == 0 {
+			confidence = confidenceTitleAuthor
+		}
+	}
+
+	if confidence == 0 {
+		return "", 0
+	}
+
+	return models.MatchTitleAuthor, confidence
+}
+
+// workOf returns work of edition if it was read.
+func (m *matcher) workOf(ed openlib.Edition) *openlib.Work {
+	for _, ref := range ed.Works {
+		if work := m.works[ref.Key]; work != nil {
+			return work
+		}
+	}
+
+	return nil
+}
+
+// names returns known names of authors of edition, or of its work if edition names none.
+func (m *matcher) names(ed openlib.Edition) []string {
+	keys := make([]string, 0, len(ed.Authors))
+
+	for _, ref := range ed.Authors {
+		keys = append(keys, ref.Key)
+	}
+
+	if work := m.workOf(ed); len(keys) == 0 && work != nil {
+		for _, ref := range work.Authors {
+			keys = append(keys, ref.Author.Key)
+		}
+	}
+
+	var names []string
+
+	for _, key := range keys {
+		if name := m.authors[key]; name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// hasWords reports whether all of words are in set, there must be at least one.
+func hasWords(set map[string]bool, words []string) bool {
+	for _, word := range words {
+		if !set[word] {
+			return false
+		}
+	}
+
+	return len(words) > 0
+}
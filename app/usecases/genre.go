@@ -0,0 +1,54 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/tracer"
+)
+
+type Genre struct {
+	repo GenreRepository
+}
+
+func NewGenre(repo GenreRepository) Genre {
+	return Genre{repo: repo}
+}
+
+// Tree returns taxonomy of genres, top level genres with genres beneath them nested.
+func (g Genre) Tree(ctx context.Context) ([]*models.Genre, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Genre.Tree")
+	defer span.End()
+
+	genres, err := g.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching genres: %w", err)
+	}
+
+	return models.GenreTree(genres), nil
+}
+
+func (g Genre) Create(ctx context.Context, genre models.Genre) (models.Genre, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Genre.Create")
+	defer span.End()
+
+	genre, err := g.repo.Add(ctx, genre)
+	if err != nil {
+		return models.Genre{}, fmt.Errorf("error adding genre record: %w", err)
+	}
+
+	return genre, nil
+}
+
+// AddSynonym adds other name of genre, genre already spelled as synonym is merged into it.
+func (g Genre) AddSynonym(ctx context.Context, synonym models.GenreSynonym) error {
+	ctx, span := tracer.Start(ctx, "usecases.Genre.AddSynonym")
+	defer span.End()
+
+	if err := g.repo.AddSynonym(ctx, synonym); err != nil {
+		return fmt.Errorf("error adding genre synonym: %w", err)
+	}
+
+	return nil
+}
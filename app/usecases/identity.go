@@ -0,0 +1,169 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/tokay"
+	"github.com/delveper/mylib/lib/tracer"
+	"github.com/pkg/errors"
+)
+
+const (
+	stateExpiry  = 10 * time.Minute
+	defaultRole  = "reader"
+	stateEntropy = 32
+)
+
+// Identity signs readers in through external OpenID provider.
+// Readers are linked by verified email or provisioned just in time.
+type Identity struct {
+	reader     Reader
+	issuer     string
+	provider   IdentityProvider
+	identities IdentityRepository
+}
+
+func NewIdentity(reader Reader, issuer string, provider IdentityProvider, identities IdentityRepository) Identity {
+	return Identity{
+		reader:     reader,
+		issuer:     issuer,
+		provider:   provider,
+		identities: identities,
+	}
+}
+
+// Begin starts authorization and returns provider URL reader has to be redirected to
+// together with state, which has to be bound to browser of reader until callback.
+// State and nonce are kept in session storage until callback.
+func (i Identity) Begin(ctx context.Context) (uri, state string, err error) {
+	ctx, span := tracer.Start(ctx, "usecases.Identity.Begin")
+	defer span.End()
+
+	state, err = tokay.Random(stateEntropy)
+	if err != nil {
+		return "", "", fmt.Errorf("error generating state: %w", err)
+	}
+
+	nonce, err := tokay.Random(stateEntropy)
+	if err != nil {
+		return "", "", fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	if err := i.reader.sess.Create(ctx, models.Token{ID: state, UID: nonce, Expiry: stateExpiry}); err != nil {
+		return "", "", fmt.Errorf("error saving state: %w", err)
+	}
+
+	return i.provider.AuthCodeURL(state, nonce), state, nil
+}
+
+// Complete handles provider callback and issues models.TokenPair for linked reader.
+func (i Identity) Complete(ctx context.Context, state, code string) (*models.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Identity.Complete")
+	defer span.End()
+
+	saved, err := i.reader.sess.Consume(ctx, models.Token{ID: state})
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, exceptions.ErrInvalidState)
+	}
+
+	claims, err := i.provider.Exchange(ctx, code, saved.UID)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, exceptions.ErrInvalidCredits)
+	}
+
+	reader, err := i.link(ctx, claims.Subject, claims.Email, claims.EmailVerified, claims.GivenName, claims.FamilyName)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenPair, err := i.reader.newTokenPair(ctx, reader)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, exceptions.ErrTokenNotCreated)
+	}
+
+	i.reader.metrics.Inc(models.EventLoginSucceeded)
+
+	return tokenPair, nil
+}
+
+// link finds reader already linked to external subject,
+// links existing reader with same email or provisions new one.
+func (i Identity) link(ctx context.Context, subject, email string, verified bool, firstName, lastName string) (models.Reader, error) {
+	identity, err := i.identities.GetBySubject(ctx, models.Identity{Issuer: i.issuer, Subject: subject})
+	if err == nil {
+		reader, err := i.reader.repo.GetByID(ctx, models.Reader{ID: identity.ReaderID})
+		if err != nil {
+			return models.Reader{}, fmt.Errorf("errror fetching reader: %w", err)
+		}
+
+		return reader, nil
+	}
+
+	if !errors.Is(err, exceptions.ErrRecordNotFound) {
+		return models.Reader{}, fmt.Errorf("error fetching identity: %w", err)
+	}
+
+	// Linking by unverified email would allow account takeover.
+	if !verified || email == "" {
+		return models.Reader{}, exceptions.ErrIdentityNotVerified
+	}
+
+	reader := models.Reader{Email: email}
+	reader.Normalize()
+
+	reader, err = i.reader.repo.GetByEmail(ctx, reader)
+	if errors.Is(err, exceptions.ErrRecordNotFound) {
+		reader, err = i.provision(ctx, email, firstName, lastName)
+	}
+
+	if err != nil {
+		return models.Reader{}, fmt.Errorf("errror fetching reader: %w", err)
+	}
+
+	if err := i.identities.Add(ctx, models.Identity{ReaderID: reader.ID, Issuer: i.issuer, Subject: subject}); err != nil {
+		return models.Reader{}, fmt.Errorf("error linking identity: %w", err)
+	}
+
+	return reader, nil
+}
+
+// provision creates reader with random password,
+// so that it can sign in only through provider until password is reset.
+// Names and email come from provider and are validated as if reader signed up.
+func (i Identity) provision(ctx context.Context, email, firstName, lastName string) (models.Reader, error) {
+	password, err := tokay.Random(stateEntropy)
+	if err != nil {
+		return models.Reader{}, fmt.Errorf("error generating password: %w", err)
+	}
+
+	reader := models.Reader{
+		FirstName: strings.TrimSpace(firstName),
+		LastName:  strings.TrimSpace(lastName),
+		Email:     email,
+		Password:  password,
+		Role:      defaultRole,
+	}
+
+	reader.Normalize()
+
+	if err := reader.OK(); err != nil {
+		return models.Reader{}, err
+	}
+
+	if err := reader.HashPassword(); err != nil {
+		return models.Reader{}, err
+	}
+
+	if err := i.reader.repo.Add(ctx, reader); err != nil {
+		return models.Reader{}, fmt.Errorf("error provisioning reader: %w", err)
+	}
+
+	i.reader.metrics.Inc(models.EventSignUp)
+
+	return i.reader.repo.GetByEmail(ctx, reader)
+}
@@ -0,0 +1,284 @@
+package usecases
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/oidc"
+	"github.com/delveper/mylib/lib/oidc/oidctest"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+func TestIdentityComplete(t *testing.T) {
+	ident, p, readers := setupIdentity(t)
+	ctx := context.Background()
+
+	state, code := login(t, ident, p)
+
+	pair, err := ident.Complete(ctx, state, code)
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	if pair.AccessToken == "" || pair.RefreshToken == "" {
+		t.Errorf("got token pair %+v, want both tokens", pair)
+	}
+
+	if _, ok := readers.byEmail[p.Identity.Email]; !ok {
+		t.Errorf("reader %s is not provisioned", p.Identity.Email)
+	}
+
+	// State is single use.
+	if _, err := ident.Complete(ctx, state, code); !errors.Is(err, exceptions.ErrInvalidState) {
+		t.Errorf("repeated callback returned %v, want %v", err, exceptions.ErrInvalidState)
+	}
+
+	// Signing in again finds reader by linked subject.
+	state, code = login(t, ident, p)
+
+	if _, err := ident.Complete(ctx, state, code); err != nil {
+		t.Fatalf("complete for linked reader: %v", err)
+	}
+
+	if len(readers.byEmail) != 1 {
+		t.Errorf("got %d readers, want 1", len(readers.byEmail))
+	}
+}
+
+func TestIdentityCompleteFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    func(state string) string
+		tamper   func(*oidc.Claims)
+		identity func(*oidctest.Identity)
+		want     error
+	}{
+		{
+			name:  "state mismatch",
+			state: func(string) string { return "another" },
+			want:  exceptions.ErrInvalidState,
+		},
+		{
+			name:   "nonce mismatch",
+			tamper: func(c *oidc.Claims) { c.Nonce = "another" },
+			want:   exceptions.ErrInvalidCredits,
+		},
+		{
+			name:   "wrong audience",
+			tamper: func(c *oidc.Claims) { c.Audience = []string{"another"} },
+			want:   exceptions.ErrInvalidCredits,
+		},
+		{
+			name:   "wrong issuer",
+			tamper: func(c *oidc.Claims) { c.Issuer = "https://issuer.example.com" },
+			want:   exceptions.ErrInvalidCredits,
+		},
+		{
+			name: "expired token",
+			tamper: func(c *oidc.Claims) {
+				c.ExpiresAt.Time = time.Now().Add(-time.Minute)
+			},
+			want: exceptions.ErrInvalidCredits,
+		},
+		{
+			name:     "unverified email",
+			identity: func(i *oidctest.Identity) { i.EmailVerified = false },
+			want:     exceptions.ErrIdentityNotVerified,
+		},
+		{
+			name:     "invalid name",
+			identity: func(i *oidctest.Identity) { i.GivenName = "<script>" },
+			want:     exceptions.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ident, p, readers := setupIdentity(t)
+
+			p.Tamper = tt.tamper
+			if tt.identity != nil {
+				tt.identity(&p.Identity)
+			}
+
+			state, code := login(t, ident, p)
+			if tt.state != nil {
+				state = tt.state(state)
+			}
+
+			_, err := ident.Complete(context.Background(), state, code)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+
+			if len(readers.byEmail) != 0 {
+				t.Errorf("got %d readers, want none", len(readers.byEmail))
+			}
+		})
+	}
+}
+
+// setupIdentity starts mock provider and returns Identity signing readers in through it.
+func setupIdentity(t *testing.T) (Identity, *oidctest.Provider, *fakeReaders) {
+	t.Helper()
+
+	p, err := oidctest.NewProvider()
+	if err != nil {
+		t.Fatalf("starting provider: %v", err)
+	}
+
+	t.Cleanup(p.Close)
+
+	meta, err := oidc.Discover(context.Background(), p.Client(), p.URL)
+	if err != nil {
+		t.Fatalf("discovery: %v", err)
+	}
+
+	rp := oidc.NewRelyingParty(meta, p.Client(), oidctest.ClientID, oidctest.ClientSecret, "http://localhost/callback", "email")
+
+	cfg := config.NewReloadable(&config.Config{JWT: config.JWT{
+		Alg:        "HS256",
+		Key:        "key",
+		AccessExp:  time.Minute,
+		RefreshExp: time.Hour,
+	}}, nil)
+
+	readers := &fakeReaders{byEmail: make(map[string]models.Reader)}
+	reader := NewReader(readers, &fakeTokens{tokens: make(map[string]string)}, nopMetrics{}, cfg)
+
+	return NewIdentity(reader, meta.Issuer, rp, &fakeIdentities{}), p, readers
+}
+
+// login begins authorization and signs in at provider, state and code of callback are returned.
+func login(t *testing.T, ident Identity, p *oidctest.Provider) (state, code string) {
+	t.Helper()
+
+	authURL, _, err := ident.Begin(context.Background())
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+
+	callback, err := p.Login(authURL)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	return callback.Query().Get("state"), callback.Query().Get("code")
+}
+
+type fakeTokens struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func (f *fakeTokens) Create(_ context.Context, token models.Token) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.tokens[token.ID] = token.UID
+
+	return nil
+}
+
+func (f *fakeTokens) Find(_ context.Context, token models.Token) (models.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	uid, ok := f.tokens[token.ID]
+	if !ok {
+		return models.Token{}, exceptions.ErrTokenNotFound
+	}
+
+	token.UID = uid
+
+	return token, nil
+}
+
+func (f *fakeTokens) Consume(_ context.Context, token models.Token) (models.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	uid, ok := f.tokens[token.ID]
+	if !ok {
+		return models.Token{}, exceptions.ErrTokenNotFound
+	}
+
+	delete(f.tokens, token.ID)
+	token.UID = uid
+
+	return token, nil
+}
+
+func (f *fakeTokens) Destroy(_ context.Context, token models.Token) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.tokens, token.ID)
+
+	return nil
+}
+
+type fakeReaders struct {
+	byEmail map[string]models.Reader
+}
+
+func (f *fakeReaders) Add(_ context.Context, reader models.Reader) error {
+	if _, ok := f.byEmail[reader.Email]; ok {
+		return exceptions.ErrRecordExists
+	}
+
+	reader.ID = uuid.New().String()
+	f.byEmail[reader.Email] = reader
+
+	return nil
+}
+
+func (f *fakeReaders) GetByID(_ context.Context, reader models.Reader) (models.Reader, error) {
+	for _, r := range f.byEmail {
+		if r.ID == reader.ID {
+			return r, nil
+		}
+	}
+
+	return models.Reader{}, exceptions.ErrRecordNotFound
+}
+
+func (f *fakeReaders) GetByEmail(_ context.Context, reader models.Reader) (models.Reader, error) {
+	r, ok := f.byEmail[reader.Email]
+	if !ok {
+		return models.Reader{}, exceptions.ErrRecordNotFound
+	}
+
+	return r, nil
+}
+
+type fakeIdentities struct {
+	identities []models.Identity
+}
+
+func (f *fakeIdentities) Add(_ context.Context, identity models.Identity) error {
+	f.identities = append(f.identities, identity)
+	return nil
+}
+
+func (f *fakeIdentities) GetBySubject(_ context.Context, identity models.Identity) (models.Identity, error) {
+	for _, i := range f.identities {
+		if i.Issuer == identity.Issuer && i.Subject == identity.Subject {
+			return i, nil
+		}
+	}
+
+	return models.Identity{}, exceptions.ErrRecordNotFound
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) Inc(string) {}
+
+func (nopMetrics) Add(string, int) {}
@@ -0,0 +1,544 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/tracer"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// importBatchRows is number of rows added at once in best-effort import,
+// failed batch is retried row by row to find out which rows are at fault.
+const importBatchRows = 1000
+
+// newAuthorID stands in for ID of author that is not in catalog yet,
+// so that row passes validation. Such author is added right before books are.
+const newAuthorID = "00000000-0000-0000-0000-000000000000"
+
+// BulkImport validates every row and adds valid ones according to policy.
+// Rows are checked against each other and against catalog before anything is added,
+// so dry run reports the same errors as real import would.
+// Atomic import adds authors and books in single transaction, so nothing is left behind if any of it fails.
+func (b Book) BulkImport(ctx context.Context, rows []models.ImportRow, opts models.ImportOptions) (*models.ImportReport, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Book.BulkImport",
+		attribute.Int("rows", len(rows)),
+		attribute.String("policy", opts.Policy),
+		attribute.Bool("dry_run", opts.DryRun),
+	)
+	defer span.End()
+
+	report := importReport{
+		errs:    make(map[int]*models.ImportRowError),
+		updates: make(map[int]bool),
+	}
+
+	valid, err := b.checkRows(ctx, rows, &report)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.ImportReport{Total: len(rows), Valid: len(valid)}
+
+	switch {
+	case opts.DryRun:
+	case opts.Policy == models.ImportAtomic && len(valid) < len(rows):
+	case opts.Policy == models.ImportAtomic:
+		err := b.tx.InTx(ctx, func(ctx context.Context) error {
+			if err := b.addAuthors(ctx, valid); err != nil {
+				return err
+			}
+
+			added, replaced := splitReplacing(valid)
+
+			if err := b.repo.AddMany(ctx, books(added)); err != nil {
+				return fmt.Errorf("error adding book records: %w", err)
+			}
+
+			if err := b.repo.ReplaceMany(ctx, books(replaced)); err != nil {
+				return fmt.Errorf("error replacing book records: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		res.Imported = len(valid)
+	default:
+		if err := b.addAuthors(ctx, valid); err != nil {
+			return nil, err
+		}
+
+		added, replaced := splitReplacing(valid)
+
+		n, err := b.addBestEffort(ctx, added, b.repo.AddMany, &report)
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := b.addBestEffort(ctx, replaced, b.repo.ReplaceMany, &report)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Imported = n + m
+	}
+
+	res.Errors = report.list()
+	res.Failed = len(res.Errors)
+
+	if res.Imported > 0 {
+		res.Updated = report.updated()
+	}
+
+	if res.Imported > 0 {
+		b.metrics.Add(models.EventBooksBulkImported, res.Imported)
+	}
+
+	return res, nil
+}
+
+// importRunner tracks import jobs running in background, so that shutdown waits for them.
+// Jobs run on context of runner, which is canceled if they do not finish in time.
+type importRunner struct {
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newImportRunner() *importRunner {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &importRunner{ctx: ctx, cancel: cancel}
+}
+
+// StartImport registers import job and runs it in background.
+// Job outlives request, so it is given its own timeout.
+func (b Book) StartImport(ctx context.Context, job models.ImportJob, rows []models.ImportRow) (models.ImportJob, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Book.StartImport")
+	defer span.End()
+
+	job.Status = models.ImportPending
+	job.Report = models.ImportReport{Total: len(rows)}
+
+	job, err := b.jobs.Add(ctx, job)
+	if err != nil {
+		return models.ImportJob{}, fmt.Errorf("error adding import job: %w", err)
+	}
+
+	b.imports.wg.Add(1)
+
+	go func() {
+		defer b.imports.wg.Done()
+		b.runImport(job, rows)
+	}()
+
+	return job, nil
+}
+
+// FetchImport returns import job with its report.
+func (b Book) FetchImport(ctx context.Context, job models.ImportJob) (models.ImportJob, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Book.FetchImport")
+	defer span.End()
+
+	job, err := b.jobs.GetByID(ctx, job)
+	if err != nil {
+		return models.ImportJob{}, fmt.Errorf("error fetching import job: %w", err)
+	}
+
+	return job, nil
+}
+
+// StopImports waits for import jobs running in background until ctx is done.
+// Jobs still running then are canceled and waited for again, so that their failure is recorded.
+func (b Book) StopImports(ctx context.Context) error {
+	done := make(chan struct{})
+
+	go func() {
+		b.imports.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	b.imports.cancel()
+	<-done
+
+	return fmt.Errorf("import jobs canceled: %w", ctx.Err())
+}
+
+// FailStaleImports marks jobs left pending or running by stopped instance as failed.
+// Only jobs older than import timeout are marked, younger ones may still run on another instance.
+func (b Book) FailStaleImports(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Book.FailStaleImports")
+	defer span.End()
+
+	n, err := b.jobs.FailStale(ctx, b.cfg.Current().Books.ImportTimeout, exceptions.ErrImportInterrupted.Error())
+	if err != nil {
+		return 0, fmt.Errorf("error failing stale import jobs: %w", err)
+	}
+
+	return n, nil
+}
+
+func (b Book) runImport(job models.ImportJob, rows []models.ImportRow) {
+	ctx, cancel := context.WithTimeout(b.imports.ctx, b.cfg.Current().Books.ImportTimeout)
+	defer cancel()
+
+	ctx, span := tracer.Start(ctx, "usecases.Book.runImport", attribute.String("job_id", job.ID))
+	defer span.End()
+
+	job.Status = models.ImportRunning
+	_ = b.jobs.Update(ctx, job)
+
+	report, err := b.BulkImport(ctx, rows, job.Options)
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+
+	if err != nil {
+		job.Status = models.ImportFailed
+		job.Message = importMessage(err)
+
+		tracer.Fail(span, err)
+	} else {
+		job.Status = models.ImportDone
+		job.Report = *report
+	}
+
+	// Import may have run out of time, yet its outcome has to be saved.
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer saveCancel()
+
+	_ = b.jobs.Update(saveCtx, job)
+}
+
+// importMessage returns message of failed job that is safe to show to client.
+// Like problem details, it is taken from sentinel error, so that internal messages never reach client.
+func importMessage(err error) string {
+	switch {
+	case errors.Is(err, exceptions.ErrDeadline), errors.Is(err, context.DeadlineExceeded):
+		return exceptions.ErrDeadline.Error()
+	case errors.Is(err, context.Canceled):
+		return exceptions.ErrImportInterrupted.Error()
+	case errors.Is(err, exceptions.ErrValidation):
+		return exceptions.ErrValidation.Error()
+	default:
+		return exceptions.ErrUnexpected.Error()
+	}
+}
+
+// checkRows returns rows that passed every check, errors of the rest are added to report.
+func (b Book) checkRows(ctx context.Context, rows []models.ImportRow, report *importReport) ([]models.ImportRow, error) {
+	if err := b.resolveAuthors(ctx, rows); err != nil {
+		return nil, err
+	}
+
+	valid := make([]models.ImportRow, 0, len(rows))
+	editions := make(map[string]int)
+	isbns := make(map[string]int)
+
+	for _, row := range rows {
+		if row.Err != nil {
+			report.add(row.Line, row.Err)
+			continue
+		}
+
+		row.Book.Normalize()
+
+		if err := row.Book.OK(); err != nil {
+			report.add(row.Line, err)
+			continue
+		}
+
+		var vErr exceptions.ValidationError
+
+		if line, ok := editions[row.Book.Edition()]; ok && row.Book.ISBN == "" {
+			vErr.Add("edition", "unique", fmt.Sprintf("is repeated in line %d", line))
+		}
+
+		if line, ok := isbns[row.Book.ISBN]; ok && row.Book.ISBN != "" {
+			vErr.Add("isbn", "unique", fmt.Sprintf("is repeated in line %d", line))
+		}
+
+		if err := vErr.Err(); err != nil {
+			report.add(row.Line, err)
+			continue
+		}
+
+		editions[row.Book.Edition()] = row.Line
+		isbns[row.Book.ISBN] = row.Line
+
+		valid = append(valid, row)
+	}
+
+	if len(valid) == 0 {
+		return nil, nil
+	}
+
+	authorIDs := make([]string, 0, len(valid))
+	for _, row := range valid {
+		if row.Book.AuthorID != newAuthorID {
+			authorIDs = append(authorIDs, row.Book.AuthorID)
+		}
+	}
+
+	authors, err := b.author.Existing(ctx, authorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error checking authors: %w", err)
+	}
+
+	takenEditions, takenISBNs, err := b.repo.Taken(ctx, books(valid))
+	if err != nil {
+		return nil, fmt.Errorf("error checking book records: %w", err)
+	}
+
+	checked := valid[:0]
+
+	for _, row := range valid {
+		var vErr exceptions.ValidationError
+
+		if !authors[row.Book.AuthorID] && row.Book.AuthorID != newAuthorID {
+			vErr.Add("author_id", "exists", "does not belong to any author")
+		}
+
+		replacing := row.Replace && row.Book.ISBN != ""
+
+		// Editions having ISBN are told apart by it.
+		if takenEditions[row.Book.Edition()] && row.Book.ISBN == "" {
+			vErr.Add("edition", "unique", "is already in catalog")
+		}
+
+		switch {
+		case takenISBNs[row.Book.ISBN] && replacing:
+			report.updates[row.Line] = true
+		case takenISBNs[row.Book.ISBN]:
+			vErr.Add("isbn", "unique", "is already in catalog")
+		}
+
+		if err := vErr.Err(); err != nil {
+			report.add(row.Line, err)
+			continue
+		}
+
+		checked = append(checked, row)
+	}
+
+	return checked, nil
+}
+
+// resolveAuthors sets author IDs of rows that name author instead,
+// rows naming author missing in catalog are given newAuthorID.
+func (b Book) resolveAuthors(ctx context.Context, rows []models.ImportRow) error {
+	named := make(map[string]models.Author)
+
+	for _, row := range rows {
+		if row.Err == nil && row.Book.AuthorID == "" && row.Author.LastName != "" {
+			named[nameKey(row.Author)] = row.Author
+		}
+	}
+
+	if len(named) == 0 {
+		return nil
+	}
+
+	list := make([]models.Author, 0, len(named))
+	for _, author := range named {
+		list = append(list, author)
+	}
+
+	found, err := b.author.FindByNames(ctx, list)
+	if err != nil {
+		return fmt.Errorf("error finding authors: %w", err)
+	}
+
+	ids := make(map[string]string, len(found))
+	for _, author := range found {
+		ids[nameKey(author)] = author.ID
+	}
+
+	for i, row := range rows {
+		if row.Err != nil || row.Book.AuthorID != "" || row.Author.LastName == "" {
+			continue
+		}
+
+		rows[i].Book.AuthorID = newAuthorID
+
+		if id, ok := ids[nameKey(row.Author)]; ok {
+			rows[i].Book.AuthorID = id
+		}
+	}
+
+	return nil
+}
+
+// addAuthors adds authors missing in catalog once per name and sets their IDs to rows.
+// On best effort, authors are kept even if books fail afterwards, they are valid catalog entries by themselves.
+func (b Book) addAuthors(ctx context.Context, rows []models.ImportRow) error {
+	missing := make(map[string]models.Author)
+
+	for _, row := range rows {
+		if row.Book.AuthorID == newAuthorID {
+			missing[nameKey(row.Author)] = row.Author
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	list := make([]models.Author, 0, len(missing))
+	for _, author := range missing {
+		list = append(list, author)
+	}
+
+	added, err := b.author.AddMany(ctx, list)
+	if err != nil {
+		return fmt.Errorf("error adding authors: %w", err)
+	}
+
+	ids := make(map[string]string, len(added))
+	for _, author := range added {
+		ids[nameKey(author)] = author.ID
+	}
+
+	for i, row := range rows {
+		if row.Book.AuthorID == newAuthorID {
+			rows[i].Book.AuthorID = ids[nameKey(row.Author)]
+		}
+	}
+
+	return nil
+}
+
+// nameKey identifies author by name regardless of case, as repository compares names.
+func nameKey(author models.Author) string {
+	return strings.ToLower(author.FirstName) + "\x00" + strings.ToLower(author.LastName)
+}
+
+// addBestEffort adds rows in batches, rows of failed batch are added one by one.
+func (b Book) addBestEffort(ctx context.Context, rows []models.ImportRow, add func(context.Context, []models.Book) error, report *importReport) (int, error) {
+	var imported int
+
+	for start := 0; start < len(rows); start += importBatchRows {
+		end := start + importBatchRows
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		batch := rows[start:end]
+
+		err := add(ctx, books(batch))
+		if err == nil {
+			imported += len(batch)
+			continue
+		}
+
+		if errors.Is(err, exceptions.ErrDeadline) {
+			return 0, fmt.Errorf("error adding book records: %w", err)
+		}
+
+		for _, row := range batch {
+			if err := add(ctx, []models.Book{row.Book}); err != nil {
+				if errors.Is(err, exceptions.ErrDeadline) {
+					return 0, fmt.Errorf("error adding book record: %w", err)
+				}
+
+				report.add(row.Line, err)
+
+				continue
+			}
+
+			imported++
+		}
+	}
+
+	return imported, nil
+}
+
+// splitReplacing separates rows replacing books having the same ISBN from rows adding new ones.
+func splitReplacing(rows []models.ImportRow) (added, replaced []models.ImportRow) {
+	for _, row := range rows {
+		if row.Replace && row.Book.ISBN != "" {
+			replaced = append(replaced, row)
+		} else {
+			added = append(added, row)
+		}
+	}
+
+	return added, replaced
+}
+
+func books(rows []models.ImportRow) []models.Book {
+	res := make([]models.Book, len(rows))
+	for i, row := range rows {
+		res[i] = row.Book
+	}
+
+	return res
+}
+
+// importReport collects errors of rows by line number,
+// along with lines of rows that replace books already in catalog.
+type importReport struct {
+	errs    map[int]*models.ImportRowError
+	updates map[int]bool
+}
+
+// add records error of row, only validation and conflict details are exposed.
+func (r *importReport) add(line int, err error) {
+	rowErr := models.ImportRowError{Line: line, Message: "failed adding book"}
+
+	var vErr *exceptions.ValidationError
+
+	switch {
+	case errors.As(err, &vErr):
+		rowErr.Message = exceptions.ErrValidation.Error()
+		rowErr.Fields = vErr.Fields
+	case errors.Is(err, exceptions.ErrValidation):
+		rowErr.Message = err.Error()
+	case errors.Is(err, exceptions.ErrDuplicateEdition):
+		rowErr.Message = exceptions.ErrDuplicateEdition.Error()
+	case errors.Is(err, exceptions.ErrDuplicateISBN):
+		rowErr.Message = exceptions.ErrDuplicateISBN.Error()
+	}
+
+	r.errs[line] = &rowErr
+}
+
+func (r *importReport) list() []models.ImportRowError {
+	res := make([]models.ImportRowError, 0, len(r.errs))
+	for _, rowErr := range r.errs {
+		res = append(res, *rowErr)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Line < res[j].Line })
+
+	return res
+}
+
+// updated counts rows replacing books that did not fail.
+func (r *importReport) updated() int {
+	var n int
+
+	for line := range r.updates {
+		if r.errs[line] == nil {
+			n++
+		}
+	}
+
+	return n
+}
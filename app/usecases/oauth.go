@@ -0,0 +1,470 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/app/exceptions"
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/tokay"
+	"github.com/delveper/mylib/lib/tracer"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	codeExpiry  = time.Minute
+	codeEntropy = 32
+)
+
+// OAuth implements authorization server of authorization code flow with PKCE,
+// refresh token and client credentials grants (RFC 6749, RFC 7636, RFC 7662, RFC 7009).
+type OAuth struct {
+	clients ClientRepository
+	codes   AuthCodeRepository
+	readers ReaderRepository
+	sess    TokenRepository
+	cfg     *config.Reloadable
+}
+
+func NewOAuth(clients ClientRepository, codes AuthCodeRepository, readers ReaderRepository, sess TokenRepository, cfg *config.Reloadable) OAuth {
+	return OAuth{
+		clients: clients,
+		codes:   codes,
+		readers: readers,
+		sess:    sess,
+		cfg:     cfg,
+	}
+}
+
+// Register registers new client. Secret of confidential client is generated
+// and returned in plain text only once.
+func (o OAuth) Register(ctx context.Context, client models.Client) (models.Client, error) {
+	ctx, span := tracer.Start(ctx, "usecases.OAuth.Register")
+	defer span.End()
+
+	var secret string
+
+	if client.Confidential {
+		var err error
+		if secret, err = tokay.Random(codeEntropy); err != nil {
+			return models.Client{}, fmt.Errorf("error generating client secret: %w", err)
+		}
+
+		client.Secret = secret
+		if err := client.HashSecret(); err != nil {
+			return models.Client{}, err
+		}
+	}
+
+	client, err := o.clients.Add(ctx, client)
+	if err != nil {
+		return models.Client{}, fmt.Errorf("error adding client record: %w", err)
+	}
+
+	client.Secret = secret
+
+	return client, nil
+}
+
+// Consent validates authorization request and describes access client asks for.
+func (o OAuth) Consent(ctx context.Context, req models.AuthRequest) (*models.Consent, error) {
+	ctx, span := tracer.Start(ctx, "usecases.OAuth.Consent")
+	defer span.End()
+
+	client, scope, err := o.validateAuthRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Scope = scope
+
+	return &models.Consent{
+		ClientID:   client.ID,
+		ClientName: client.Name,
+		Scopes:     strings.Fields(scope),
+		Request:    req,
+	}, nil
+}
+
+// Authorize issues authorization code after reader approved request
+// and returns URI reader has to be redirected to.
+func (o OAuth) Authorize(ctx context.Context, token models.AccessToken, req models.AuthRequest) (string, error) {
+	ctx, span := tracer.Start(ctx, "usecases.OAuth.Authorize")
+	defer span.End()
+
+	if token.ClientID != "" {
+		return "", fmt.Errorf("client can not give consent on behalf of reader: %w", exceptions.ErrAccessDenied)
+	}
+
+	client, scope, err := o.validateAuthRequest(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	val, err := tokay.Random(codeEntropy)
+	if err != nil {
+		return "", fmt.Errorf("error generating code: %w", err)
+	}
+
+	code := models.AuthCode{
+		Code:                val,
+		ClientID:            client.ID,
+		ReaderID:            token.ReaderID,
+		Role:                token.Role,
+		RedirectURI:         req.RedirectURI,
+		Scope:               scope,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Expiry:              codeExpiry,
+	}
+
+	if err := o.codes.Create(ctx, code); err != nil {
+		return "", fmt.Errorf("error saving code: %w", err)
+	}
+
+	return redirectURI(req.RedirectURI, url.Values{"code": {val}, "state": {req.State}}), nil
+}
+
+// Deny returns URI reader has to be redirected to after denying access.
+// Request is validated to not redirect reader to arbitrary URI.
+func (o OAuth) Deny(ctx context.Context, _ models.AccessToken, req models.AuthRequest) (string, error) {
+	ctx, span := tracer.Start(ctx, "usecases.OAuth.Deny")
+	defer span.End()
+
+	if _, _, err := o.validateAuthRequest(ctx, req); err != nil {
+		return "", err
+	}
+
+	return redirectURI(req.RedirectURI, url.Values{"error": {"access_denied"}, "state": {req.State}}), nil
+}
+
+// Exchange handles token endpoint according to requested grant type.
+func (o OAuth) Exchange(ctx context.Context, req models.TokenRequest) (*models.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "usecases.OAuth.Exchange")
+	defer span.End()
+
+	client, err := o.authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.Allows(req.GrantType) {
+		return nil, fmt.Errorf("%w: %s", exceptions.ErrUnsupportedGrant, req.GrantType)
+	}
+
+	switch req.GrantType {
+	case models.GrantAuthorizationCode:
+		return o.exchangeCode(ctx, client, req)
+	case models.GrantRefreshToken:
+		return o.exchangeRefreshToken(ctx, client, req)
+	case models.GrantClientCredentials:
+		return o.exchangeClientCredentials(ctx, client, req)
+	default:
+		return nil, fmt.Errorf("%w: %s", exceptions.ErrUnsupportedGrant, req.GrantType)
+	}
+}
+
+// Introspect describes state of given token to authenticated client (RFC 7662).
+// Tokens that are expired, revoked or malformed are reported as inactive.
+// Public clients can not prove their identity, so that they may introspect only tokens issued to them,
+// others are reported as inactive too.
+func (o OAuth) Introspect(ctx context.Context, req models.TokenRequest, val string) (*models.Introspection, error) {
+	ctx, span := tracer.Start(ctx, "usecases.OAuth.Introspect")
+	defer span.End()
+
+	client, err := o.authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	info, _, err := o.parseGrant(ctx, val)
+	if err != nil || (!client.Confidential && info.ClientID != client.ID) {
+		return &models.Introspection{Active: false}, nil
+	}
+
+	return info, nil
+}
+
+// Revoke invalidates grant given token belongs to (RFC 7009).
+// Unknown or invalid tokens do not cause error. Access tokens are stateless,
+// so that access token of revoked grant is accepted until it expires, which is JWT_CLIENT_ACCESS_EXP at most.
+// Refresh and introspection of revoked grant fail at once.
+func (o OAuth) Revoke(ctx context.Context, req models.TokenRequest, val string) error {
+	ctx, span := tracer.Start(ctx, "usecases.OAuth.Revoke")
+	defer span.End()
+
+	client, err := o.authenticate(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	info, grantID, err := o.parseGrant(ctx, val)
+	if err != nil || info.ClientID != client.ID {
+		return nil
+	}
+
+	if err := o.sess.Destroy(ctx, models.Token{ID: grantID}); err != nil {
+		return fmt.Errorf("error destroying grant: %w", err)
+	}
+
+	return nil
+}
+
+func (o OAuth) exchangeCode(ctx context.Context, client models.Client, req models.TokenRequest) (*models.TokenPair, error) {
+	code, err := o.codes.Consume(ctx, models.AuthCode{Code: req.Code})
+	if err != nil {
+		return nil, fmt.Errorf("error consuming code: %w", err)
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != req.RedirectURI {
+		return nil, fmt.Errorf("%w: code was issued to another client or redirect uri", exceptions.ErrInvalidGrant)
+	}
+
+	if err := tokay.VerifyPKCE(req.CodeVerifier, code.CodeChallenge, code.CodeChallengeMethod); err != nil {
+		return nil, fmt.Errorf("%w: %v", exceptions.ErrInvalidGrant, err)
+	}
+
+	return o.newGrant(ctx, client, code.ReaderID, code.Role, code.Scope)
+}
+
+func (o OAuth) exchangeRefreshToken(ctx context.Context, client models.Client, req models.TokenRequest) (*models.TokenPair, error) {
+	token, err := tokay.Parse[models.RefreshToken](req.RefreshToken, o.cfg.Current().JWT.Key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", exceptions.ErrInvalidGrant, err)
+	}
+
+	if token.ClientID != client.ID {
+		return nil, fmt.Errorf("%w: token was issued to another client", exceptions.ErrInvalidGrant)
+	}
+
+	// Grant is consumed at once, so that concurrent replays of the same token do not both succeed.
+	grant, err := o.sess.Consume(ctx, models.Token{ID: token.ID})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", exceptions.ErrInvalidGrant, err)
+	}
+
+	if grant.UID != client.ID {
+		return nil, fmt.Errorf("%w: grant was issued to another client", exceptions.ErrInvalidGrant)
+	}
+
+	scope := token.Scope
+	if req.Scope != "" {
+		if scope, err = narrowScope(token.Scope, req.Scope); err != nil {
+			return nil, err
+		}
+	}
+
+	reader, err := o.readers.GetByID(ctx, models.Reader{ID: token.ReaderID})
+	if err != nil {
+		return nil, fmt.Errorf("errror fetching reader: %w", err)
+	}
+
+	return o.newGrant(ctx, client, reader.ID, reader.Role, scope)
+}
+
+func (o OAuth) exchangeClientCredentials(ctx context.Context, client models.Client, req models.TokenRequest) (*models.TokenPair, error) {
+	if !client.Confidential {
+		return nil, fmt.Errorf("%w: client is not confidential", exceptions.ErrInvalidClient)
+	}
+
+	scope, err := client.GrantScope(req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.newGrant(ctx, client, "", "", scope)
+}
+
+// newGrant records grant in session storage and issues tokens bound to it.
+// Access token is short-lived, its lifetime bounds revocation.
+// Refresh token is issued only on behalf of reader and only if client is allowed to refresh.
+func (o OAuth) newGrant(ctx context.Context, client models.Client, readerID, role, scope string) (*models.TokenPair, error) {
+	jwt := o.cfg.Current().JWT
+	alg, key := jwt.Alg, jwt.Key
+	accessExp, refreshExp := jwt.ClientAccessExp, jwt.RefreshExp
+
+	withRefresh := readerID != "" && client.Allows(models.GrantRefreshToken)
+
+	grant := models.Token{ID: uuid.New().String(), UID: client.ID, Expiry: accessExp}
+	if withRefresh {
+		grant.Expiry = refreshExp
+	}
+
+	if err := o.sess.Create(ctx, grant); err != nil {
+		return nil, fmt.Errorf("error recording grant: %w", err)
+	}
+
+	access := models.AccessToken{
+		ReaderID:       readerID,
+		RefreshTokenID: grant.ID,
+		Role:           role,
+		ClientID:       client.ID,
+		Scope:          scope,
+		Expiry:         accessExp,
+	}
+
+	accessVal, err := tokay.Make[models.AccessToken](alg, key, accessExp, access)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", err, exceptions.ErrTokenNotCreated)
+	}
+
+	pair := models.TokenPair{
+		AccessToken: accessVal,
+		TokenType:   "Bearer",
+		ExpiresIn:   time.Duration(accessExp.Seconds()),
+		Scope:       scope,
+	}
+
+	if withRefresh {
+		refresh := models.RefreshToken{
+			ID:       grant.ID,
+			ReaderID: readerID,
+			ClientID: client.ID,
+			Scope:    scope,
+			Expiry:   refreshExp,
+		}
+
+		if pair.RefreshToken, err = tokay.Make[models.RefreshToken](alg, key, refreshExp, refresh); err != nil {
+			return nil, fmt.Errorf("%v: %w", err, exceptions.ErrTokenNotCreated)
+		}
+	}
+
+	return &pair, nil
+}
+
+// parseGrant parses either access or refresh token, checks
+// that grant it is bound to was not revoked and returns grant ID.
+func (o OAuth) parseGrant(ctx context.Context, val string) (*models.Introspection, string, error) {
+	key := o.cfg.Current().JWT.Key
+
+	var info models.Introspection
+	var grantID string
+
+	if access, err := tokay.Parse[models.AccessToken](val, key); err == nil && access.RefreshTokenID != "" {
+		grantID = access.RefreshTokenID
+		info = models.Introspection{
+			Scope:     access.Scope,
+			ClientID:  access.ClientID,
+			Subject:   access.ReaderID,
+			TokenType: "access_token",
+		}
+	} else if refresh, err := tokay.Parse[models.RefreshToken](val, key); err == nil && refresh.ID != "" {
+		grantID = refresh.ID
+		info = models.Introspection{
+			Scope:     refresh.Scope,
+			ClientID:  refresh.ClientID,
+			Subject:   refresh.ReaderID,
+			TokenType: "refresh_token",
+		}
+	} else {
+		return nil, "", exceptions.ErrTokenInvalid
+	}
+
+	if info.ClientID == "" {
+		return nil, "", exceptions.ErrTokenInvalid
+	}
+
+	grant, err := o.sess.Find(ctx, models.Token{ID: grantID})
+	if err != nil {
+		return nil, "", err
+	}
+
+	if grant.UID != info.ClientID {
+		return nil, "", exceptions.ErrTokenInvalid
+	}
+
+	info.Active = true
+
+	return &info, grantID, nil
+}
+
+// authenticate verifies client credentials.
+// Public clients are identified by ID only.
+func (o OAuth) authenticate(ctx context.Context, req models.TokenRequest) (models.Client, error) {
+	if req.ClientID == "" {
+		return models.Client{}, fmt.Errorf("%w: missing client id", exceptions.ErrInvalidClient)
+	}
+
+	client, err := o.clients.GetByID(ctx, models.Client{ID: req.ClientID})
+	if err != nil {
+		if errors.Is(err, exceptions.ErrClientNotFound) {
+			return models.Client{}, fmt.Errorf("%w: %w", exceptions.ErrInvalidClient, err)
+		}
+
+		return models.Client{}, fmt.Errorf("error fetching client: %w", err)
+	}
+
+	if client.Confidential {
+		if err := client.CheckSecret(req.ClientSecret); err != nil {
+			return models.Client{}, fmt.Errorf("%w: %w", exceptions.ErrInvalidClient, err)
+		}
+	}
+
+	return client, nil
+}
+
+func (o OAuth) validateAuthRequest(ctx context.Context, req models.AuthRequest) (models.Client, string, error) {
+	client, err := o.clients.GetByID(ctx, models.Client{ID: req.ClientID})
+	if err != nil {
+		if errors.Is(err, exceptions.ErrClientNotFound) {
+			return models.Client{}, "", fmt.Errorf("%w: %w", exceptions.ErrInvalidClient, err)
+		}
+
+		return models.Client{}, "", fmt.Errorf("error fetching client: %w", err)
+	}
+
+	if !client.HasRedirectURI(req.RedirectURI) {
+		return models.Client{}, "", fmt.Errorf("%w: redirect uri is not registered", exceptions.ErrInvalidRequest)
+	}
+
+	if req.ResponseType != "code" || !client.Allows(models.GrantAuthorizationCode) {
+		return models.Client{}, "", fmt.Errorf("%w: unsupported response type %q", exceptions.ErrInvalidRequest, req.ResponseType)
+	}
+
+	// PKCE is required for every client.
+	if req.CodeChallenge == "" {
+		return models.Client{}, "", fmt.Errorf("%w: code challenge is required", exceptions.ErrInvalidRequest)
+	}
+
+	if req.CodeChallengeMethod != tokay.MethodS256 && req.CodeChallengeMethod != tokay.MethodPlain {
+		return models.Client{}, "", fmt.Errorf("%w: unsupported code challenge method", exceptions.ErrInvalidRequest)
+	}
+
+	scope, err := client.GrantScope(req.Scope)
+	if err != nil {
+		return models.Client{}, "", err
+	}
+
+	return client, scope, nil
+}
+
+// narrowScope checks that requested scope is subset of granted one.
+func narrowScope(granted, requested string) (string, error) {
+	client := models.Client{Scopes: strings.Fields(granted)}
+
+	return client.GrantScope(requested)
+}
+
+// redirectURI appends non-empty query parameters to redirect URI.
+func redirectURI(uri string, params url.Values) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+
+	q := u.Query()
+	for k, v := range params {
+		if len(v) > 0 && v[0] != "" {
+			q[k] = v
+		}
+	}
+
+	u.RawQuery = q.Encode()
+
+	return u.String()
+}
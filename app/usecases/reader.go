@@ -4,24 +4,35 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/delveper/mylib/app/config"
 	"github.com/delveper/mylib/app/exceptions"
 	"github.com/delveper/mylib/app/models"
 	"github.com/delveper/mylib/lib/hash"
+	"github.com/delveper/mylib/lib/tokay"
+	"github.com/delveper/mylib/lib/tracer"
+	"github.com/pkg/errors"
 )
 
 type Reader struct {
-	repo ReaderRepository
-	sess TokenRepository
+	repo    ReaderRepository
+	sess    TokenRepository
+	metrics models.Metrics
+	cfg     *config.Reloadable
 }
 
-func NewReader(repo ReaderRepository, sess TokenRepository) Reader {
+func NewReader(repo ReaderRepository, sess TokenRepository, metrics models.Metrics, cfg *config.Reloadable) Reader {
 	return Reader{
-		repo: repo,
-		sess: sess,
+		repo:    repo,
+		sess:    sess,
+		metrics: metrics,
+		cfg:     cfg,
 	}
 }
 
 func (r Reader) Auth(ctx context.Context, token models.AccessToken) error {
+	ctx, span := tracer.Start(ctx, "usecases.Reader.Auth")
+	defer span.End()
+
 	accessToken := models.Token{ID: token.ReaderID, UID: token.RefreshTokenID}
 
 	savedToken, err := r.sess.Find(ctx, accessToken)
@@ -37,20 +48,35 @@ func (r Reader) Auth(ctx context.Context, token models.AccessToken) error {
 }
 
 func (r Reader) SignUp(ctx context.Context, reader models.Reader) error {
+	ctx, span := tracer.Start(ctx, "usecases.Reader.SignUp")
+	defer span.End()
+
 	if err := r.repo.Add(ctx, reader); err != nil {
 		return fmt.Errorf("error signup reader: %w", err)
 	}
 
+	r.metrics.Inc(models.EventSignUp)
+
 	return nil
 }
 
 func (r Reader) SignIn(ctx context.Context, creds models.Credentials) (*models.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Reader.SignIn")
+	defer span.End()
+
 	reader, err := r.repo.GetByEmail(ctx, models.Reader{Email: creds.Email})
 	if err != nil {
+		r.metrics.Inc(models.EventLoginFailed)
+
+		if errors.Is(err, exceptions.ErrRecordNotFound) {
+			return nil, exceptions.ErrInvalidCredits
+		}
+
 		return nil, fmt.Errorf("errror fetching reader: %w", err)
 	}
 
 	if err := hash.Verify(creds.Password, reader.Password); err != nil {
+		r.metrics.Inc(models.EventLoginFailed)
 		return nil, exceptions.ErrInvalidCredits
 	}
 
@@ -59,10 +85,15 @@ func (r Reader) SignIn(ctx context.Context, creds models.Credentials) (*models.T
 		return nil, fmt.Errorf("%v: %w", err, exceptions.ErrTokenNotCreated)
 	}
 
+	r.metrics.Inc(models.EventLoginSucceeded)
+
 	return tokenPair, nil
 }
 
 func (r Reader) SignOut(ctx context.Context, token models.AccessToken) error {
+	ctx, span := tracer.Start(ctx, "usecases.Reader.SignOut")
+	defer span.End()
+
 	accessToken := models.Token{ID: token.ReaderID, UID: token.RefreshTokenID}
 
 	if err := r.sess.Destroy(ctx, accessToken); err != nil {
@@ -78,11 +109,29 @@ func (r Reader) SignOut(ctx context.Context, token models.AccessToken) error {
 	return nil
 }
 
-func (r Reader) Refresh(ctx context.Context, token models.RefreshToken) (*models.TokenPair, error) {
-	refreshToken := models.Token{ID: token.ID}
+// Refresh issues new token pair in exchange for signed refresh token of the same reader as access token.
+// Refresh token is consumed at once, so that it can be exchanged only once.
+// Refresh tokens issued to third-party clients are exchanged at token endpoint of OAuth only.
+func (r Reader) Refresh(ctx context.Context, access models.AccessToken, val string) (*models.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Reader.Refresh")
+	defer span.End()
 
-	if err := r.sess.Destroy(ctx, refreshToken); err != nil {
-		return nil, fmt.Errorf("error destroying refresh token: %w", err)
+	token, err := tokay.Parse[models.RefreshToken](val, r.cfg.Current().JWT.Key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", exceptions.ErrTokenInvalid, err)
+	}
+
+	if token.ClientID != "" || token.ReaderID == "" || token.ReaderID != access.ReaderID {
+		return nil, fmt.Errorf("%w: refresh token was issued to another reader or client", exceptions.ErrTokenInvalid)
+	}
+
+	saved, err := r.sess.Consume(ctx, models.Token{ID: token.ID})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", exceptions.ErrTokenInvalid, err)
+	}
+
+	if saved.UID != token.ReaderID {
+		return nil, exceptions.ErrTokenInvalid
 	}
 
 	reader, err := r.repo.GetByID(ctx, models.Reader{ID: token.ReaderID})
@@ -0,0 +1,114 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/tracer"
+)
+
+// Scores of books sharing author or genre with saved one. Books readers saved together
+// are scored by cosine similarity, which is at most 1 for every saved book.
+const (
+	scoreSameAuthor = 0.5
+	scoreSameGenre  = 0.25
+)
+
+// Recommend merges books saved together with ones reader saved and books sharing their author or genre,
+// scores of the same book are summed up and the strongest reason explains it.
+// Popular books fill in the rest, so that reader who saved nothing yet gets recommendations as well.
+func (b Book) Recommend(ctx context.Context, reader models.Reader, limit int) ([]models.Recommendation, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Book.Recommend")
+	defer span.End()
+
+	alsoSaved, err := b.repo.AlsoSaved(ctx, reader, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching books saved together: %w", err)
+	}
+
+	related, err := b.repo.Related(ctx, reader, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching related books: %w", err)
+	}
+
+	recs := make([]models.Recommendation, 0, limit)
+
+	index := make(map[string]int)
+
+	for _, rec := range append(alsoSaved, related...) {
+		switch rec.Reason {
+		case models.ReasonSameAuthor:
+			rec.Score = scoreSameAuthor
+		case models.ReasonSameGenre:
+			rec.Score = scoreSameGenre
+		}
+
+		i, ok := index[rec.Book.ID]
+		if !ok {
+			index[rec.Book.ID] = len(recs)
+			recs = append(recs, rec)
+
+			continue
+		}
+
+		score := recs[i].Score + rec.Score
+		if rec.Score > recs[i].Score {
+			recs[i] = rec
+		}
+
+		recs[i].Score = score
+	}
+
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Score > recs[j].Score })
+
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+
+	if len(recs) < limit {
+		popular, err := b.repo.Popular(ctx, reader, limit)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching popular books: %w", err)
+		}
+
+		for _, rec := range popular {
+			if _, ok := index[rec.Book.ID]; ok || len(recs) == limit {
+				continue
+			}
+
+			// Popularity counts readers, it is not comparable to scores of other reasons.
+			rec.Score = 0
+			recs = append(recs, rec)
+		}
+	}
+
+	for i := range recs {
+		recs[i].Explain()
+	}
+
+	return recs, nil
+}
+
+// Similarity refreshes similarity of works saved together, it runs as periodic batch job.
+type Similarity struct {
+	repo BookRepository
+}
+
+func NewSimilarity(repo BookRepository) Similarity {
+	return Similarity{repo: repo}
+}
+
+// Refresh recomputes similarity of every pair of works and returns number of pairs kept.
+func (s Similarity) Refresh(ctx context.Context, opts models.SimilarityOptions) (int64, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Similarity.Refresh")
+	defer span.End()
+
+	n, err := s.repo.RefreshSimilarity(ctx, opts)
+	if err != nil {
+		return 0, fmt.Errorf("error refreshing similarity of works: %w", err)
+	}
+
+	return n, nil
+}
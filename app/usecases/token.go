@@ -3,9 +3,9 @@ package usecases
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 
+	"github.com/delveper/mylib/app/config"
 	"github.com/delveper/mylib/app/exceptions"
 	"github.com/delveper/mylib/app/models"
 	"github.com/delveper/mylib/lib/tokay"
@@ -13,7 +13,9 @@ import (
 )
 
 func (r Reader) newTokenPair(ctx context.Context, reader models.Reader) (*models.TokenPair, error) {
-	refreshToken, refreshTokenVal, err := newRefreshToken(reader.ID)
+	jwt := r.cfg.Current().JWT
+
+	refreshToken, refreshTokenVal, err := newRefreshToken(jwt, reader.ID)
 	if err != nil {
 		return nil, fmt.Errorf("%v: %w", err, exceptions.ErrTokenNotCreated)
 	}
@@ -21,7 +23,7 @@ func (r Reader) newTokenPair(ctx context.Context, reader models.Reader) (*models
 		return nil, err
 	}
 
-	accessToken, accessTokenVal, err := newAccessToken(reader.ID, refreshToken.ID, reader.Role)
+	accessToken, accessTokenVal, err := newAccessToken(jwt, reader.ID, refreshToken.ID, reader.Role)
 	if err != nil {
 		return nil, fmt.Errorf("%v: %w", err, exceptions.ErrTokenNotCreated)
 	}
@@ -37,15 +39,8 @@ func (r Reader) newTokenPair(ctx context.Context, reader models.Reader) (*models
 	}, nil
 }
 
-func newAccessToken(readerID, refreshTokenID, role string) (token models.Token, val string, err error) {
-	alg := os.Getenv("JWT_ALG")
-	key := os.Getenv("JWT_KEY")
-
-	exp, err := time.ParseDuration(os.Getenv("JWT_ACCESS_EXP"))
-
-	if err != nil {
-		return models.Token{}, "", fmt.Errorf("error parsing access token expirity: %w", err)
-	}
+func newAccessToken(cfg config.JWT, readerID, refreshTokenID, role string) (token models.Token, val string, err error) {
+	exp := cfg.AccessExp
 
 	data := models.AccessToken{
 		ReaderID:       readerID,
@@ -54,7 +49,7 @@ func newAccessToken(readerID, refreshTokenID, role string) (token models.Token,
 		Expiry:         exp,
 	}
 
-	val, err = tokay.Make[models.AccessToken](alg, key, exp, data)
+	val, err = tokay.Make[models.AccessToken](cfg.Alg, cfg.Key, exp, data)
 	if err != nil {
 		return models.Token{}, "", fmt.Errorf("error making access token: %w", err)
 	}
@@ -68,16 +63,9 @@ func newAccessToken(readerID, refreshTokenID, role string) (token models.Token,
 	return token, val, nil
 }
 
-func newRefreshToken(uid string) (token models.Token, val string, err error) {
+func newRefreshToken(cfg config.JWT, uid string) (token models.Token, val string, err error) {
 	id := uuid.New().String()
-
-	alg := os.Getenv("JWT_ALG")
-	key := os.Getenv("JWT_KEY")
-
-	exp, err := time.ParseDuration(os.Getenv("JWT_REFRESH_EXP"))
-	if err != nil {
-		return models.Token{}, "", fmt.Errorf("error parsing refresh token expirity: %w", err)
-	}
+	exp := cfg.RefreshExp
 
 	data := models.RefreshToken{
 		ID:       id,
@@ -85,7 +73,7 @@ func newRefreshToken(uid string) (token models.Token, val string, err error) {
 		Expiry:   exp,
 	}
 
-	val, err = tokay.Make[models.RefreshToken](alg, key, exp, data)
+	val, err = tokay.Make[models.RefreshToken](cfg.Alg, cfg.Key, exp, data)
 	if err != nil {
 		return models.Token{}, "", fmt.Errorf("error making refresh token: %w", err)
 	}
@@ -0,0 +1,67 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/delveper/mylib/app/models"
+	"github.com/delveper/mylib/lib/tracer"
+)
+
+type Work struct {
+	repo WorkRepository
+}
+
+func NewWork(repo WorkRepository) Work {
+	return Work{repo: repo}
+}
+
+func (w Work) Create(ctx context.Context, work models.Work) (models.Work, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Work.Create")
+	defer span.End()
+
+	work, err := w.repo.Add(ctx, work)
+	if err != nil {
+		return models.Work{}, fmt.Errorf("error adding work record: %w", err)
+	}
+
+	return work, nil
+}
+
+// Fetch returns work with its editions.
+func (w Work) Fetch(ctx context.Context, work models.Work) (models.Work, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Work.Fetch")
+	defer span.End()
+
+	work, err := w.repo.GetByID(ctx, work)
+	if err != nil {
+		return models.Work{}, fmt.Errorf("error fetching work record: %w", err)
+	}
+
+	return work, nil
+}
+
+func (w Work) CreateSeries(ctx context.Context, series models.Series) (models.Series, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Work.CreateSeries")
+	defer span.End()
+
+	series, err := w.repo.AddSeries(ctx, series)
+	if err != nil {
+		return models.Series{}, fmt.Errorf("error adding series record: %w", err)
+	}
+
+	return series, nil
+}
+
+// FetchSeries returns series with its works in order, so that series can be read through.
+func (w Work) FetchSeries(ctx context.Context, series models.Series) (models.Series, error) {
+	ctx, span := tracer.Start(ctx, "usecases.Work.FetchSeries")
+	defer span.End()
+
+	series, err := w.repo.GetSeries(ctx, series)
+	if err != nil {
+		return models.Series{}, fmt.Errorf("error fetching series record: %w", err)
+	}
+
+	return series, nil
+}
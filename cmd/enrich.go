@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/delveper/mylib/app/models"
+	repo "github.com/delveper/mylib/app/repository/psql"
+	"github.com/delveper/mylib/app/usecases"
+)
+
+// Enrich fills in missing fields of books from Open Library dumps downloaded beforehand,
+// nothing is fetched over network. Dumps may be gzip compressed, e.g.
+//
+//	mylib enrich -editions ol_dump_editions_latest.txt.gz -works ol_dump_works_latest.txt.gz -authors ol_dump_authors_latest.txt.gz
+func Enrich(args []string) {
+	flags := flag.NewFlagSet("enrich", flag.ExitOnError)
+	editionsPath := flags.String("editions", "", "path of editions dump, required")
+	worksPath := flags.String("works", "", "path of works dump, subjects and covers of works are used if editions have none")
+	authorsPath := flags.String("authors", "", "path of authors dump, without it books without common ISBN are matched by title only")
+	minConfidence := flags.Float64("min-confidence", 0.8, "fill in books only from matches at least this confident, the rest are recorded for review")
+	dryRun := flags.Bool("dry-run", false, "report matches without recording them")
+
+	_ = flags.Parse(args)
+
+	if *editionsPath == "" {
+		flags.Usage()
+		return
+	}
+
+	runJob("enrich", func(ctx context.Context, db *sql.DB, logger models.Logger) error {
+		var src usecases.EnrichSource
+
+		for _, dump := range []struct {
+			path string
+			r    *io.Reader
+		}{
+			{*editionsPath, &src.Editions},
+			{*worksPath, &src.Works},
+			{*authorsPath, &src.Authors},
+		} {
+			if dump.path == "" {
+				continue
+			}
+
+			file, err := os.Open(dump.path)
+			if err != nil {
+				return fmt.Errorf("error opening dump: %w", err)
+			}
+
+			defer file.Close()
+
+			*dump.r = file
+		}
+
+		enrich := usecases.NewEnrich(repo.NewBook(db), repo.NewBookMatch(db))
+
+		report, err := enrich.Run(ctx, src, models.EnrichOptions{MinConfidence: *minConfidence, DryRun: *dryRun})
+		if err != nil {
+			return err
+		}
+
+		logger.Infow("Books enriched.",
+			"incomplete", report.Incomplete,
+			"matched", report.Matched,
+			"applied", report.Applied,
+			"skipped", report.Skipped,
+			"methods", report.Methods,
+			"dry_run", *dryRun,
+		)
+
+		return nil
+	})
+}
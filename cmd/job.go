@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/delveper/mylib/app/config"
+	"github.com/delveper/mylib/app/models"
+	repo "github.com/delveper/mylib/app/repository/psql"
+	"github.com/delveper/mylib/lib/banderlog"
+	"github.com/delveper/mylib/mig"
+)
+
+// runJob sets up logger and repo the same way server does and runs batch job,
+// which is canceled on interrupt.
+func runJob(name string, job func(ctx context.Context, db *sql.DB, logger models.Logger) error) {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config: %+v", err)
+		return
+	}
+
+	var logger models.Logger = banderlog.New(cfg.Log)
+	defer func() {
+		if err := logger.Flush(); err != nil {
+			log.Printf("Failed flush logger: %+v", err)
+		}
+	}()
+
+	repoConn, err := repo.Connect(cfg.DB)
+	if err != nil {
+		logger.Errorf("Failed connecting to repo: %+v", err)
+		return
+	}
+
+	defer func() {
+		if err := repoConn.Close(); err != nil {
+			logger.Warnf("Failed closing repo connection: %+v", err)
+		}
+	}()
+
+	migration := mig.New()
+	migration.SetLogger(logger)
+
+	if err := migration.Run(repoConn, cfg.DB.Dialect, cfg.DB.Migrate); err != nil {
+		logger.Errorf("Failed making migrations: %+v", err)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Infof("Job %s started.", name)
+
+	if err := job(ctx, repoConn, logger); err != nil {
+		logger.Errorf("Failed running job %s: %+v", name, err)
+		return
+	}
+
+	logger.Infof("Job %s finished.", name)
+}
@@ -1,30 +1,64 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/delveper/mylib/app/config"
 	"github.com/delveper/mylib/app/models"
 	"github.com/delveper/mylib/app/presenters/rest"
 	repo "github.com/delveper/mylib/app/repository/psql"
 	sess "github.com/delveper/mylib/app/repository/rds"
 	"github.com/delveper/mylib/app/usecases"
 	"github.com/delveper/mylib/lib/banderlog"
-	"github.com/delveper/mylib/lib/env"
+	"github.com/delveper/mylib/lib/metrics"
+	"github.com/delveper/mylib/lib/oidc"
+	"github.com/delveper/mylib/lib/throttle"
+	"github.com/delveper/mylib/lib/tracer"
 	"github.com/delveper/mylib/mig"
+	"github.com/go-chi/chi/v5"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "enrich":
+			Enrich(os.Args[2:])
+			return
+		case "similarity":
+			Similarity(os.Args[2:])
+			return
+		}
+	}
+
 	Run()
 }
 
 func Run() {
-	if err := env.LoadVars(); err != nil {
-		log.Printf("Failed to load environment variables: %+v", err)
+	printConfig := flag.Bool("print-config", false, "print loaded configuration with secrets masked and exit")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config: %+v", err)
+		return
+	}
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Printf("Failed printing config: %+v", err)
+		}
+
 		return
 	}
 
-	var logger models.Logger = banderlog.New()
+	logger := banderlog.New(cfg.Log)
 	defer func() {
 		if err := logger.Flush(); err != nil {
 			log.Printf("Failed flush logger: %+v", err)
@@ -32,27 +66,75 @@ func Run() {
 	}()
 	logger.Infof("Logger set up with level: %s", logger.Level())
 
-	repoConn, err := repo.Connect()
+	reloader := config.NewReloadable(cfg, logger)
+	reloader.Subscribe(func(old, cur *config.Config) {
+		if old.Log.Level == cur.Log.Level {
+			return
+		}
+
+		if err := logger.SetLevel(cur.Log.Level); err != nil {
+			logger.Warnf("Failed changing logger level: %+v", err)
+		}
+	})
+
+	// SIGHUP reloads config, banderlog reopens log file on it as well.
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	go func() {
+		for range hangup {
+			_, _ = reloader.Reload("sighup")
+		}
+	}()
+
+	metric := metrics.New("mylib")
+
+	shutdownTracer, err := tracer.Setup(context.Background(), "mylib", cfg.Trace.Exporter)
+	if err != nil {
+		logger.Errorf("Failed setting up tracer: %+v", err)
+		return
+	}
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdownTracer(ctx); err != nil {
+			logger.Warnf("Failed flushing traces: %+v", err)
+		}
+	}()
+	logger.Infof("Tracer set up with exporter: %s", cfg.Trace.Exporter)
+
+	repoConn, err := repo.Connect(cfg.DB)
 	if err != nil {
 		logger.Errorf("Failed connecting to repo: %+v", err)
+		return
 	}
 
 	defer func() {
 		if err := repoConn.Close(); err != nil {
 			logger.Warnf("Failed closing repo connection: %+v", err)
 		}
+
+		logger.Infof("Connection to repo closed.")
 	}()
 	logger.Infof("Connection to repo established.")
 
+	if err := metric.RegisterDB(repoConn, "repo"); err != nil {
+		logger.Errorf("Failed registering repo metrics: %+v", err)
+		return
+	}
+
 	migration := mig.New()
 	migration.SetLogger(logger)
 
-	if err := migration.Run(repoConn); err != nil {
+	if err := migration.Run(repoConn, cfg.DB.Dialect, cfg.DB.Migrate); err != nil {
 		logger.Errorf("Failed making migrations: %+v", err)
 		return
 	}
 
-	sessConn, err := sess.Connect()
+	sessConn, err := sess.Connect(cfg.Session)
 	if err != nil {
 		logger.Errorf("Failed connecting to session repo: %+v", err)
 		return
@@ -62,48 +144,141 @@ func Run() {
 		if err := sessConn.Close(); err != nil {
 			logger.Warnf("Failed closing session repo connection: %+v", err)
 		}
+
+		logger.Infof("Connection to session repo closed.")
 	}()
 
+	sessConn.AddHook(metric.RedisHook())
+	sessConn.AddHook(tracer.RedisHook())
+
 	readerRepo := repo.NewReader(repoConn)
 	bookRepo := repo.NewBook(repoConn)
+	genreRepo := repo.NewGenre(repoConn)
+	workRepo := repo.NewWork(repoConn)
 	tokenRepo := sess.NewToken(sessConn)
 	authorRepo := repo.NewAuthor(repoConn)
+	importJobRepo := repo.NewImportJob(repoConn)
+	clientRepo := repo.NewClient(repoConn)
+	identityRepo := repo.NewIdentity(repoConn)
+	codeRepo := sess.NewAuthCode(sessConn)
+	txRepo := repo.NewTx(repoConn)
 
 	logger.Infof("Repository layer initialized.")
 
-	readerLogic := usecases.NewReader(readerRepo, tokenRepo)
-	bookLogic := usecases.NewBook(bookRepo, authorRepo)
+	readerLogic := usecases.NewReader(readerRepo, tokenRepo, metric, reloader)
+	bookLogic := usecases.NewBook(bookRepo, authorRepo, importJobRepo, txRepo, metric, reloader)
+	genreLogic := usecases.NewGenre(genreRepo)
+	workLogic := usecases.NewWork(workRepo)
+	oauthLogic := usecases.NewOAuth(clientRepo, codeRepo, readerRepo, tokenRepo, reloader)
 
 	logger.Infof("Usecase layer initialized.")
 
-	readerREST := rest.NewReader(readerLogic, logger)
-	bookREST := rest.NewBook(bookLogic, logger)
+	if n, err := bookLogic.FailStaleImports(context.Background()); err != nil {
+		logger.Warnf("Failed marking stale import jobs: %+v", err)
+	} else if n > 0 {
+		logger.Infof("Stale import jobs marked as failed: %d", n)
+	}
+
+	// Import jobs are waited for after server stopped and before repo is closed.
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.DrainTimeout)
+		defer cancel()
+
+		if err := bookLogic.StopImports(ctx); err != nil {
+			logger.Warnf("Failed waiting for import jobs: %+v", err)
+		}
+	}()
+
+	readerREST := rest.NewReader(readerLogic, logger, reloader)
+	bookREST := rest.NewBook(bookLogic, logger, reloader)
+	genreREST := rest.NewGenre(genreLogic, logger, reloader)
+	workREST := rest.NewWork(workLogic, logger, reloader)
+	oauthREST := rest.NewOAuth(oauthLogic, logger, reloader)
+	configREST := rest.NewConfig(logger, reloader)
 
 	logger.Infof("RESTish layer initialized.")
 
-	router := rest.NewRouter(
+	health := rest.NewHealth(logger,
+		rest.Check{Name: "postgres", Fn: repoConn.PingContext},
+		rest.Check{Name: "redis", Fn: func(ctx context.Context) error { return sessConn.Ping(ctx).Err() }},
+		rest.Check{Name: "migrations", Fn: func(ctx context.Context) error { return migration.Check(ctx, repoConn) }},
+	)
+
+	routes := []func(chi.Router){
+		health.Route,
+		func(rtr chi.Router) { rtr.Handle("/metrics", metric.Handler()) },
 		readerREST.Route,
 		bookREST.Route,
-	)
+		genreREST.Route,
+		workREST.Route,
+		oauthREST.Route,
+		configREST.Route,
+	}
+
+	if cfg.OIDC.Enabled() {
+		client := &http.Client{Transport: tracer.Transport(http.DefaultTransport)}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		provider, err := oidc.Discover(ctx, client, cfg.OIDC.Issuer)
+		cancel()
+
+		if err != nil {
+			logger.Errorf("Failed discovering identity provider: %+v", err)
+			return
+		}
+
+		relyingParty := oidc.NewRelyingParty(provider, client,
+			cfg.OIDC.ClientID,
+			cfg.OIDC.ClientSecret,
+			cfg.OIDC.RedirectURL,
+			cfg.OIDC.Scopes...,
+		)
+
+		identityLogic := usecases.NewIdentity(readerLogic, provider.Issuer, relyingParty, identityRepo)
+		identityREST := rest.NewIdentity(identityLogic, logger, reloader)
+		routes = append(routes, identityREST.Route)
+
+		logger.Infof("External identity provider set up: %s", provider.Issuer)
+	}
+
+	router := rest.NewRouter(routes...)
 
 	logger.Infof("Routes registered successfully.")
 
+	var limiter rest.RateLimiter = throttle.NewMemory()
+	if cfg.RateLimit.Store == "redis" {
+		limiter = sess.NewLimiter(sessConn)
+	}
+
 	handler := rest.ChainMiddlewares(router,
+		rest.WithMetrics(metric),
+		rest.WithTracing(),
 		rest.WithLogRequest(logger),
 		rest.WithoutPanic(logger),
+		rest.WithRateLimit(limiter, models.DefaultRouteCosts, reloader, logger),
 	)
 
 	logger.Infof("Server middleware set up.")
 
-	srv, err := rest.NewServer(handler)
+	srv, err := rest.NewServer(handler, cfg.Server)
 	if err != nil {
 		logger.Errorf("Failed initializing server: %+v", err)
 		return
 	}
 
-	if err := srv.Run(); err != nil {
+	srv.RegisterOnDrain(health.Drain)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Infof("Server starting on the port %s in %s tls mode.", cfg.Server.Port, srv.Mode())
+
+	// Run blocks until signal is received and in-flight requests are drained,
+	// deferred functions close session repo, repo and logger afterwards.
+	if err := srv.Run(ctx); err != nil {
 		logger.Errorf("Failed running server: %+v", err)
+		return
 	}
 
-	logger.Infof("Server started on the port: %s", os.Getenv("SRV_PORT"))
+	logger.Infof("Server stopped gracefully.")
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+
+	"github.com/delveper/mylib/app/models"
+	repo "github.com/delveper/mylib/app/repository/psql"
+	"github.com/delveper/mylib/app/usecases"
+)
+
+// Similarity recomputes similarity of works saved together by readers, which backs recommendations.
+// It is meant to be run periodically, e.g. nightly by cron:
+//
+//	mylib similarity -min-shared 2 -per-book 50
+func Similarity(args []string) {
+	flags := flag.NewFlagSet("similarity", flag.ExitOnError)
+	minShared := flags.Int("min-shared", 2, "keep pairs of works saved by at least this many readers")
+	perBook := flags.Int("per-book", 50, "keep this many most similar works of every work")
+
+	_ = flags.Parse(args)
+
+	runJob("similarity", func(ctx context.Context, db *sql.DB, logger models.Logger) error {
+		similarity := usecases.NewSimilarity(repo.NewBook(db))
+
+		n, err := similarity.Refresh(ctx, models.SimilarityOptions{MinShared: *minShared, PerBook: *perBook})
+		if err != nil {
+			return err
+		}
+
+		logger.Infow("Similarity of works refreshed.", "pairs", n)
+
+		return nil
+	})
+}
@@ -0,0 +1,24 @@
+package banderlog
+
+import (
+	"context"
+
+	"github.com/delveper/mylib/app/models"
+)
+
+type contextKey struct{}
+
+// ToContext stores request-scoped logger in ctx.
+func ToContext(ctx context.Context, logger models.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext retrieves logger stored by ToContext,
+// fallback is returned if there is none.
+func FromContext(ctx context.Context, fallback models.Logger) models.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(models.Logger); ok {
+		return logger
+	}
+
+	return fallback
+}
@@ -3,9 +3,14 @@
 package banderlog
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/delveper/mylib/app/models"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -17,10 +22,63 @@ const (
 	ErrorLevel = "ERROR"
 )
 
-type Log struct{ core *zap.SugaredLogger }
+// Console output modes.
+const (
+	ConsoleText = "text"
+	ConsoleJSON = "json"
+	ConsoleOff  = "off"
+)
+
+const samplingTick = time.Second
+
+type Log struct {
+	core *zap.SugaredLogger
+	lvl  zap.AtomicLevel
+}
+
+// Config holds logger settings. Tags are understood by env.Parse.
+type Config struct {
+	Level              string        `env:"LOG_LEVEL" default:"DEBUG"`
+	Console            string        `env:"LOG_CONSOLE" default:"text"`
+	File               string        `env:"LOG_FILE"`
+	MaxSizeMB          int           `env:"LOG_MAX_SIZE_MB" default:"100"`
+	RotateEvery        time.Duration `env:"LOG_ROTATE_EVERY" default:"24h"`
+	MaxBackups         int           `env:"LOG_MAX_BACKUPS" default:"7"`
+	Retention          time.Duration `env:"LOG_RETENTION" default:"720h"`
+	Compress           bool          `env:"LOG_COMPRESS" default:"true"`
+	SamplingInitial    int           `env:"LOG_SAMPLING_INITIAL" default:"0"`
+	SamplingThereafter int           `env:"LOG_SAMPLING_THEREAFTER" default:"100"`
+	RedactKeys         []string      `env:"LOG_REDACT_KEYS"`
+}
+
+// Validate checks values New would panic on.
+func (cfg Config) Validate() error {
+	if _, err := zap.ParseAtomicLevel(cfg.Level); err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+
+	switch cfg.Console {
+	case ConsoleText, ConsoleJSON, ConsoleOff:
+	default:
+		return fmt.Errorf("unknown console mode: %q", cfg.Console)
+	}
+
+	return nil
+}
+
+// New creates logger writing to console and to rotated JSON file.
+// Debug lines are sampled if SamplingInitial is set:
+// first N lines with the same message per second are logged
+// and every SamplingThereafter line after that.
+// Log file is reopened on SIGHUP.
+// Sensitive keys and JWT-looking values are masked in every sink.
+func New(cfg Config) *Log {
+	return newLog(cfg, zapcore.Lock(os.Stderr))
+}
 
-func New() *Log {
-	lvl := os.Getenv("LOG_LEVEL")
+// newLog creates logger writing console output to given sink.
+func newLog(cfg Config, console zapcore.WriteSyncer) *Log {
+	lvl := cfg.Level
 	if lvl == "" {
 		lvl = DebugLevel
 	}
@@ -51,25 +109,114 @@ func New() *Log {
 	encFileCfg := encConsoleCfg
 	encFileCfg.EncodeLevel = zapcore.CapitalLevelEncoder
 
-	file, err := os.Create(os.Getenv("LOG_FILE"))
-	if err != nil {
-		log.Fatalf("failed creating logger file : %v", err)
+	var cores []zapcore.Core
+
+	switch mode := cfg.Console; mode {
+	case ConsoleText, "":
+		cores = append(cores, zapcore.NewCore(zapcore.NewConsoleEncoder(encConsoleCfg), console, atomLvl))
+	case ConsoleJSON:
+		cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(encFileCfg), console, atomLvl))
+	case ConsoleOff:
+	default:
+		log.Panicf("unknown console mode: %q", mode)
+	}
+
+	if cfg.File != "" {
+		file := &Rotator{
+			Path:       cfg.File,
+			MaxSize:    int64(cfg.MaxSizeMB) << 20,
+			Every:      cfg.RotateEvery,
+			MaxBackups: cfg.MaxBackups,
+			Retention:  cfg.Retention,
+			Compress:   cfg.Compress,
+		}
+
+		if err := file.Reopen(); err != nil {
+			log.Fatalf("failed creating logger file : %v", err)
+		}
+
+		go reopenOnHangup(file)
+
+		cores = append(cores, zapcore.NewCore(zapcore.NewJSONEncoder(encFileCfg), zapcore.Lock(file), atomLvl))
+	}
+
+	// Configured keys extend default ones, so that setting them never unmasks passwords and tokens.
+	keys := append(DefaultRedactKeys[:len(DefaultRedactKeys):len(DefaultRedactKeys)], cfg.RedactKeys...)
+
+	// Redactor wraps tee, so that both console and file sinks are covered.
+	core := NewRedactor(keys...).Core(zapcore.NewTee(cores...))
+
+	if cfg.SamplingInitial > 0 {
+		core = sampleDebug(core, cfg.SamplingInitial, cfg.SamplingThereafter)
 	}
 
-	cores := []zapcore.Core{
-		zapcore.NewCore(zapcore.NewConsoleEncoder(encConsoleCfg), zapcore.Lock(os.Stderr), atomLvl.Level()),
-		zapcore.NewCore(zapcore.NewJSONEncoder(encFileCfg), zapcore.Lock(file), atomLvl.Level()),
+	return &Log{core: zap.New(core).Sugar(), lvl: atomLvl}
+}
+
+// sampleDebug samples only debug lines leaving other levels intact.
+func sampleDebug(core zapcore.Core, initial, thereafter int) zapcore.Core {
+	debug := zapcore.NewSamplerWithOptions(levelCore{core, func(l zapcore.Level) bool { return l == zapcore.DebugLevel }},
+		samplingTick, initial, thereafter)
+	rest := levelCore{core, func(l zapcore.Level) bool { return l > zapcore.DebugLevel }}
+
+	return zapcore.NewTee(debug, rest)
+}
+
+// levelCore narrows levels enabled by underlying core.
+type levelCore struct {
+	zapcore.Core
+	enabled func(zapcore.Level) bool
+}
+
+func (c levelCore) Enabled(l zapcore.Level) bool {
+	return c.enabled(l) && c.Core.Enabled(l)
+}
+
+func (c levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return levelCore{c.Core.With(fields), c.enabled}
+}
+
+func (c levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
 	}
 
-	core := zapcore.NewTee(cores...)
+	return checked
+}
+
+// reopenOnHangup reopens log file on SIGHUP sent by external logrotate.
+func reopenOnHangup(file *Rotator) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
 
-	return &Log{zap.New(core).Sugar()}
+	reopenOn(sig, file)
+}
+
+// reopenOn reopens log file on every signal received until channel is closed.
+func reopenOn(sig <-chan os.Signal, file *Rotator) {
+	for range sig {
+		if err := file.Reopen(); err != nil {
+			log.Printf("failed reopening logger file: %v", err)
+		}
+	}
 }
 
 func (log *Log) Level() string {
 	return log.core.Level().String()
 }
 
+// SetLevel changes level of logger and all its children at runtime.
+func (log *Log) SetLevel(lvl string) error {
+	level, err := zapcore.ParseLevel(lvl)
+	if err != nil {
+		return fmt.Errorf("failed to parse logger level: %w", err)
+	}
+
+	log.lvl.SetLevel(level)
+
+	return nil
+}
+
 func (log *Log) Flush() error {
 	return log.core.Sync()
 }
@@ -126,6 +273,7 @@ func (log *Log) Printf(format string, keyVal ...any) {
 	log.core.Infof(format, keyVal...)
 }
 
-func (log *Log) With(keyVal ...any) {
-	log.core.With(keyVal...)
+// With returns child logger adding given key/val pairs to every line.
+func (log *Log) With(keyVal ...any) models.Logger {
+	return &Log{core: log.core.With(keyVal...), lvl: log.lvl}
 }
@@ -0,0 +1,179 @@
+package banderlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "[REDACTED]"
+
+// DefaultRedactKeys are always masked, LOG_REDACT_KEYS adds to them.
+var DefaultRedactKeys = []string{
+	"password",
+	"access_token",
+	"refresh_token",
+	"authorization",
+	"email",
+	"client_secret",
+	"code",
+	"code_verifier",
+	"id_token",
+}
+
+var jwtRegexp = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// Redactor masks values of sensitive keys and JWT-looking values
+// in messages, fields and nested objects before they reach any sink.
+type Redactor struct {
+	keys  map[string]struct{}
+	query *regexp.Regexp
+}
+
+func NewRedactor(keys ...string) *Redactor {
+	r := Redactor{keys: make(map[string]struct{}, len(keys))}
+
+	quoted := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+
+		r.keys[key] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+
+	if len(quoted) > 0 {
+		// matches sensitive query parameters in logged URIs.
+		r.query = regexp.MustCompile(`(?i)([?&](?:` + strings.Join(quoted, "|") + `)=)[^&\s"]*`)
+	}
+
+	return &r
+}
+
+// Core wraps core, so that every entry passes through redactor.
+func (r *Redactor) Core(core zapcore.Core) zapcore.Core {
+	return redactCore{Core: core, r: r}
+}
+
+func (r *Redactor) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "-", "_")
+
+	_, ok := r.keys[key]
+
+	return ok
+}
+
+func (r *Redactor) String(s string) string {
+	s = jwtRegexp.ReplaceAllString(s, redacted)
+
+	if r.query != nil {
+		s = r.query.ReplaceAllString(s, "${1}"+redacted)
+	}
+
+	return s
+}
+
+func (r *Redactor) Fields(fields []zapcore.Field) []zapcore.Field {
+	res := make([]zapcore.Field, len(fields))
+
+	for i, f := range fields {
+		res[i] = r.field(f)
+	}
+
+	return res
+}
+
+func (r *Redactor) field(f zapcore.Field) zapcore.Field {
+	if r.sensitive(f.Key) {
+		return zap.String(f.Key, redacted)
+	}
+
+	switch f.Type {
+	case zapcore.StringType:
+		return zap.String(f.Key, r.String(f.String))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return zap.String(f.Key, r.String(err.Error()))
+		}
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok {
+			return zap.String(f.Key, r.String(s.String()))
+		}
+	case zapcore.ReflectType, zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+		return zap.Any(f.Key, r.object(f.Interface))
+	}
+
+	return f
+}
+
+// object walks JSON representation of value masking sensitive keys on any depth.
+func (r *Redactor) object(val any) any {
+	if val == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return r.String(fmt.Sprintf("%+v", val))
+	}
+
+	var tree any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return r.String(string(data))
+	}
+
+	return r.walk(tree)
+}
+
+func (r *Redactor) walk(node any) any {
+	switch n := node.(type) {
+	case map[string]any:
+		for key, val := range n {
+			if r.sensitive(key) {
+				n[key] = redacted
+				continue
+			}
+
+			n[key] = r.walk(val)
+		}
+	case []any:
+		for i, val := range n {
+			n[i] = r.walk(val)
+		}
+	case string:
+		return r.String(n)
+	}
+
+	return node
+}
+
+type redactCore struct {
+	zapcore.Core
+	r *Redactor
+}
+
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{Core: c.Core.With(c.r.Fields(fields)), r: c.r}
+}
+
+func (c redactCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+
+	return checked
+}
+
+func (c redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.r.String(entry.Message)
+
+	return c.Core.Write(entry, c.r.Fields(fields))
+}
@@ -0,0 +1,293 @@
+package banderlog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+)
+
+// Rotator is zapcore.WriteSyncer appending to file and rotating it
+// when it grows over max size or gets older than rotation interval.
+// Rotated backups are optionally gzipped and removed
+// when there are more than max backups or they are older than retention.
+type Rotator struct {
+	Path       string
+	MaxSize    int64         // bytes, 0 disables size rotation
+	Every      time.Duration // 0 disables time rotation
+	MaxBackups int           // 0 keeps all backups
+	Retention  time.Duration // 0 keeps backups forever
+	Compress   bool
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+
+	millMu sync.Mutex
+	mills  sync.WaitGroup
+}
+
+func (r *Rotator) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.due(len(p)) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+func (r *Rotator) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	return r.file.Sync()
+}
+
+// Reopen closes and opens file by the same path again,
+// so that external tools like logrotate can move file away.
+// Age for time rotation is still counted from the last rotation.
+func (r *Rotator) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.close(); err != nil {
+		return err
+	}
+
+	return r.open()
+}
+
+// Close closes file and waits for backups being compressed and removed.
+func (r *Rotator) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	defer r.mills.Wait()
+
+	return r.close()
+}
+
+func (r *Rotator) due(n int) bool {
+	if r.MaxSize > 0 && r.size+int64(n) > r.MaxSize && r.size > 0 {
+		return true
+	}
+
+	return r.Every > 0 && time.Since(r.opened) >= r.Every
+}
+
+func (r *Rotator) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0o755); err != nil {
+		return fmt.Errorf("error creating log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(r.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("error getting log file info: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+
+	if r.opened.IsZero() {
+		r.opened = time.Now()
+	}
+
+	return nil
+}
+
+func (r *Rotator) close() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+
+	if err != nil {
+		return fmt.Errorf("error closing log file: %w", err)
+	}
+
+	return nil
+}
+
+// rotate renames current file to timestamped backup and opens new one.
+// Compression and removal of old backups happen in background.
+func (r *Rotator) rotate() error {
+	if err := r.close(); err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(r.Path)
+	backup := strings.TrimSuffix(r.Path, ext) + "-" + time.Now().Format(backupTimeFormat) + ext
+
+	if err := os.Rename(r.Path, backup); err != nil {
+		return fmt.Errorf("error renaming log file: %w", err)
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.opened = time.Now()
+
+	r.mills.Add(1)
+
+	go func() {
+		defer r.mills.Done()
+		r.mill(backup)
+	}()
+
+	return nil
+}
+
+// mill compresses fresh backup and removes ones exceeding limits.
+// Errors are reported to stderr since logger itself is writing here.
+func (r *Rotator) mill(backup string) {
+	r.millMu.Lock()
+	defer r.millMu.Unlock()
+
+	if r.Compress {
+		if err := compress(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "banderlog: %v\n", err)
+		}
+	}
+
+	if err := r.prune(); err != nil {
+		fmt.Fprintf(os.Stderr, "banderlog: %v\n", err)
+	}
+}
+
+type backupFile struct {
+	path string
+	made time.Time
+}
+
+func (r *Rotator) prune() error {
+	backups, err := r.backups()
+	if err != nil {
+		return err
+	}
+
+	for i, b := range backups {
+		expired := r.Retention > 0 && time.Since(b.made) > r.Retention
+		excess := r.MaxBackups > 0 && i >= r.MaxBackups
+
+		if !expired && !excess {
+			continue
+		}
+
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error removing log backup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// backups lists rotated files newest first.
+func (r *Rotator) backups() ([]backupFile, error) {
+	ext := filepath.Ext(r.Path)
+	prefix := filepath.Base(strings.TrimSuffix(r.Path, ext)) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(r.Path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading log directory: %w", err)
+	}
+
+	var backups []backupFile
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, compressSuffix), ext)
+		stamp = strings.TrimPrefix(stamp, prefix)
+
+		made, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backupFile{
+			path: filepath.Join(filepath.Dir(r.Path), name),
+			made: made,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].made.After(backups[j].made)
+	})
+
+	return backups, nil
+}
+
+func compress(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening log backup: %w", err)
+	}
+
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("error creating compressed log backup: %w", err)
+	}
+
+	defer func() {
+		if e := dst.Close(); e != nil && err == nil {
+			err = fmt.Errorf("error closing compressed log backup: %w", e)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+
+	if _, err := io.Copy(gz, src); err != nil {
+		return fmt.Errorf("error compressing log backup: %w", err)
+	}
+
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("error compressing log backup: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("error removing uncompressed log backup: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package banderlog
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRotator(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxSize int64
+		every   time.Duration
+		age     time.Duration // of file before last write
+		writes  []string
+		file    string
+		backup  string
+	}{
+		{
+			name:    "under max size",
+			maxSize: 12,
+			writes:  []string{"first\n", "second"},
+			file:    "first\nsecond",
+		},
+		{
+			name:    "over max size",
+			maxSize: 10,
+			writes:  []string{"first\n", "second\n"},
+			file:    "second\n",
+			backup:  "first\n",
+		},
+		{
+			name:    "line longer than max size",
+			maxSize: 4,
+			writes:  []string{"first\n"},
+			file:    "first\n",
+		},
+		{
+			name:   "younger than interval",
+			every:  time.Hour,
+			age:    time.Minute,
+			writes: []string{"first\n", "second\n"},
+			file:   "first\nsecond\n",
+		},
+		{
+			name:   "older than interval",
+			every:  time.Hour,
+			age:    time.Hour,
+			writes: []string{"first\n", "second\n"},
+			file:   "second\n",
+			backup: "first\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Rotator{Path: filepath.Join(t.TempDir(), "app.log"), MaxSize: tt.maxSize, Every: tt.every}
+
+			defer r.Close()
+
+			for i, line := range tt.writes {
+				if i == len(tt.writes)-1 && tt.age > 0 {
+					r.opened = time.Now().Add(-tt.age)
+				}
+
+				write(t, r, line)
+			}
+
+			if got := readFile(t, r.Path); got != tt.file {
+				t.Errorf("file contains %q, want %q", got, tt.file)
+			}
+
+			backups := readBackups(t, r)
+			if tt.backup == "" && len(backups) != 0 || tt.backup != "" && (len(backups) != 1 || backups[0] != tt.backup) {
+				t.Errorf("backups contain %q, want %q", backups, tt.backup)
+			}
+		})
+	}
+}
+
+func TestRotatorReopen(t *testing.T) {
+	r := &Rotator{Path: filepath.Join(t.TempDir(), "app.log"), Every: time.Hour}
+
+	defer r.Close()
+
+	write(t, r, "before\n")
+
+	// as logrotate does before sending SIGHUP.
+	moved := r.Path + ".1"
+	if err := os.Rename(r.Path, moved); err != nil {
+		t.Fatal(err)
+	}
+
+	opened := time.Now().Add(-time.Minute)
+	r.opened = opened
+
+	sig := make(chan os.Signal)
+	done := make(chan struct{})
+
+	go func() {
+		reopenOn(sig, r)
+		close(done)
+	}()
+
+	sig <- syscall.SIGHUP
+	close(sig)
+	<-done
+
+	write(t, r, "after\n")
+
+	if got := readFile(t, moved); got != "before\n" {
+		t.Errorf("moved file contains %q, want %q", got, "before\n")
+	}
+
+	if got := readFile(t, r.Path); got != "after\n" {
+		t.Errorf("reopened file contains %q, want %q", got, "after\n")
+	}
+
+	if !r.opened.Equal(opened) {
+		t.Errorf("reopening moved time of last rotation from %v to %v", opened, r.opened)
+	}
+}
+
+func write(t *testing.T, r *Rotator, line string) {
+	t.Helper()
+
+	if _, err := r.Write([]byte(line)); err != nil {
+		t.Fatalf("writing log: %v", err)
+	}
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("reading log: %v", err)
+	}
+
+	return string(data)
+}
+
+func readBackups(t *testing.T, r *Rotator) []string {
+	t.Helper()
+
+	backups, err := r.backups()
+	if err != nil {
+		t.Fatalf("listing backups: %v", err)
+	}
+
+	var data []string
+	for _, b := range backups {
+		data = append(data, readFile(t, b.path))
+	}
+
+	return data
+}
@@ -0,0 +1,92 @@
+package cite
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var bibtexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// bibtexWriter writes @book entries, keys repeated within output get letter suffix as in tolkien1937a.
+type bibtexWriter struct {
+	w    io.Writer
+	keys map[string]int
+}
+
+func (bw *bibtexWriter) Write(e Entry) error {
+	key := e.key()
+
+	if n := bw.keys[key]; n > 0 {
+		bw.keys[key]++
+		key += suffix(n)
+	} else {
+		bw.keys[key] = 1
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "@book{%s,\n", key)
+
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "  %s = {%s},\n", name, bibtexEscaper.Replace(value))
+		}
+	}
+
+	names := make([]string, len(e.Authors))
+	for i, n := range e.Authors {
+		names[i] = n.Inverted()
+	}
+
+	field("author", strings.Join(names, " and "))
+	field("title", e.Title)
+	field("publisher", e.Publisher)
+	field("address", e.Place)
+
+	if e.Year != 0 {
+		field("year", strconv.Itoa(e.Year))
+	}
+
+	field("isbn", e.ISBN)
+
+	if e.Pages != 0 {
+		field("pagetotal", strconv.Itoa(e.Pages))
+	}
+
+	field("keywords", strings.Join(e.Subjects, ", "))
+	b.WriteString("}\n\n")
+
+	if _, err := io.WriteString(bw.w, b.String()); err != nil {
+		return fmt.Errorf("error writing entry: %w", err)
+	}
+
+	return nil
+}
+
+func (bw *bibtexWriter) Close() error {
+	return nil
+}
+
+// suffix returns letters for n-th repetition of key: a, b, ..., z, aa, ab and so on.
+func suffix(n int) string {
+	var s []byte
+
+	for ; n > 0; n = (n - 1) / 26 {
+		s = append([]byte{byte('a' + (n-1)%26)}, s...)
+	}
+
+	return string(s)
+}
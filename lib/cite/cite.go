@@ -0,0 +1,106 @@
+// Package cite renders bibliographic entries in citation formats
+// understood by reference managers and metadata harvesters.
+package cite
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Supported formats.
+const (
+	FormatBibTeX = "bibtex"
+	FormatRIS    = "ris"
+	FormatCSL    = "csl"
+	FormatDC     = "dc"
+)
+
+// ContentTypes maps formats to media types.
+var ContentTypes = map[string]string{
+	FormatBibTeX: "application/x-bibtex",
+	FormatRIS:    "application/x-research-info-systems",
+	FormatCSL:    "application/vnd.citationstyles.csl+json",
+	FormatDC:     "application/dc+xml",
+}
+
+// Extensions maps formats to file extensions.
+var Extensions = map[string]string{
+	FormatBibTeX: "bib",
+	FormatRIS:    "ris",
+	FormatCSL:    "json",
+	FormatDC:     "xml",
+}
+
+// Entry is book as it is cited, empty fields are omitted from output.
+type Entry struct {
+	ID        string
+	Title     string
+	Authors   []Name
+	Year      int
+	ISBN      string
+	Publisher string
+	Place     string
+	Pages     int
+	Subjects  []string
+}
+
+type Name struct {
+	Given  string
+	Family string
+}
+
+// Writer writes entries, Close has to be called to complete output.
+type Writer interface {
+	Write(Entry) error
+	Close() error
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatBibTeX:
+		return &bibtexWriter{w: w, keys: make(map[string]int)}, nil
+	case FormatRIS:
+		return &risWriter{w: w}, nil
+	case FormatCSL:
+		return newCSLWriter(w), nil
+	case FormatDC:
+		return newDCWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported format: %q", format)
+	}
+}
+
+// Inverted returns name in "Family, Given" order.
+func (n Name) Inverted() string {
+	if n.Given == "" {
+		return n.Family
+	}
+
+	return n.Family + ", " + n.Given
+}
+
+// key returns citation key made of family name of first author and year, e.g. tolkien1937.
+func (e Entry) key() string {
+	var b strings.Builder
+
+	if len(e.Authors) > 0 {
+		for _, r := range strings.ToLower(e.Authors[0].Family) {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				b.WriteRune(r)
+			}
+		}
+	}
+
+	if b.Len() == 0 {
+		b.WriteString("book")
+	}
+
+	if e.Year != 0 {
+		b.WriteString(strconv.Itoa(e.Year))
+	}
+
+	return b.String()
+}
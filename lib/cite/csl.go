@@ -0,0 +1,97 @@
+package cite
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type cslItem struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title,omitempty"`
+	Author         []cslName `json:"author,omitempty"`
+	Issued         *cslDate  `json:"issued,omitempty"`
+	Publisher      string    `json:"publisher,omitempty"`
+	PublisherPlace string    `json:"publisher-place,omitempty"`
+	ISBN           string    `json:"ISBN,omitempty"`
+	NumberOfPages  int       `json:"number-of-pages,omitempty"`
+	Keyword        string    `json:"keyword,omitempty"`
+}
+
+type cslName struct {
+	Family string `json:"family"`
+	Given  string `json:"given,omitempty"`
+}
+
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// cslWriter writes items of CSL-JSON array.
+type cslWriter struct {
+	w     *bufio.Writer
+	enc   *json.Encoder
+	count int
+}
+
+func newCSLWriter(w io.Writer) *cslWriter {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+
+	return &cslWriter{w: bw, enc: enc}
+}
+
+func (cw *cslWriter) Write(e Entry) error {
+	item := cslItem{
+		ID:             e.ID,
+		Type:           "book",
+		Title:          e.Title,
+		Publisher:      e.Publisher,
+		PublisherPlace: e.Place,
+		ISBN:           e.ISBN,
+		NumberOfPages:  e.Pages,
+		Keyword:        strings.Join(e.Subjects, ", "),
+	}
+
+	for _, n := range e.Authors {
+		item.Author = append(item.Author, cslName{Family: n.Family, Given: n.Given})
+	}
+
+	if e.Year != 0 {
+		item.Issued = &cslDate{DateParts: [][]int{{e.Year}}}
+	}
+
+	sep := ","
+	if cw.count == 0 {
+		sep = "["
+	}
+
+	cw.count++
+
+	if _, err := cw.w.WriteString(sep); err != nil {
+		return fmt.Errorf("error writing item: %w", err)
+	}
+
+	if err := cw.enc.Encode(item); err != nil {
+		return fmt.Errorf("error encoding item: %w", err)
+	}
+
+	return nil
+}
+
+func (cw *cslWriter) Close() error {
+	end := "]\n"
+	if cw.count == 0 {
+		end = "[]\n"
+	}
+
+	if _, err := cw.w.WriteString(end); err != nil {
+		return fmt.Errorf("error writing items: %w", err)
+	}
+
+	return cw.w.Flush()
+}
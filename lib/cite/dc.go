@@ -0,0 +1,93 @@
+package cite
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Namespaces of simple Dublin Core as OAI-PMH defines it.
+const (
+	dcNamespace    = "http://purl.org/dc/elements/1.1/"
+	oaiDCNamespace = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+)
+
+type dcRecord struct {
+	XMLName    xml.Name `xml:"oai_dc:dc"`
+	Identifier []string `xml:"dc:identifier"`
+	Title      string   `xml:"dc:title,omitempty"`
+	Creator    []string `xml:"dc:creator"`
+	Subject    []string `xml:"dc:subject"`
+	Publisher  string   `xml:"dc:publisher,omitempty"`
+	Date       string   `xml:"dc:date,omitempty"`
+	Type       string   `xml:"dc:type"`
+	Format     string   `xml:"dc:format,omitempty"`
+}
+
+// dcWriter writes oai_dc records within single root element,
+// namespaces are declared on root, so that records stay compact.
+type dcWriter struct {
+	w   *bufio.Writer
+	enc *xml.Encoder
+}
+
+func newDCWriter(w io.Writer) (*dcWriter, error) {
+	bw := bufio.NewWriter(w)
+
+	root := xml.Header + `<records xmlns:oai_dc="` + oaiDCNamespace + `" xmlns:dc="` + dcNamespace + `">`
+
+	if _, err := bw.WriteString(root); err != nil {
+		return nil, fmt.Errorf("error writing records: %w", err)
+	}
+
+	return &dcWriter{w: bw, enc: xml.NewEncoder(bw)}, nil
+}
+
+func (dw *dcWriter) Write(e Entry) error {
+	rec := dcRecord{
+		Title:     e.Title,
+		Subject:   e.Subjects,
+		Publisher: e.Publisher,
+		Type:      "Text",
+	}
+
+	if e.ID != "" {
+		rec.Identifier = append(rec.Identifier, "urn:uuid:"+e.ID)
+	}
+
+	if e.ISBN != "" {
+		rec.Identifier = append(rec.Identifier, "urn:isbn:"+e.ISBN)
+	}
+
+	for _, n := range e.Authors {
+		rec.Creator = append(rec.Creator, n.Inverted())
+	}
+
+	if e.Year != 0 {
+		rec.Date = strconv.Itoa(e.Year)
+	}
+
+	if e.Pages != 0 {
+		rec.Format = strconv.Itoa(e.Pages) + " pages"
+	}
+
+	if err := dw.enc.Encode(rec); err != nil {
+		return fmt.Errorf("error encoding record: %w", err)
+	}
+
+	return nil
+}
+
+func (dw *dcWriter) Close() error {
+	if err := dw.enc.Flush(); err != nil {
+		return fmt.Errorf("error flushing records: %w", err)
+	}
+
+	if _, err := dw.w.WriteString(`</records>`); err != nil {
+		return fmt.Errorf("error writing records: %w", err)
+	}
+
+	return dw.w.Flush()
+}
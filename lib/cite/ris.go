@@ -0,0 +1,62 @@
+package cite
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// risWriter writes BOOK references, one tag per line as RIS requires.
+type risWriter struct {
+	w io.Writer
+}
+
+func (rw *risWriter) Write(e Entry) error {
+	var b strings.Builder
+
+	tag := func(name, value string) {
+		// Line breaks would start new tag.
+		value = strings.Join(strings.Fields(value), " ")
+		if value != "" {
+			fmt.Fprintf(&b, "%s  - %s\r\n", name, value)
+		}
+	}
+
+	tag("TY", "BOOK")
+
+	for _, n := range e.Authors {
+		tag("AU", n.Inverted())
+	}
+
+	tag("TI", e.Title)
+
+	if e.Year != 0 {
+		tag("PY", strconv.Itoa(e.Year))
+	}
+
+	tag("PB", e.Publisher)
+	tag("CY", e.Place)
+	tag("SN", e.ISBN)
+
+	if e.Pages != 0 {
+		tag("SP", strconv.Itoa(e.Pages))
+	}
+
+	for _, subject := range e.Subjects {
+		tag("KW", subject)
+	}
+
+	tag("ID", e.ID)
+	b.WriteString("ER  - \r\n\r\n")
+
+	if _, err := io.WriteString(rw.w, b.String()); err != nil {
+		return fmt.Errorf("error writing entry: %w", err)
+	}
+
+	return nil
+}
+
+func (rw *risWriter) Close() error {
+	return nil
+}
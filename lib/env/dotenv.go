@@ -0,0 +1,313 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseError reports malformed line of dotenv file.
+type ParseError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// Read parses dotenv formatted input.
+// Supported syntax:
+//
+//	# comment
+//	export KEY=value           # inline comment after whitespace
+//	KEY=                       # empty value
+//	KEY='literal $NOT expanded'
+//	KEY="escapes \n \t \" \\ \$ and ${VAR} or ${VAR:-default} expanded"
+//	KEY="multi
+//	line"
+//
+// Variables are expanded from lookup first and from keys defined above second.
+func Read(name string, r io.Reader, lookup func(string) (string, bool)) (map[string]string, error) {
+	p := parser{name: name, vars: make(map[string]string), lookup: lookup}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	for scanner.Scan() {
+		p.lines = append(p.lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", name, err)
+	}
+
+	for p.pos < len(p.lines) {
+		if err := p.parseLine(); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.vars, nil
+}
+
+type parser struct {
+	name   string
+	lines  []string
+	pos    int
+	vars   map[string]string
+	lookup func(string) (string, bool)
+}
+
+func (p *parser) fail(line int, format string, args ...any) error {
+	return &ParseError{File: p.name, Line: line + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseLine() error {
+	start := p.pos
+	line := strings.TrimSpace(p.lines[p.pos])
+	p.pos++
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	if strings.HasPrefix(line, "export ") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+	}
+
+	key, val, ok := strings.Cut(line, "=")
+	if !ok {
+		return p.fail(start, "expected KEY=value, got %q", line)
+	}
+
+	key = strings.TrimSpace(key)
+	if !validKey(key) {
+		return p.fail(start, "invalid key %q", key)
+	}
+
+	val = strings.TrimLeft(val, " \t")
+
+	var err error
+
+	switch {
+	case strings.HasPrefix(val, "'"):
+		val, err = p.quoted(start, val, '\'')
+	case strings.HasPrefix(val, `"`):
+		val, err = p.quoted(start, val, '"')
+		if err == nil {
+			val, err = p.unescape(start, val)
+		}
+	default:
+		val = stripComment(val)
+		val, err = p.expand(start, strings.TrimSpace(val))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	p.vars[key] = val
+
+	return nil
+}
+
+// quoted returns raw content between quotes reading following lines if quote is not closed.
+// Only whitespace and comment are allowed after closing quote.
+func (p *parser) quoted(start int, val string, quote byte) (string, error) {
+	var buf strings.Builder
+
+	rest := val[1:]
+
+	for {
+		if end := closingQuote(rest, quote); end >= 0 {
+			buf.WriteString(rest[:end])
+
+			tail := strings.TrimSpace(rest[end+1:])
+			if tail != "" && !strings.HasPrefix(tail, "#") {
+				return "", p.fail(p.pos-1, "unexpected %q after closing quote", tail)
+			}
+
+			return buf.String(), nil
+		}
+
+		if p.pos >= len(p.lines) {
+			return "", p.fail(start, "unterminated quoted value")
+		}
+
+		buf.WriteString(rest)
+		buf.WriteByte('\n')
+
+		rest = p.lines[p.pos]
+		p.pos++
+	}
+}
+
+// closingQuote finds index of unescaped quote, backslash escapes only double quotes.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (p *parser) unescape(line int, val string) (string, error) {
+	var buf strings.Builder
+
+	for i := 0; i < len(val); i++ {
+		c := val[i]
+
+		if c == '$' {
+			expanded, n, err := p.variable(line, val[i:])
+			if err != nil {
+				return "", err
+			}
+
+			buf.WriteString(expanded)
+			i += n - 1
+
+			continue
+		}
+
+		if c != '\\' || i == len(val)-1 {
+			buf.WriteByte(c)
+			continue
+		}
+
+		i++
+
+		switch val[i] {
+		case 'n':
+			buf.WriteByte('\n')
+		case 'r':
+			buf.WriteByte('\r')
+		case 't':
+			buf.WriteByte('\t')
+		case '"', '\\', '$', '\'':
+			buf.WriteByte(val[i])
+		default:
+			buf.WriteByte('\\')
+			buf.WriteByte(val[i])
+		}
+	}
+
+	return buf.String(), nil
+}
+
+func (p *parser) expand(line int, val string) (string, error) {
+	var buf strings.Builder
+
+	for i := 0; i < len(val); i++ {
+		if val[i] != '$' {
+			buf.WriteByte(val[i])
+			continue
+		}
+
+		expanded, n, err := p.variable(line, val[i:])
+		if err != nil {
+			return "", err
+		}
+
+		buf.WriteString(expanded)
+		i += n - 1
+	}
+
+	return buf.String(), nil
+}
+
+// variable expands $VAR, ${VAR} or ${VAR:-default} at the start of s
+// and returns expansion and number of bytes consumed.
+func (p *parser) variable(line int, s string) (string, int, error) {
+	if len(s) < 2 {
+		return s, len(s), nil
+	}
+
+	if s[1] == '{' {
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return "", 0, p.fail(line, "unterminated variable reference %q", s)
+		}
+
+		name, def, hasDef := strings.Cut(s[2:end], ":-")
+		if !validKey(name) || strings.Contains(name, ".") {
+			return "", 0, p.fail(line, "invalid variable name %q", name)
+		}
+
+		if val, ok := p.resolve(name); ok && (val != "" || !hasDef) {
+			return val, end + 1, nil
+		}
+
+		return def, end + 1, nil
+	}
+
+	n := 1
+	for n < len(s) && s[n] != '.' && isKeyChar(s[n], n == 1) {
+		n++
+	}
+
+	if n == 1 {
+		return "$", 1, nil
+	}
+
+	val, _ := p.resolve(s[1:n])
+
+	return val, n, nil
+}
+
+// resolve prefers real environment over keys defined in file.
+func (p *parser) resolve(name string) (string, bool) {
+	if p.lookup != nil {
+		if val, ok := p.lookup(name); ok {
+			return val, true
+		}
+	}
+
+	val, ok := p.vars[name]
+
+	return val, ok
+}
+
+// stripComment removes inline comment which must be preceded by whitespace,
+// so that values like URL fragments are kept intact.
+func stripComment(val string) string {
+	for i := 1; i < len(val); i++ {
+		if val[i] == '#' && (val[i-1] == ' ' || val[i-1] == '\t') {
+			return val[:i]
+		}
+	}
+
+	return val
+}
+
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if !isKeyChar(key[i], i == 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isKeyChar(c byte, first bool) bool {
+	switch {
+	case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		return true
+	case c >= '0' && c <= '9', c == '.':
+		return !first
+	default:
+		return false
+	}
+}
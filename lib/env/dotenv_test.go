@@ -0,0 +1,114 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	lookup := func(key string) (string, bool) {
+		env := map[string]string{"HOME": "/home/reader", "EMPTY": ""}
+		val, ok := env[key]
+
+		return val, ok
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want map[string]string
+	}{
+		{
+			name: "plain",
+			in:   "# comment\n\nKEY=value\nexport EXPORTED=yes\nSPACED = padded \nEMPTY_VALUE=\n",
+			want: map[string]string{"KEY": "value", "EXPORTED": "yes", "SPACED": "padded", "EMPTY_VALUE": ""},
+		},
+		{
+			name: "inline comment",
+			in:   "KEY=value # comment\nURL=http://host/path#fragment\n",
+			want: map[string]string{"KEY": "value", "URL": "http://host/path#fragment"},
+		},
+		{
+			name: "single quotes are literal",
+			in:   `KEY='$HOME \n # not comment'` + "\n",
+			want: map[string]string{"KEY": `$HOME \n # not comment`},
+		},
+		{
+			name: "double quotes unescape",
+			in:   `KEY="tab\tnew\nquote\" backslash\\ dollar\$HOME"` + " # comment\n",
+			want: map[string]string{"KEY": "tab\tnew\nquote\" backslash\\ dollar$HOME"},
+		},
+		{
+			name: "multi-line",
+			in:   "KEY=\"first\nsecond\"\nSINGLE='a\nb'\nNEXT=1\n",
+			want: map[string]string{"KEY": "first\nsecond", "SINGLE": "a\nb", "NEXT": "1"},
+		},
+		{
+			name: "expansion",
+			in:   "DIR=$HOME/lib\nFILE=${DIR}/app.log\nQUOTED=\"${HOME}\"\nUNKNOWN=$NOPE.\nDOLLAR=$ 5\n",
+			want: map[string]string{
+				"DIR":     "/home/reader/lib",
+				"FILE":    "/home/reader/lib/app.log",
+				"QUOTED":  "/home/reader",
+				"UNKNOWN": ".",
+				"DOLLAR":  "$ 5",
+			},
+		},
+		{
+			name: "defaults",
+			in:   "A=${NOPE:-fallback}\nB=${EMPTY:-fallback}\nC=${HOME:-fallback}\nD=${EMPTY}\n",
+			want: map[string]string{"A": "fallback", "B": "fallback", "C": "/home/reader", "D": ""},
+		},
+		{
+			name: "environment wins over file",
+			in:   "HOME=/file\nPATHS=$HOME\n",
+			want: map[string]string{"HOME": "/file", "PATHS": "/home/reader"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(".env", strings.NewReader(tt.in), lookup)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		line int
+		msg  string
+	}{
+		{name: "missing equals", in: "KEY=1\nNOPE\n", line: 2, msg: "expected KEY=value"},
+		{name: "invalid key", in: "\n1KEY=1\n", line: 2, msg: "invalid key"},
+		{name: "unterminated quote", in: "A=1\nKEY=\"open\nstill open\n", line: 2, msg: "unterminated quoted value"},
+		{name: "text after quote", in: "A=1\nB=\"x\nclosed\" trailing\n", line: 3, msg: "after closing quote"},
+		{name: "unterminated variable", in: "KEY=${HOME\n", line: 1, msg: "unterminated variable reference"},
+		{name: "invalid variable", in: "A=1\n\nKEY=${1A}\n", line: 3, msg: "invalid variable name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(".env.test", strings.NewReader(tt.in), nil)
+
+			var pErr *ParseError
+			if !errors.As(err, &pErr) {
+				t.Fatalf("got error %v, want ParseError", err)
+			}
+
+			if pErr.File != ".env.test" || pErr.Line != tt.line || !strings.Contains(pErr.Msg, tt.msg) {
+				t.Errorf("got %v, want .env.test:%d: %s", err, tt.line, tt.msg)
+			}
+		})
+	}
+}
@@ -1,34 +1,115 @@
 package env
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
 )
 
-func LoadVars() (err error) {
-	env, err := os.Open(".env")
-	if err != nil {
-		return fmt.Errorf("error during opening environment file: %w", err)
+// owned holds variables set by LoadVars,
+// so that they can be updated when files are loaded again.
+var (
+	ownedMu sync.Mutex
+	owned   = make(map[string]struct{})
+)
+
+// Files returns dotenv files in order of increasing precedence:
+// .env, .env.local, .env.<appEnv> and .env.<appEnv>.local.
+func Files(appEnv string) []string {
+	files := []string{".env", ".env.local"}
+
+	if appEnv != "" {
+		files = append(files, ".env."+appEnv, ".env."+appEnv+".local")
 	}
 
-	defer func() {
-		if err = env.Close(); err != nil {
-			err = fmt.Errorf("error during closing environment file: %w", err)
+	return files
+}
+
+// LoadVars loads given dotenv files, or Files(APP_ENV) if none given, into environment.
+// Later files override earlier ones, but variables already set
+// in real environment are never overridden. Missing files are skipped.
+// Loading again updates variables set by previous load and unsets ones removed from files.
+func LoadVars(files ...string) error {
+	ownedMu.Lock()
+	defer ownedMu.Unlock()
+
+	if len(files) == 0 {
+		files = Files(os.Getenv("APP_ENV"))
+	}
+
+	vars := make(map[string]string)
+
+	// files may refer to variables defined in files loaded before.
+	lookup := func(key string) (string, bool) {
+		if _, ok := owned[key]; ok {
+			val, ok := vars[key]
+			return val, ok
 		}
-	}()
 
-	buf := bufio.NewScanner(env)
-	buf.Split(bufio.ScanLines)
+		if val, ok := os.LookupEnv(key); ok {
+			return val, true
+		}
+
+		val, ok := vars[key]
+
+		return val, ok
+	}
+
+	for _, name := range files {
+		data, err := readFile(name, lookup)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
 
-	for buf.Scan() {
-		if keyVal := strings.Split(buf.Text(), "="); len(keyVal) > 1 {
-			if err := os.Setenv(keyVal[0], keyVal[1]); err != nil {
-				return fmt.Errorf("error during setting environment variable: %w", err)
+		for key, val := range data {
+			vars[key] = val
+		}
+	}
+
+	for key := range owned {
+		if _, ok := vars[key]; !ok {
+			if err := os.Unsetenv(key); err != nil {
+				return fmt.Errorf("error during unsetting environment variable: %w", err)
+			}
+
+			delete(owned, key)
+		}
+	}
+
+	for key, val := range vars {
+		if _, ok := owned[key]; !ok {
+			if _, ok := os.LookupEnv(key); ok {
+				continue
 			}
 		}
+
+		if err := os.Setenv(key, val); err != nil {
+			return fmt.Errorf("error during setting environment variable: %w", err)
+		}
+
+		owned[key] = struct{}{}
 	}
 
 	return nil
 }
+
+func readFile(name string, lookup func(string) (string, bool)) (vars map[string]string, err error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("error during opening environment file: %w", err)
+	}
+
+	defer func() {
+		if e := file.Close(); e != nil && err == nil {
+			err = fmt.Errorf("error during closing environment file: %w", e)
+		}
+	}()
+
+	return Read(name, file, lookup)
+}
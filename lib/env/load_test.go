@@ -0,0 +1,132 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	tests := []struct {
+		appEnv string
+		want   []string
+	}{
+		{appEnv: "", want: []string{".env", ".env.local"}},
+		{appEnv: "test", want: []string{".env", ".env.local", ".env.test", ".env.test.local"}},
+	}
+
+	for _, tt := range tests {
+		if got := Files(tt.appEnv); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Files(%q) = %q, want %q", tt.appEnv, got, tt.want)
+		}
+	}
+}
+
+func TestLoadVars(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, data string) string {
+		t.Helper()
+
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	// unset everything loaded by the test.
+	t.Cleanup(func() {
+		if err := LoadVars(filepath.Join(dir, "missing")); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Setenv("ENV_TEST_REAL", "real")
+
+	base := write(".env", "ENV_TEST_A=base\nENV_TEST_B=base\nENV_TEST_REAL=file\nENV_TEST_REF=${ENV_TEST_REAL}\n")
+	local := write(".env.local", "ENV_TEST_B=local\nENV_TEST_C=${ENV_TEST_A}-local\n")
+	missing := filepath.Join(dir, ".env.test")
+
+	steps := []struct {
+		name  string
+		files []string
+		data  map[string]string // rewrites .env.local before loading
+		want  map[string]string
+		unset []string
+	}{
+		{
+			name:  "layered",
+			files: []string{base, local, missing},
+			want: map[string]string{
+				"ENV_TEST_A":    "base",
+				"ENV_TEST_B":    "local",
+				"ENV_TEST_C":    "base-local",
+				"ENV_TEST_REAL": "real",
+				"ENV_TEST_REF":  "real",
+			},
+		},
+		{
+			name:  "reload updates and removes",
+			files: []string{base, local},
+			data:  map[string]string{".env.local": "ENV_TEST_A=changed\n"},
+			want: map[string]string{
+				"ENV_TEST_A":    "changed",
+				"ENV_TEST_B":    "base",
+				"ENV_TEST_REAL": "real",
+			},
+			unset: []string{"ENV_TEST_C"},
+		},
+		{
+			name:  "reload without files",
+			files: []string{missing},
+			want:  map[string]string{"ENV_TEST_REAL": "real"},
+			unset: []string{"ENV_TEST_A", "ENV_TEST_B", "ENV_TEST_REF"},
+		},
+	}
+
+	for _, step := range steps {
+		for name, data := range step.data {
+			write(name, data)
+		}
+
+		if err := LoadVars(step.files...); err != nil {
+			t.Fatalf("%s: load: %v", step.name, err)
+		}
+
+		for key, want := range step.want {
+			if got, ok := os.LookupEnv(key); !ok || got != want {
+				t.Errorf("%s: %s = %q (set %t), want %q", step.name, key, got, ok, want)
+			}
+		}
+
+		for _, key := range step.unset {
+			if got, ok := os.LookupEnv(key); ok {
+				t.Errorf("%s: %s = %q, want unset", step.name, key, got)
+			}
+		}
+	}
+}
+
+func TestLoadVarsError(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(path, []byte("ENV_TEST_OK=1\nbroken\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := LoadVars(path)
+
+	var pErr *ParseError
+	if !errors.As(err, &pErr) || pErr.File != path || pErr.Line != 2 {
+		t.Fatalf("got error %v, want %s:2", err, path)
+	}
+
+	if _, ok := os.LookupEnv("ENV_TEST_OK"); ok {
+		t.Error("variables set from file with error")
+	}
+}
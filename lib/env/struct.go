@@ -0,0 +1,223 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const mask = "******"
+
+// Struct tags understood by Parse.
+const (
+	tagName     = "env"
+	tagDefault  = "default"
+	tagRequired = "required"
+	tagSecret   = "secret"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Parse fills fields of struct pointed by dst from environment variables named in `env` tag.
+// Values missing in environment are taken from `default` tag,
+// fields tagged `required:"true"` must have non-empty value.
+// Nested structs are parsed recursively. Slices are split by commas and spaces.
+// All errors are collected, so that every misconfigured variable is reported at once.
+func Parse(dst any) error {
+	val := reflect.ValueOf(dst)
+	if val.Kind() != reflect.Pointer || val.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected pointer to struct, got %T", dst)
+	}
+
+	var errs []string
+
+	walk(val.Elem(), func(field reflect.Value, tag reflect.StructTag) {
+		name := tag.Get(tagName)
+
+		raw, ok := os.LookupEnv(name)
+		if !ok || raw == "" {
+			raw = tag.Get(tagDefault)
+		}
+
+		if raw == "" {
+			if tag.Get(tagRequired) == "true" {
+				errs = append(errs, fmt.Sprintf("%s is required", name))
+			}
+
+			return
+		}
+
+		if err := set(field, raw); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+		}
+	})
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// Var is formatted value of struct field.
+type Var struct {
+	Key    string
+	Val    string
+	Secret bool
+}
+
+// Masked returns value with secret masked.
+func (v Var) Masked() string {
+	if v.Secret && v.Val != "" {
+		return mask
+	}
+
+	return v.Val
+}
+
+// Change describes variable that differs between two structs.
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// Vars lists variables of parsed struct in order of fields,
+// ones tagged `secret:"true"` are marked as secret.
+func Vars(src any) []Var {
+	val := reflect.Indirect(reflect.ValueOf(src))
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var vars []Var
+
+	walk(val, func(field reflect.Value, tag reflect.StructTag) {
+		vars = append(vars, Var{
+			Key:    tag.Get(tagName),
+			Val:    format(field),
+			Secret: tag.Get(tagSecret) == "true",
+		})
+	})
+
+	return vars
+}
+
+// Print writes variables of parsed struct as KEY=value lines masking secrets.
+func Print(w io.Writer, src any) error {
+	for _, v := range Vars(src) {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", v.Key, v.Masked()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Diff lists variables that differ between two structs of the same type.
+// Changed secrets are reported with masked values.
+func Diff(old, cur any) []Change {
+	prev := make(map[string]Var)
+	for _, v := range Vars(old) {
+		prev[v.Key] = v
+	}
+
+	var changes []Change
+
+	for _, v := range Vars(cur) {
+		if p, ok := prev[v.Key]; !ok || p.Val != v.Val {
+			changes = append(changes, Change{Key: v.Key, Old: p.Masked(), New: v.Masked()})
+		}
+	}
+
+	return changes
+}
+
+// walk calls fn for every field having `env` tag descending into nested structs.
+func walk(val reflect.Value, fn func(reflect.Value, reflect.StructTag)) {
+	typ := val.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		field := val.Field(i)
+
+		if _, ok := sf.Tag.Lookup(tagName); !ok {
+			if field.Kind() == reflect.Struct {
+				walk(field, fn)
+			}
+
+			continue
+		}
+
+		fn(field, sf.Tag)
+	}
+}
+
+func set(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(d))
+
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type: %s", field.Type())
+		}
+
+		items := strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' })
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type: %s", field.Type())
+	}
+
+	return nil
+}
+
+func format(field reflect.Value) string {
+	switch v := field.Interface().(type) {
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
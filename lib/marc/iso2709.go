@@ -0,0 +1,218 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Delimiters of ISO 2709 record.
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+)
+
+const (
+	leaderLen   = 24
+	entryLen    = 12
+	maxFieldLen = 9999
+	maxRecord   = 99999
+)
+
+type iso2709Reader struct {
+	r *bufio.Reader
+}
+
+func newISO2709Reader(r io.Reader) *iso2709Reader {
+	return &iso2709Reader{r: bufio.NewReader(r)}
+}
+
+func (ir *iso2709Reader) Read() (*Record, error) {
+	// Records are often separated by line breaks by tools that produce them.
+	for {
+		b, err := ir.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if b != '\n' && b != '\r' {
+			_ = ir.r.UnreadByte()
+			break
+		}
+	}
+
+	var head [5]byte
+
+	if _, err := io.ReadFull(ir.r, head[:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	size, ok := digits(head[:])
+	if !ok || size <= leaderLen {
+		return nil, fmt.Errorf("%w: invalid record length %q", ErrMalformed, head[:])
+	}
+
+	data := make([]byte, size)
+	copy(data, head[:])
+
+	if _, err := io.ReadFull(ir.r, data[len(head):]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	return parseISO2709(data)
+}
+
+// parseISO2709 parses record as is, MARC-8 encoded records are read correctly only when they are plain ASCII.
+func parseISO2709(data []byte) (*Record, error) {
+	rec := Record{Leader: string(data[:leaderLen])}
+
+	base, ok := digits(data[12:17])
+	if !ok || base <= leaderLen || base > len(data) {
+		return nil, fmt.Errorf("%w: invalid base address %q", ErrMalformed, data[12:17])
+	}
+
+	dir := data[leaderLen : base-1]
+	if len(dir)%entryLen != 0 {
+		return nil, fmt.Errorf("%w: invalid directory length %d", ErrMalformed, len(dir))
+	}
+
+	for i := 0; i < len(dir); i += entryLen {
+		entry := dir[i : i+entryLen]
+
+		length, ok1 := digits(entry[3:7])
+		start, ok2 := digits(entry[7:12])
+
+		if !ok1 || !ok2 || base+start+length > len(data) {
+			return nil, fmt.Errorf("%w: invalid directory entry %q", ErrMalformed, entry)
+		}
+
+		f := Field{Tag: string(entry[:3])}
+		raw := bytes.TrimSuffix(data[base+start:base+start+length], []byte{fieldTerminator})
+
+		if f.IsControl() {
+			f.Value = string(raw)
+			rec.Fields = append(rec.Fields, f)
+
+			continue
+		}
+
+		if len(raw) < 2 {
+			return nil, fmt.Errorf("%w: field %s has no indicators", ErrMalformed, f.Tag)
+		}
+
+		f.Ind1, f.Ind2 = raw[0], raw[1]
+
+		for _, part := range bytes.Split(raw[2:], []byte{subfieldDelimiter}) {
+			if len(part) == 0 {
+				continue
+			}
+
+			f.Subfields = append(f.Subfields, Subfield{Code: part[0], Value: string(part[1:])})
+		}
+
+		rec.Fields = append(rec.Fields, f)
+	}
+
+	return &rec, nil
+}
+
+// digits parses unsigned decimal number of fixed width, signs and spaces that strconv accepts are rejected,
+// so that lengths and offsets are never negative.
+func digits(b []byte) (int, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+
+	var n int
+
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+
+		n = n*10 + int(c-'0')
+	}
+
+	return n, true
+}
+
+type iso2709Writer struct {
+	w io.Writer
+}
+
+func newISO2709Writer(w io.Writer) *iso2709Writer {
+	return &iso2709Writer{w: w}
+}
+
+// Write encodes record in UTF-8, leader is adjusted accordingly.
+func (iw *iso2709Writer) Write(rec *Record) error {
+	var dir, body bytes.Buffer
+
+	for _, f := range rec.Fields {
+		if len(f.Tag) != 3 {
+			return fmt.Errorf("%w: invalid tag %q", ErrMalformed, f.Tag)
+		}
+
+		start := body.Len()
+
+		if f.IsControl() {
+			body.WriteString(f.Value)
+		} else {
+			body.WriteByte(blank(f.Ind1))
+			body.WriteByte(blank(f.Ind2))
+
+			for _, sf := range f.Subfields {
+				body.WriteByte(subfieldDelimiter)
+				body.WriteByte(sf.Code)
+				body.WriteString(sf.Value)
+			}
+		}
+
+		body.WriteByte(fieldTerminator)
+
+		length := body.Len() - start
+		if length > maxFieldLen {
+			return fmt.Errorf("%w: field %s is too long", ErrMalformed, f.Tag)
+		}
+
+		fmt.Fprintf(&dir, "%s%04d%05d", f.Tag, length, start)
+	}
+
+	dir.WriteByte(fieldTerminator)
+	body.WriteByte(recordTerminator)
+
+	base := leaderLen + dir.Len()
+	size := base + body.Len()
+
+	if size > maxRecord {
+		return fmt.Errorf("%w: record is too long", ErrMalformed)
+	}
+
+	leader := []byte(defaultLeader)
+	if len(rec.Leader) == leaderLen {
+		leader = []byte(rec.Leader)
+	}
+
+	copy(leader[0:5], fmt.Sprintf("%05d", size))
+	leader[9] = 'a'
+	copy(leader[10:12], "22")
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+	copy(leader[20:24], "4500")
+
+	out := make([]byte, 0, size)
+	out = append(out, leader...)
+	out = append(out, dir.Bytes()...)
+	out = append(out, body.Bytes()...)
+
+	if _, err := iw.w.Write(out); err != nil {
+		return fmt.Errorf("error writing record: %w", err)
+	}
+
+	return nil
+}
+
+func (iw *iso2709Writer) Close() error {
+	return nil
+}
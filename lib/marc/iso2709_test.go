@@ -0,0 +1,77 @@
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestISO2709RoundTrip(t *testing.T) {
+	data := record(t)
+
+	rec, err := newISO2709Reader(bytes.NewReader(data)).Read()
+	if err != nil {
+		t.Fatalf("reading record: %v", err)
+	}
+
+	if got := rec.Fields[0].Value; got != "ocm01" {
+		t.Errorf("control field is %q, want %q", got, "ocm01")
+	}
+
+	f, ok := rec.First("245")
+	if !ok || f.Subfield('a') != "Title" {
+		t.Errorf("title field is %+v, want subfield a %q", f, "Title")
+	}
+}
+
+func TestISO2709Malformed(t *testing.T) {
+	// Directory starts right after leader, entry of 245 is the second one.
+	const entry245 = leaderLen + entryLen
+
+	tests := []struct {
+		name   string
+		offset int
+		patch  string
+	}{
+		{name: "negative field length", offset: entry245, patch: "245-00100000"},
+		{name: "signed field length", offset: entry245 + 3, patch: "+009"},
+		{name: "negative field start", offset: entry245 + 7, patch: "-0001"},
+		{name: "field past record", offset: entry245 + 3, patch: "9999"},
+		{name: "spaced field start", offset: entry245 + 7, patch: " 0006"},
+		{name: "signed base address", offset: 12, patch: "+0049"},
+		{name: "negative base address", offset: 12, patch: "-0001"},
+		{name: "base address within leader", offset: 12, patch: "00010"},
+		{name: "signed record length", offset: 0, patch: "+0090"},
+		{name: "negative record length", offset: 0, patch: "-0001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := record(t)
+			copy(data[tt.offset:], tt.patch)
+
+			_, err := newISO2709Reader(bytes.NewReader(data)).Read()
+			if !errors.Is(err, ErrMalformed) {
+				t.Errorf("got error %v, want %v", err, ErrMalformed)
+			}
+		})
+	}
+}
+
+// record returns encoded record with control field and title.
+func record(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	rec := Record{Fields: []Field{
+		{Tag: "001", Value: "ocm01"},
+		{Tag: "245", Ind1: '1', Ind2: '0', Subfields: []Subfield{{Code: 'a', Value: "Title"}}},
+	}}
+
+	if err := newISO2709Writer(&buf).Write(&rec); err != nil {
+		t.Fatalf("writing record: %v", err)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,227 @@
+// Package marc reads and writes bibliographic records in MARC 21,
+// both as ISO 2709 binary and as MARCXML.
+package marc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrMalformed is returned for record that does not follow the format.
+var ErrMalformed = errors.New("malformed MARC record")
+
+// defaultLeader is used for records without leader: new bibliographic record of monograph.
+const defaultLeader = "00000nam a2200000 i 4500"
+
+// Supported formats.
+const (
+	FormatISO2709 = "mrc"
+	FormatXML     = "marcxml"
+)
+
+// ContentTypes maps formats to media types.
+var ContentTypes = map[string]string{
+	FormatISO2709: "application/marc",
+	FormatXML:     "application/marcxml+xml",
+}
+
+// Record is MARC record, fields are kept in order they were read.
+type Record struct {
+	Leader string
+	Fields []Field
+}
+
+// Field is control field when tag starts with "00", such field has Value only.
+// Data field has indicators and subfields instead.
+type Field struct {
+	Tag       string
+	Ind1      byte
+	Ind2      byte
+	Value     string
+	Subfields []Subfield
+}
+
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+// Reader reads records one by one, io.EOF is returned after the last one.
+type Reader interface {
+	Read() (*Record, error)
+}
+
+// Writer writes records, Close has to be called to complete output.
+type Writer interface {
+	Write(*Record) error
+	Close() error
+}
+
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatISO2709:
+		return newISO2709Reader(r), nil
+	case FormatXML:
+		return newXMLReader(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %q", format)
+	}
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatISO2709:
+		return newISO2709Writer(w), nil
+	case FormatXML:
+		return newXMLWriter(w)
+	default:
+		return nil, fmt.Errorf("unsupported format: %q", format)
+	}
+}
+
+// IsControl tells whether field has no indicators and subfields.
+func (f Field) IsControl() bool {
+	return strings.HasPrefix(f.Tag, "00")
+}
+
+// Subfield returns value of first subfield with given code.
+func (f Field) Subfield(code byte) string {
+	for _, sf := range f.Subfields {
+		if sf.Code == code {
+			return sf.Value
+		}
+	}
+
+	return ""
+}
+
+// Get returns fields with given tag.
+func (r *Record) Get(tag string) []Field {
+	var fields []Field
+
+	for _, f := range r.Fields {
+		if f.Tag == tag {
+			fields = append(fields, f)
+		}
+	}
+
+	return fields
+}
+
+// First returns first field with given tag.
+func (r *Record) First(tag string) (Field, bool) {
+	for _, f := range r.Fields {
+		if f.Tag == tag {
+			return f, true
+		}
+	}
+
+	return Field{}, false
+}
+
+// MarshalJSON encodes record in MARC-in-JSON format.
+func (r Record) MarshalJSON() ([]byte, error) {
+	type subfield map[string]string
+
+	type dataField struct {
+		Ind1      string     `json:"ind1"`
+		Ind2      string     `json:"ind2"`
+		Subfields []subfield `json:"subfields"`
+	}
+
+	fields := make([]map[string]any, len(r.Fields))
+
+	for i, f := range r.Fields {
+		if f.IsControl() {
+			fields[i] = map[string]any{f.Tag: f.Value}
+			continue
+		}
+
+		df := dataField{Ind1: string(blank(f.Ind1)), Ind2: string(blank(f.Ind2)), Subfields: make([]subfield, len(f.Subfields))}
+		for j, sf := range f.Subfields {
+			df.Subfields[j] = subfield{string(sf.Code): sf.Value}
+		}
+
+		fields[i] = map[string]any{f.Tag: df}
+	}
+
+	return json.Marshal(struct {
+		Leader string           `json:"leader"`
+		Fields []map[string]any `json:"fields"`
+	}{r.Leader, fields})
+}
+
+// UnmarshalJSON decodes record in MARC-in-JSON format.
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var src struct {
+		Leader string                       `json:"leader"`
+		Fields []map[string]json.RawMessage `json:"fields"`
+	}
+
+	if err := json.Unmarshal(data, &src); err != nil {
+		return err
+	}
+
+	r.Leader = src.Leader
+	r.Fields = r.Fields[:0]
+
+	for _, obj := range src.Fields {
+		for tag, raw := range obj {
+			f := Field{Tag: tag}
+
+			if f.IsControl() {
+				if err := json.Unmarshal(raw, &f.Value); err != nil {
+					return fmt.Errorf("field %s: %w", tag, err)
+				}
+
+				r.Fields = append(r.Fields, f)
+
+				continue
+			}
+
+			var df struct {
+				Ind1      string              `json:"ind1"`
+				Ind2      string              `json:"ind2"`
+				Subfields []map[string]string `json:"subfields"`
+			}
+
+			if err := json.Unmarshal(raw, &df); err != nil {
+				return fmt.Errorf("field %s: %w", tag, err)
+			}
+
+			f.Ind1, f.Ind2 = indicator(df.Ind1), indicator(df.Ind2)
+
+			for _, sf := range df.Subfields {
+				for code, val := range sf {
+					if code != "" {
+						f.Subfields = append(f.Subfields, Subfield{Code: code[0], Value: val})
+					}
+				}
+			}
+
+			r.Fields = append(r.Fields, f)
+		}
+	}
+
+	return nil
+}
+
+func indicator(s string) byte {
+	if s == "" {
+		return ' '
+	}
+
+	return s[0]
+}
+
+// blank replaces unset indicator with blank.
+func blank(b byte) byte {
+	if b == 0 {
+		return ' '
+	}
+
+	return b
+}
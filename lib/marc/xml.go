@@ -0,0 +1,148 @@
+package marc
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// xmlNamespace is namespace of MARCXML schema.
+const xmlNamespace = "http://www.loc.gov/MARC21/slim"
+
+type xmlRecord struct {
+	XMLName       xml.Name          `xml:"record"`
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// xmlReader decodes record elements one by one, so that they can be
+// read from collection or from any other document that embeds them.
+type xmlReader struct {
+	dec *xml.Decoder
+}
+
+func newXMLReader(r io.Reader) *xmlReader {
+	return &xmlReader{dec: xml.NewDecoder(r)}
+}
+
+func (xr *xmlReader) Read() (*Record, error) {
+	for {
+		tok, err := xr.dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, err
+			}
+
+			return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+
+		var src xmlRecord
+		if err := xr.dec.DecodeElement(&src, &start); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+		}
+
+		return src.record(), nil
+	}
+}
+
+// record restores order of fields, MARCXML lists control fields first anyway.
+func (src xmlRecord) record() *Record {
+	rec := Record{Leader: src.Leader}
+
+	for _, cf := range src.ControlFields {
+		rec.Fields = append(rec.Fields, Field{Tag: cf.Tag, Value: cf.Value})
+	}
+
+	for _, df := range src.DataFields {
+		f := Field{Tag: df.Tag, Ind1: indicator(df.Ind1), Ind2: indicator(df.Ind2)}
+
+		for _, sf := range df.Subfields {
+			if sf.Code != "" {
+				f.Subfields = append(f.Subfields, Subfield{Code: sf.Code[0], Value: sf.Value})
+			}
+		}
+
+		rec.Fields = append(rec.Fields, f)
+	}
+
+	return &rec
+}
+
+// xmlWriter streams records as elements of single collection.
+type xmlWriter struct {
+	w   *bufio.Writer
+	enc *xml.Encoder
+}
+
+func newXMLWriter(w io.Writer) (*xmlWriter, error) {
+	bw := bufio.NewWriter(w)
+
+	if _, err := bw.WriteString(xml.Header + `<collection xmlns="` + xmlNamespace + `">`); err != nil {
+		return nil, fmt.Errorf("error writing collection: %w", err)
+	}
+
+	return &xmlWriter{w: bw, enc: xml.NewEncoder(bw)}, nil
+}
+
+func (xw *xmlWriter) Write(rec *Record) error {
+	dst := xmlRecord{Leader: rec.Leader}
+	if len(dst.Leader) != leaderLen {
+		dst.Leader = defaultLeader
+	}
+
+	for _, f := range rec.Fields {
+		if f.IsControl() {
+			dst.ControlFields = append(dst.ControlFields, xmlControlField{Tag: f.Tag, Value: f.Value})
+			continue
+		}
+
+		df := xmlDataField{Tag: f.Tag, Ind1: string(blank(f.Ind1)), Ind2: string(blank(f.Ind2))}
+		for _, sf := range f.Subfields {
+			df.Subfields = append(df.Subfields, xmlSubfield{Code: string(sf.Code), Value: sf.Value})
+		}
+
+		dst.DataFields = append(dst.DataFields, df)
+	}
+
+	if err := xw.enc.Encode(dst); err != nil {
+		return fmt.Errorf("error encoding record: %w", err)
+	}
+
+	return nil
+}
+
+func (xw *xmlWriter) Close() error {
+	if err := xw.enc.Flush(); err != nil {
+		return fmt.Errorf("error flushing records: %w", err)
+	}
+
+	if _, err := xw.w.WriteString(`</collection>`); err != nil {
+		return fmt.Errorf("error writing collection: %w", err)
+	}
+
+	return xw.w.Flush()
+}
@@ -0,0 +1,144 @@
+// Package metrics is wrapper around prometheus client
+// that collects HTTP, database, redis and business metrics.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.HistogramVec
+	commands *prometheus.HistogramVec
+	events   *prometheus.CounterVec
+}
+
+// New creates registry with metrics of given namespace
+// along with go runtime and process collectors.
+func New(namespace string) *Metrics {
+	m := Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of HTTP requests by route pattern and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		commands: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      "command_duration_seconds",
+			Help:      "Duration of redis commands by command name and result.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"command", "status"}),
+		events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_total",
+			Help:      "Number of business events.",
+		}, []string{"event"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.commands,
+		m.events,
+	)
+
+	return &m
+}
+
+// Handler exposes metrics in prometheus format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Inc increments counter of business event.
+func (m *Metrics) Inc(event string) {
+	m.events.WithLabelValues(event).Inc()
+}
+
+// Add increases counter of business event by n, e.g. by number of books imported at once.
+func (m *Metrics) Add(event string, n int) {
+	m.events.WithLabelValues(event).Add(float64(n))
+}
+
+// ObserveRequest records duration of HTTP request.
+func (m *Metrics) ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Observe(elapsed.Seconds())
+}
+
+// RegisterDB exposes sql.DB.Stats() of connection pool.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) error {
+	if err := m.registry.Register(collectors.NewDBStatsCollector(db, name)); err != nil {
+		return fmt.Errorf("error registering db stats collector: %w", err)
+	}
+
+	return nil
+}
+
+// RedisHook returns hook recording duration of every redis command.
+func (m *Metrics) RedisHook() redis.Hook {
+	return redisHook{m.commands}
+}
+
+type startKey struct{}
+
+type redisHook struct{ commands *prometheus.HistogramVec }
+
+func (h redisHook) BeforeProcess(ctx context.Context, _ redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+func (h redisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.observe(ctx, cmd.Name(), cmd.Err())
+	return nil
+}
+
+func (h redisHook) BeforeProcessPipeline(ctx context.Context, _ []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+func (h redisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmd.Err() != nil {
+			err = cmd.Err()
+			break
+		}
+	}
+
+	h.observe(ctx, "pipeline", err)
+
+	return nil
+}
+
+func (h redisHook) observe(ctx context.Context, name string, err error) {
+	start, ok := ctx.Value(startKey{}).(time.Time)
+	if !ok {
+		return
+	}
+
+	status := "ok"
+
+	switch {
+	case err == redis.Nil:
+		status = "nil"
+	case err != nil:
+		status = "error"
+	}
+
+	h.commands.WithLabelValues(name, status).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,216 @@
+// Package oidctest provides local OpenID provider for tests, the way httptest provides HTTP servers.
+// Provider serves discovery, JWKS, authorization and token endpoints and signs ID tokens with its own key.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/delveper/mylib/lib/oidc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	// KeyID identifies signing key of Provider in its JWKS.
+	KeyID = "oidctest"
+
+	ClientID     = "client"
+	ClientSecret = "secret"
+
+	tokenExpiry = time.Minute
+)
+
+// Identity signs in on authorization endpoint of Provider.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+// Provider is OpenID provider running on local server, Close has to be called when test is done.
+// Tamper, if set, modifies claims of every ID token before it is signed, e.g. to expire it.
+type Provider struct {
+	*httptest.Server
+	Identity Identity
+	Tamper   func(*oidc.Claims)
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]oidc.Claims
+	seq   int
+}
+
+// NewProvider starts Provider, Server.URL is its issuer.
+func NewProvider() (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("error generating key: %w", err)
+	}
+
+	p := Provider{
+		key:   key,
+		codes: make(map[string]oidc.Claims),
+		Identity: Identity{
+			Subject:       "subject",
+			Email:         "reader@example.com",
+			EmailVerified: true,
+			GivenName:     "Jane",
+			FamilyName:    "Doe",
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+
+	p.Server = httptest.NewServer(mux)
+
+	return &p, nil
+}
+
+// Claims returns valid claims of ID token for Identity.
+func (p *Provider) Claims(nonce string) oidc.Claims {
+	now := time.Now()
+
+	return oidc.Claims{
+		Nonce:         nonce,
+		Email:         p.Identity.Email,
+		EmailVerified: p.Identity.EmailVerified,
+		GivenName:     p.Identity.GivenName,
+		FamilyName:    p.Identity.FamilyName,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    p.URL,
+			Subject:   p.Identity.Subject,
+			Audience:  jwt.ClaimStrings{ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenExpiry)),
+		},
+	}
+}
+
+// Sign signs claims as ID token with key of Provider, kid is put into header as is.
+func (p *Provider) Sign(claims oidc.Claims, kid string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	return token.SignedString(p.key)
+}
+
+// Login follows authorization URL the way user agent does after reader signed in
+// and returns callback URL provider redirected to.
+func (p *Provider) Login(authURL string) (*url.URL, error) {
+	client := p.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting authorization: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("authorization responded with status %d", resp.StatusCode)
+	}
+
+	return url.Parse(resp.Header.Get("Location"))
+}
+
+func (p *Provider) discovery(rw http.ResponseWriter, _ *http.Request) {
+	writeJSON(rw, http.StatusOK, oidc.Provider{
+		Issuer:                p.URL,
+		AuthorizationEndpoint: p.URL + "/authorize",
+		TokenEndpoint:         p.URL + "/token",
+		JWKSURI:               p.URL + "/jwks",
+	})
+}
+
+func (p *Provider) jwks(rw http.ResponseWriter, _ *http.Request) {
+	pub := p.key.PublicKey
+
+	writeJSON(rw, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": KeyID,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *Provider) authorize(rw http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query()
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("client_id") != ClientID || q.Get("response_type") != "code" {
+		http.Error(rw, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	p.seq++
+	code := fmt.Sprintf("code-%d", p.seq)
+	p.codes[code] = p.Claims(q.Get("nonce"))
+	p.mu.Unlock()
+
+	vals := redirect.Query()
+	vals.Set("code", code)
+	vals.Set("state", q.Get("state"))
+	redirect.RawQuery = vals.Encode()
+
+	http.Redirect(rw, req, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) token(rw http.ResponseWriter, req *http.Request) {
+	id, secret, ok := req.BasicAuth()
+	if !ok || id != ClientID || secret != ClientSecret {
+		writeJSON(rw, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := req.PostFormValue("code")
+
+	p.mu.Lock()
+	claims, ok := p.codes[code]
+	delete(p.codes, code)
+	p.mu.Unlock()
+
+	if !ok || req.PostFormValue("grant_type") != "authorization_code" {
+		writeJSON(rw, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	if p.Tamper != nil {
+		p.Tamper(&claims)
+	}
+
+	val, err := p.Sign(claims, KeyID)
+	if err != nil {
+		writeJSON(rw, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, map[string]string{"id_token": val, "token_type": "Bearer"})
+}
+
+func writeJSON(rw http.ResponseWriter, code int, data any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(code)
+	_ = json.NewEncoder(rw).Encode(data)
+}
@@ -0,0 +1,216 @@
+// Package oidc implements minimal OpenID Connect relying party:
+// provider discovery, authorization code flow and ID token verification through JWKS.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const discoveryPath = "/.well-known/openid-configuration"
+
+// Provider represents OpenID provider metadata.
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims represents claims of ID token relying party is interested in.
+type Claims struct {
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	jwt.RegisteredClaims
+}
+
+// RelyingParty performs authorization code flow against discovered provider.
+type RelyingParty struct {
+	Provider
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	client       *http.Client
+	keys         *keySet
+}
+
+// Discover fetches provider metadata from issuer.
+// Given client is used for all further requests to provider,
+// so local mock provider can be used as well.
+func Discover(ctx context.Context, client *http.Client, issuer string) (*Provider, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var p Provider
+	if err := getJSON(ctx, client, strings.TrimSuffix(issuer, "/")+discoveryPath, &p); err != nil {
+		return nil, fmt.Errorf("error fetching provider metadata: %w", err)
+	}
+
+	if strings.TrimSuffix(p.Issuer, "/") != strings.TrimSuffix(issuer, "/") {
+		return nil, fmt.Errorf("issuer mismatch: expected %q, got %q", issuer, p.Issuer)
+	}
+
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return nil, fmt.Errorf("incomplete provider metadata")
+	}
+
+	return &p, nil
+}
+
+// NewRelyingParty creates RelyingParty for given provider.
+// Scope openid is always requested.
+func NewRelyingParty(p *Provider, client *http.Client, clientID, clientSecret, redirectURL string, scopes ...string) *RelyingParty {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &RelyingParty{
+		Provider:     *p,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       append([]string{"openid"}, scopes...),
+		client:       client,
+		keys:         newKeySet(client, p.JWKSURI),
+	}
+}
+
+// AuthCodeURL returns URL of authorization endpoint reader has to be redirected to.
+func (rp *RelyingParty) AuthCodeURL(state, nonce string) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {rp.ClientID},
+		"redirect_uri":  {rp.RedirectURL},
+		"scope":         {strings.Join(rp.Scopes, " ")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+
+	sep := "?"
+	if strings.Contains(rp.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return rp.AuthorizationEndpoint + sep + q.Encode()
+}
+
+// Exchange exchanges authorization code for ID token and verifies it.
+func (rp *RelyingParty) Exchange(ctx context.Context, code, nonce string) (*Claims, error) {
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {rp.RedirectURL},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rp.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(rp.ClientID), url.QueryEscape(rp.ClientSecret))
+
+	resp, err := rp.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting token: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	var tokenResp struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("error decoding token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint responded %d: %s %s", resp.StatusCode, tokenResp.Error, tokenResp.ErrorDescription)
+	}
+
+	if tokenResp.IDToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	return rp.Verify(ctx, tokenResp.IDToken, nonce)
+}
+
+// Verify checks signature of ID token with provider keys
+// and validates issuer, audience, expiry and nonce claims.
+func (rp *RelyingParty) Verify(ctx context.Context, raw, nonce string) (*Claims, error) {
+	var claims Claims
+
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}))
+
+	_, err := parser.ParseWithClaims(raw, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return rp.keys.get(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error verifying id token: %w", err)
+	}
+
+	if strings.TrimSuffix(claims.Issuer, "/") != strings.TrimSuffix(rp.Issuer, "/") {
+		return nil, fmt.Errorf("unexpected issuer: %s", claims.Issuer)
+	}
+
+	if !claims.VerifyAudience(rp.ClientID, true) {
+		return nil, fmt.Errorf("id token is not issued for client %s", rp.ClientID)
+	}
+
+	if claims.ExpiresAt == nil || claims.IssuedAt == nil {
+		return nil, fmt.Errorf("id token has no exp or iat claim")
+	}
+
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("nonce mismatch")
+	}
+
+	return &claims, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, uri string, dst any) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error requesting %s: %w", uri, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with status %d", uri, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(dst); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package oidc_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/delveper/mylib/lib/oidc"
+	"github.com/delveper/mylib/lib/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const redirectURL = "http://localhost/callback"
+
+func TestExchange(t *testing.T) {
+	p, rp := setup(t)
+
+	callback, err := p.Login(rp.AuthCodeURL("state", "nonce"))
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	if got := callback.Query().Get("state"); got != "state" {
+		t.Errorf("callback state is %q, want %q", got, "state")
+	}
+
+	claims, err := rp.Exchange(context.Background(), callback.Query().Get("code"), "nonce")
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+
+	if claims.Subject != p.Identity.Subject || claims.Email != p.Identity.Email || !claims.EmailVerified {
+		t.Errorf("got claims %+v, want identity %+v", claims, p.Identity)
+	}
+}
+
+func TestExchangeNonceMismatch(t *testing.T) {
+	p, rp := setup(t)
+
+	callback, err := p.Login(rp.AuthCodeURL("state", "nonce"))
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	if _, err := rp.Exchange(context.Background(), callback.Query().Get("code"), "another"); err == nil {
+		t.Error("exchange succeeded with nonce of another request")
+	}
+}
+
+func TestExchangeUnknownCode(t *testing.T) {
+	_, rp := setup(t)
+
+	if _, err := rp.Exchange(context.Background(), "unknown", "nonce"); err == nil {
+		t.Error("exchange succeeded with unknown code")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		kid    string
+		nonce  string
+		tamper func(*oidc.Claims)
+		valid  bool
+	}{
+		{
+			name:  "valid",
+			kid:   oidctest.KeyID,
+			nonce: "nonce",
+			valid: true,
+		},
+		{
+			name:  "nonce mismatch",
+			kid:   oidctest.KeyID,
+			nonce: "another",
+		},
+		{
+			name:   "wrong audience",
+			kid:    oidctest.KeyID,
+			nonce:  "nonce",
+			tamper: func(c *oidc.Claims) { c.Audience = jwt.ClaimStrings{"another"} },
+		},
+		{
+			name:   "wrong issuer",
+			kid:    oidctest.KeyID,
+			nonce:  "nonce",
+			tamper: func(c *oidc.Claims) { c.Issuer = "https://issuer.example.com" },
+		},
+		{
+			name:  "expired",
+			kid:   oidctest.KeyID,
+			nonce: "nonce",
+			tamper: func(c *oidc.Claims) {
+				c.IssuedAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+				c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			},
+		},
+		{
+			name:   "no expiry",
+			kid:    oidctest.KeyID,
+			nonce:  "nonce",
+			tamper: func(c *oidc.Claims) { c.ExpiresAt = nil },
+		},
+		{
+			name:  "unknown key id",
+			kid:   "unknown",
+			nonce: "nonce",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, rp := setup(t)
+
+			claims := p.Claims("nonce")
+			if tt.tamper != nil {
+				tt.tamper(&claims)
+			}
+
+			raw, err := p.Sign(claims, tt.kid)
+			if err != nil {
+				t.Fatalf("signing token: %v", err)
+			}
+
+			_, err = rp.Verify(context.Background(), raw, tt.nonce)
+			if tt.valid && err != nil {
+				t.Errorf("verify failed: %v", err)
+			}
+
+			if !tt.valid && err == nil {
+				t.Error("verify succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestVerifyForeignKey(t *testing.T) {
+	_, rp := setup(t)
+
+	// Token signed by another provider with the same key ID.
+	other, err := oidctest.NewProvider()
+	if err != nil {
+		t.Fatalf("starting provider: %v", err)
+	}
+
+	t.Cleanup(other.Close)
+
+	claims := other.Claims("nonce")
+	claims.Issuer = rp.Issuer
+
+	raw, err := other.Sign(claims, oidctest.KeyID)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+
+	if _, err := rp.Verify(context.Background(), raw, "nonce"); err == nil {
+		t.Error("verify succeeded for token signed with foreign key")
+	}
+}
+
+func TestDiscoverIssuerMismatch(t *testing.T) {
+	p, _ := setup(t)
+
+	// The same server reached by another name is another issuer.
+	issuer := strings.Replace(p.URL, "127.0.0.1", "localhost", 1)
+
+	if _, err := oidc.Discover(context.Background(), p.Client(), issuer); err == nil {
+		t.Error("discovery succeeded for another issuer")
+	}
+}
+
+// setup starts mock provider and discovers it.
+func setup(t *testing.T) (*oidctest.Provider, *oidc.RelyingParty) {
+	t.Helper()
+
+	p, err := oidctest.NewProvider()
+	if err != nil {
+		t.Fatalf("starting provider: %v", err)
+	}
+
+	t.Cleanup(p.Close)
+
+	meta, err := oidc.Discover(context.Background(), p.Client(), p.URL)
+	if err != nil {
+		t.Fatalf("discovery: %v", err)
+	}
+
+	return p, oidc.NewRelyingParty(meta, p.Client(), oidctest.ClientID, oidctest.ClientSecret, redirectURL, "email")
+}
@@ -0,0 +1,187 @@
+// Package onix reads products of ONIX for Books 3.0 messages.
+// Only reference tag names are supported, short tags such as <product> are not.
+package onix
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrMalformed is returned for message that can not be decoded.
+var ErrMalformed = errors.New("malformed ONIX message")
+
+// Codes of ONIX code lists used by callers.
+const (
+	// List 1, notification type.
+	NotificationDelete = "05"
+	// List 5, product identifier type.
+	IDTypeISBN10 = "02"
+	IDTypeISBN13 = "15"
+	// List 15, title type.
+	TitleTypeDistinctive = "01"
+	// List 17, contributor role.
+	RoleAuthor = "A01"
+	// List 23 and 24, extent type and unit.
+	ExtentMainContent = "00"
+	ExtentUnitPages   = "03"
+	// List 163, publishing date role.
+	DateRolePublication = "01"
+)
+
+// Product describes single product of message, only elements needed to catalog books are decoded.
+type Product struct {
+	RecordReference  string            `xml:"RecordReference"`
+	NotificationType string            `xml:"NotificationType"`
+	Identifiers      []Identifier      `xml:"ProductIdentifier"`
+	Descriptive      DescriptiveDetail `xml:"DescriptiveDetail"`
+	Publishing       PublishingDetail  `xml:"PublishingDetail"`
+}
+
+type Identifier struct {
+	Type  string `xml:"ProductIDType"`
+	Value string `xml:"IDValue"`
+}
+
+type DescriptiveDetail struct {
+	Titles       []TitleDetail `xml:"TitleDetail"`
+	Contributors []Contributor `xml:"Contributor"`
+	Extents      []Extent      `xml:"Extent"`
+	Subjects     []Subject     `xml:"Subject"`
+}
+
+type TitleDetail struct {
+	Type     string         `xml:"TitleType"`
+	Elements []TitleElement `xml:"TitleElement"`
+}
+
+type TitleElement struct {
+	Text          string `xml:"TitleText"`
+	Prefix        string `xml:"TitlePrefix"`
+	WithoutPrefix string `xml:"TitleWithoutPrefix"`
+	Subtitle      string `xml:"Subtitle"`
+}
+
+type Contributor struct {
+	Roles              []string `xml:"ContributorRole"`
+	PersonName         string   `xml:"PersonName"`
+	PersonNameInverted string   `xml:"PersonNameInverted"`
+	NamesBeforeKey     string   `xml:"NamesBeforeKey"`
+	KeyNames           string   `xml:"KeyNames"`
+}
+
+type Extent struct {
+	Type  string `xml:"ExtentType"`
+	Value string `xml:"ExtentValue"`
+	Unit  string `xml:"ExtentUnit"`
+}
+
+type Subject struct {
+	Main    *struct{} `xml:"MainSubject"`
+	Scheme  string    `xml:"SubjectSchemeIdentifier"`
+	Code    string    `xml:"SubjectCode"`
+	Heading string    `xml:"SubjectHeadingText"`
+}
+
+type PublishingDetail struct {
+	Publishers []Publisher      `xml:"Publisher"`
+	Cities     []string         `xml:"CityOfPublication"`
+	Dates      []PublishingDate `xml:"PublishingDate"`
+}
+
+type Publisher struct {
+	Name string `xml:"PublisherName"`
+}
+
+type PublishingDate struct {
+	Role string `xml:"PublishingDateRole"`
+	Date string `xml:"Date"`
+}
+
+// Reader decodes products one by one, so that message of any size can be read.
+type Reader struct {
+	dec *xml.Decoder
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{dec: xml.NewDecoder(r)}
+}
+
+// Read returns next product, io.EOF is returned after the last one.
+func (r *Reader) Read() (*Product, error) {
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, err
+			}
+
+			return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "Product" {
+			continue
+		}
+
+		var p Product
+		if err := r.dec.DecodeElement(&p, &start); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformed, err)
+		}
+
+		return &p, nil
+	}
+}
+
+// Title returns distinctive title of product with prefix and subtitle.
+func (p *Product) Title() (title, subtitle string) {
+	for _, td := range p.Descriptive.Titles {
+		if td.Type != TitleTypeDistinctive || len(td.Elements) == 0 {
+			continue
+		}
+
+		el := td.Elements[0]
+
+		title = el.Text
+		if title == "" {
+			title = el.WithoutPrefix
+			if el.Prefix != "" {
+				title = el.Prefix + " " + title
+			}
+		}
+
+		return title, el.Subtitle
+	}
+
+	return "", ""
+}
+
+// ISBN returns ISBN-13 of product, or ISBN-10 if there is no ISBN-13.
+func (p *Product) ISBN() string {
+	var isbn string
+
+	for _, id := range p.Identifiers {
+		switch id.Type {
+		case IDTypeISBN13:
+			return id.Value
+		case IDTypeISBN10:
+			isbn = id.Value
+		}
+	}
+
+	return isbn
+}
+
+// Author returns first contributor having role of author.
+func (p *Product) Author() (Contributor, bool) {
+	for _, c := range p.Descriptive.Contributors {
+		for _, role := range c.Roles {
+			if role == RoleAuthor {
+				return c, true
+			}
+		}
+	}
+
+	return Contributor{}, false
+}
@@ -0,0 +1,161 @@
+// Package openlib reads records of Open Library data dumps.
+// Dumps are read as downloaded, either gzip compressed or not, in tab separated form
+// of type, key, revision, last modified and JSON, or as JSON record per line.
+package openlib
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrMalformed is returned for record that can not be decoded.
+var ErrMalformed = errors.New("malformed dump record")
+
+// Types of records.
+const (
+	TypeEdition = "/type/edition"
+	TypeWork    = "/type/work"
+	TypeAuthor  = "/type/author"
+)
+
+// tsvColumns is number of columns of tab separated dump, JSON is the last one.
+const tsvColumns = 5
+
+// gzipMagic starts every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Ref refers to another record by its key, e.g. "/works/OL45804W".
+type Ref struct {
+	Key string `json:"key"`
+}
+
+// Edition is published edition of work, only fields needed to enrich books are decoded.
+type Edition struct {
+	Key         string   `json:"key"`
+	Title       string   `json:"title"`
+	Subtitle    string   `json:"subtitle"`
+	ISBN10      []string `json:"isbn_10"`
+	ISBN13      []string `json:"isbn_13"`
+	Pages       int      `json:"number_of_pages"`
+	PublishDate string   `json:"publish_date"`
+	Subjects    []string `json:"subjects"`
+	Covers      []int    `json:"covers"`
+	Works       []Ref    `json:"works"`
+	Authors     []Ref    `json:"authors"`
+}
+
+// Work groups editions of the same text.
+type Work struct {
+	Key      string       `json:"key"`
+	Title    string       `json:"title"`
+	Subjects []string     `json:"subjects"`
+	Covers   []int        `json:"covers"`
+	Authors  []WorkAuthor `json:"authors"`
+}
+
+type WorkAuthor struct {
+	Author Ref `json:"author"`
+}
+
+type Author struct {
+	Key          string `json:"key"`
+	Name         string `json:"name"`
+	PersonalName string `json:"personal_name"`
+}
+
+// Record is single line of dump, Data is decoded by caller depending on Type.
+type Record struct {
+	Type string
+	Key  string
+	Data json.RawMessage
+}
+
+// Decode decodes data of record into v.
+func (r Record) Decode(v any) error {
+	if err := json.Unmarshal(r.Data, v); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrMalformed, r.Key, err)
+	}
+
+	return nil
+}
+
+// Reader reads records one by one, so that dump of any size can be read.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewReader returns Reader of dump, which is decompressed if it starts as gzip stream.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error reading dump: %w", err)
+	}
+
+	if !bytes.Equal(magic, gzipMagic) {
+		return &Reader{r: br}, nil
+	}
+
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing dump: %w", err)
+	}
+
+	return &Reader{r: bufio.NewReader(zr)}, nil
+}
+
+// Read returns next record, io.EOF is returned after the last one.
+func (r *Reader) Read() (Record, error) {
+	for {
+		line, err := r.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return Record{}, err
+		}
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Record{}, fmt.Errorf("error reading dump: %w", err)
+		}
+
+		r.line++
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		if line[0] == '{' {
+			return r.decodeJSON(line)
+		}
+
+		return r.decodeTSV(line)
+	}
+}
+
+func (r *Reader) decodeTSV(line []byte) (Record, error) {
+	cols := bytes.SplitN(line, []byte{'\t'}, tsvColumns)
+	if len(cols) != tsvColumns {
+		return Record{}, fmt.Errorf("%w: line %d: expected %d columns, got %d", ErrMalformed, r.line, tsvColumns, len(cols))
+	}
+
+	return Record{Type: string(cols[0]), Key: string(cols[1]), Data: cols[4]}, nil
+}
+
+func (r *Reader) decodeJSON(line []byte) (Record, error) {
+	var head struct {
+		Type Ref    `json:"type"`
+		Key  string `json:"key"`
+	}
+
+	if err := json.Unmarshal(line, &head); err != nil {
+		return Record{}, fmt.Errorf("%w: line %d: %w", ErrMalformed, r.line, err)
+	}
+
+	return Record{Type: head.Type.Key, Key: head.Key, Data: line}, nil
+}
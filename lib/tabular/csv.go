@@ -0,0 +1,44 @@
+package tabular
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+type csvWriter struct {
+	cols   []Column
+	w      *csv.Writer
+	record []string
+}
+
+func newCSV(w io.Writer, cols []Column) (*csvWriter, error) {
+	cw := csvWriter{cols: cols, w: csv.NewWriter(w), record: make([]string, len(cols))}
+
+	for i, col := range cols {
+		cw.record[i] = col.Name
+	}
+
+	if err := cw.w.Write(cw.record); err != nil {
+		return nil, fmt.Errorf("error writing csv header: %w", err)
+	}
+
+	return &cw, nil
+}
+
+func (cw *csvWriter) Write(row []any) error {
+	if err := checkRow(cw.cols, row); err != nil {
+		return err
+	}
+
+	for i, val := range row {
+		cw.record[i] = text(val)
+	}
+
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
@@ -0,0 +1,81 @@
+package tabular
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// jsonlWriter writes every row as JSON object keyed by column names on its own line.
+type jsonlWriter struct {
+	cols []Column
+	buf  *bufio.Writer
+	enc  *json.Encoder
+}
+
+func newJSONL(w io.Writer, cols []Column) *jsonlWriter {
+	buf := bufio.NewWriter(w)
+
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	return &jsonlWriter{cols: cols, buf: buf, enc: enc}
+}
+
+func (jw *jsonlWriter) Write(row []any) error {
+	if err := checkRow(jw.cols, row); err != nil {
+		return err
+	}
+
+	obj := make(orderedObject, len(row))
+	for i, val := range row {
+		obj[i] = keyVal{jw.cols[i].Name, val}
+	}
+
+	return jw.enc.Encode(obj)
+}
+
+func (jw *jsonlWriter) Close() error {
+	return jw.buf.Flush()
+}
+
+type keyVal struct {
+	key string
+	val any
+}
+
+// orderedObject keeps keys in order of columns, which map does not.
+type orderedObject []keyVal
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+
+	for i, kv := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		if err := enc.Encode(kv.key); err != nil {
+			return nil, err
+		}
+
+		buf.Truncate(buf.Len() - 1) // newline added by Encode
+		buf.WriteByte(':')
+
+		if err := enc.Encode(kv.val); err != nil {
+			return nil, err
+		}
+
+		buf.Truncate(buf.Len() - 1)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,247 @@
+package tabular
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const parquetMagic = "PAR1"
+
+// parquetGroupRows is number of rows kept in memory before row group is written.
+const parquetGroupRows = 10_000
+
+// Parquet enums, see parquet.thrift of apache/parquet-format.
+const (
+	parquetInt64         = 2
+	parquetByteArray     = 6
+	parquetRequired      = 0
+	parquetConvertedUTF8 = 0
+	parquetPlain         = 0
+	parquetRLE           = 3
+	parquetDataPage      = 0
+	parquetUncompressed  = 0
+)
+
+// parquetWriter writes uncompressed Parquet file with required columns in plain encoding.
+// Every column of row group is written as single data page.
+type parquetWriter struct {
+	w      io.Writer
+	offset int64
+	cols   []Column
+	pages  []bytes.Buffer
+	rows   int
+	total  int64
+	groups []parquetGroup
+}
+
+type parquetGroup struct {
+	rows   int64
+	size   int64
+	chunks []parquetChunk
+}
+
+type parquetChunk struct {
+	offset int64
+	size   int64
+}
+
+func newParquet(w io.Writer, cols []Column) (*parquetWriter, error) {
+	pw := parquetWriter{w: w, cols: cols, pages: make([]bytes.Buffer, len(cols))}
+
+	if err := pw.write([]byte(parquetMagic)); err != nil {
+		return nil, err
+	}
+
+	return &pw, nil
+}
+
+func (pw *parquetWriter) Write(row []any) error {
+	if err := checkRow(pw.cols, row); err != nil {
+		return err
+	}
+
+	for i, val := range row {
+		page := &pw.pages[i]
+
+		switch pw.cols[i].Kind {
+		case Int:
+			n, err := integer(val)
+			if err != nil {
+				return fmt.Errorf("column %s: %w", pw.cols[i].Name, err)
+			}
+
+			_ = binary.Write(page, binary.LittleEndian, n)
+		default:
+			s := text(val)
+			_ = binary.Write(page, binary.LittleEndian, uint32(len(s)))
+			page.WriteString(s)
+		}
+	}
+
+	pw.rows++
+
+	if pw.rows == parquetGroupRows {
+		return pw.flush()
+	}
+
+	return nil
+}
+
+func (pw *parquetWriter) Close() error {
+	if err := pw.flush(); err != nil {
+		return err
+	}
+
+	meta := pw.metadata()
+
+	if err := pw.write(meta); err != nil {
+		return err
+	}
+
+	var tail [8]byte
+
+	binary.LittleEndian.PutUint32(tail[:4], uint32(len(meta)))
+	copy(tail[4:], parquetMagic)
+
+	return pw.write(tail[:])
+}
+
+// flush writes buffered rows as row group.
+func (pw *parquetWriter) flush() error {
+	if pw.rows == 0 {
+		return nil
+	}
+
+	group := parquetGroup{rows: int64(pw.rows), chunks: make([]parquetChunk, len(pw.cols))}
+
+	for i := range pw.cols {
+		page := &pw.pages[i]
+
+		var hdr thriftWriter
+
+		hdr.begin(0)
+		hdr.i32(1, parquetDataPage)
+		hdr.i32(2, int32(page.Len()))
+		hdr.i32(3, int32(page.Len()))
+		hdr.begin(5)
+		hdr.i32(1, int32(pw.rows))
+		hdr.i32(2, parquetPlain)
+		hdr.i32(3, parquetRLE)
+		hdr.i32(4, parquetRLE)
+		hdr.end()
+		hdr.end()
+
+		chunk := parquetChunk{offset: pw.offset, size: int64(hdr.Len() + page.Len())}
+
+		if err := pw.write(hdr.Bytes()); err != nil {
+			return err
+		}
+
+		if err := pw.write(page.Bytes()); err != nil {
+			return err
+		}
+
+		page.Reset()
+
+		group.chunks[i] = chunk
+		group.size += chunk.size
+	}
+
+	pw.groups = append(pw.groups, group)
+	pw.total += group.rows
+	pw.rows = 0
+
+	return nil
+}
+
+// metadata encodes FileMetaData footer.
+func (pw *parquetWriter) metadata() []byte {
+	var t thriftWriter
+
+	t.begin(0)
+	t.i32(1, 1)
+
+	t.list(2, thriftStruct, len(pw.cols)+1)
+	t.begin(0)
+	t.binary(4, "schema")
+	t.i32(5, int32(len(pw.cols)))
+	t.end()
+
+	for _, col := range pw.cols {
+		t.begin(0)
+
+		if col.Kind == Int {
+			t.i32(1, parquetInt64)
+			t.i32(3, parquetRequired)
+			t.binary(4, col.Name)
+		} else {
+			t.i32(1, parquetByteArray)
+			t.i32(3, parquetRequired)
+			t.binary(4, col.Name)
+			t.i32(6, parquetConvertedUTF8)
+			t.begin(10) // LogicalType
+			t.begin(1)  // StringType
+			t.end()
+			t.end()
+		}
+
+		t.end()
+	}
+
+	t.i64(3, pw.total)
+
+	t.list(4, thriftStruct, len(pw.groups))
+
+	for _, group := range pw.groups {
+		t.begin(0)
+		t.list(1, thriftStruct, len(group.chunks))
+
+		for i, chunk := range group.chunks {
+			col := pw.cols[i]
+
+			typ := int32(parquetByteArray)
+			if col.Kind == Int {
+				typ = parquetInt64
+			}
+
+			t.begin(0)
+			t.i64(2, chunk.offset)
+			t.begin(3) // ColumnMetaData
+			t.i32(1, typ)
+			t.list(2, thriftI32, 2)
+			t.varint(zigzag(parquetPlain))
+			t.varint(zigzag(parquetRLE))
+			t.list(3, thriftBinary, 1)
+			t.str(col.Name)
+			t.i32(4, parquetUncompressed)
+			t.i64(5, group.rows)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.end()
+			t.end()
+		}
+
+		t.i64(2, group.size)
+		t.i64(3, group.rows)
+		t.end()
+	}
+
+	t.binary(6, "mylib")
+	t.end()
+
+	return t.Bytes()
+}
+
+func (pw *parquetWriter) write(p []byte) error {
+	n, err := pw.w.Write(p)
+	pw.offset += int64(n)
+
+	if err != nil {
+		return fmt.Errorf("error writing parquet: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,331 @@
+package tabular
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParquetRoundTrip(t *testing.T) {
+	cols := []Column{{Name: "title", Kind: String}, {Name: "year", Kind: Int}}
+
+	// One row more than row group holds, so that two groups are written.
+	rows := make([][]any, parquetGroupRows+1)
+	for i := range rows {
+		rows[i] = []any{fmt.Sprintf("Книга %d", i), i - 5}
+	}
+
+	data := writeParquet(t, cols, rows)
+
+	if !bytes.HasPrefix(data, []byte(parquetMagic)) || !bytes.HasSuffix(data, []byte(parquetMagic)) {
+		t.Fatal("file is not framed by magic")
+	}
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := data[len(data)-8-footerLen : len(data)-8]
+
+	meta, n := decodeStruct(t, footer)
+	if n != len(footer) {
+		t.Fatalf("footer decoded %d of %d bytes", n, len(footer))
+	}
+
+	if meta[1] != int64(1) || meta[3] != int64(len(rows)) {
+		t.Errorf("version %v and rows %v, want 1 and %d", meta[1], meta[3], len(rows))
+	}
+
+	schema := meta[2].([]any)
+	if len(schema) != len(cols)+1 {
+		t.Fatalf("got %d schema elements, want %d", len(schema), len(cols)+1)
+	}
+
+	if root := schema[0].(map[int16]any); root[5] != int64(len(cols)) {
+		t.Errorf("root has %v children, want %d", root[5], len(cols))
+	}
+
+	for i, want := range []struct {
+		name string
+		typ  int64
+	}{{"title", parquetByteArray}, {"year", parquetInt64}} {
+		el := schema[i+1].(map[int16]any)
+		if string(el[4].([]byte)) != want.name || el[1] != want.typ || el[3] != int64(parquetRequired) {
+			t.Errorf("schema element %d is %v, want %s of type %d", i+1, el, want.name, want.typ)
+		}
+	}
+
+	var got [][]any
+
+	for _, g := range meta[4].([]any) {
+		group := g.(map[int16]any)
+		groupRows := int(group[3].(int64))
+		values := make([][]any, len(cols))
+
+		var size int64
+
+		for i, c := range group[1].([]any) {
+			chunk := c.(map[int16]any)
+			cm := chunk[3].(map[int16]any)
+			offset := cm[9].(int64)
+
+			if chunk[2] != offset || cm[5] != int64(groupRows) || cm[4] != int64(parquetUncompressed) {
+				t.Fatalf("column chunk %d metadata is %v", i, cm)
+			}
+
+			header, hn := decodeStruct(t, data[offset:])
+			page := header[5].(map[int16]any)
+			pageSize := int(header[2].(int64))
+
+			if header[1] != int64(parquetDataPage) || header[3] != header[2] || page[1] != int64(groupRows) {
+				t.Fatalf("page header of chunk %d is %v", i, header)
+			}
+
+			if cm[6] != int64(hn+pageSize) {
+				t.Errorf("chunk %d size is %v, want %d", i, cm[6], hn+pageSize)
+			}
+
+			size += cm[6].(int64)
+			values[i] = decodePlain(t, cols[i].Kind, data[int(offset)+hn:int(offset)+hn+pageSize], groupRows)
+		}
+
+		if group[2] != size {
+			t.Errorf("group size is %v, want %d", group[2], size)
+		}
+
+		for r := 0; r < groupRows; r++ {
+			got = append(got, []any{values[0][r], values[1][r]})
+		}
+	}
+
+	if len(got) != len(rows) {
+		t.Fatalf("decoded %d rows, want %d", len(got), len(rows))
+	}
+
+	for i := range rows {
+		if got[i][0] != rows[i][0] || got[i][1] != int64(rows[i][1].(int)) {
+			t.Fatalf("row %d is %v, want %v", i, got[i], rows[i])
+		}
+	}
+}
+
+func TestParquetFixture(t *testing.T) {
+	cols, rows := fixture()
+	compareFixture(t, "testdata/books.parquet", writeParquet(t, cols, rows))
+}
+
+const pyarrowScript = `
+import json, sys
+try:
+    import pyarrow.parquet as pq
+except ImportError:
+    sys.exit(3)
+table = pq.read_table(sys.argv[1])
+json.dump({
+    "types": [str(field.type) for field in table.schema],
+    "rows": [table.column_names] + [list(row.values()) for row in table.to_pylist()],
+}, sys.stdout)
+`
+
+// TestParquetFixtureReader checks fixture with pyarrow, which shares no code with writer.
+func TestParquetFixtureReader(t *testing.T) {
+	const name = "testdata/books.parquet"
+
+	var got struct {
+		Types []string
+		Rows  [][]any
+	}
+
+	readExternal(t, pyarrowScript, name, &got)
+
+	if want := []string{"string", "string", "int64", "int64"}; !reflect.DeepEqual(got.Types, want) {
+		t.Errorf("%s has types %q, want %q", name, got.Types, want)
+	}
+
+	compareValues(t, name, got.Rows)
+}
+
+func writeParquet(t *testing.T, cols []Column, rows [][]any) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w, err := NewWriter(FormatParquet, &buf, cols)
+	if err != nil {
+		t.Fatalf("creating writer: %v", err)
+	}
+
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("writing row: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("closing writer: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func decodePlain(t *testing.T, kind Kind, page []byte, n int) []any {
+	t.Helper()
+
+	values := make([]any, 0, n)
+
+	for len(values) < n {
+		if kind == Int {
+			values = append(values, int64(binary.LittleEndian.Uint64(page)))
+			page = page[8:]
+
+			continue
+		}
+
+		size := int(binary.LittleEndian.Uint32(page))
+		values = append(values, string(page[4:4+size]))
+		page = page[4+size:]
+	}
+
+	if len(page) != 0 {
+		t.Fatalf("%d bytes left in page", len(page))
+	}
+
+	return values
+}
+
+// decodeStruct decodes Thrift compact struct into values by field id, independently of thriftWriter.
+// Integers are returned as int64, binaries as []byte, lists as []any, structs as map[int16]any.
+func decodeStruct(t *testing.T, data []byte) (map[int16]any, int) {
+	t.Helper()
+
+	d := thriftDecoder{data: data}
+
+	val, err := d.structure()
+	if err != nil {
+		t.Fatalf("decoding thrift: %v", err)
+	}
+
+	return val, d.pos
+}
+
+type thriftDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *thriftDecoder) structure() (map[int16]any, error) {
+	fields := make(map[int16]any)
+
+	var last int16
+
+	for {
+		b, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+
+		if b == 0 {
+			return fields, nil
+		}
+
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			v, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+
+			id = int16(unzigzag(v))
+		}
+
+		val, err := d.value(b & 0x0F)
+		if err != nil {
+			return nil, fmt.Errorf("field %d: %w", id, err)
+		}
+
+		fields[id] = val
+		last = id
+	}
+}
+
+func (d *thriftDecoder) value(typ byte) (any, error) {
+	switch typ {
+	case 1, 2:
+		return typ == 1, nil
+	case 3:
+		b, err := d.byte()
+		return int64(int8(b)), err
+	case 4, thriftI32, thriftI64:
+		v, err := d.varint()
+		return unzigzag(v), err
+	case thriftBinary:
+		n, err := d.varint()
+		if err != nil {
+			return nil, err
+		}
+
+		if d.pos+int(n) > len(d.data) {
+			return nil, fmt.Errorf("binary of %d bytes past end", n)
+		}
+
+		d.pos += int(n)
+
+		return d.data[d.pos-int(n) : d.pos], nil
+	case thriftList:
+		b, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+
+		size := uint64(b >> 4)
+		if size == 15 {
+			if size, err = d.varint(); err != nil {
+				return nil, err
+			}
+		}
+
+		list := make([]any, size)
+
+		for i := range list {
+			if list[i], err = d.value(b & 0x0F); err != nil {
+				return nil, err
+			}
+		}
+
+		return list, nil
+	case thriftStruct:
+		return d.structure()
+	default:
+		return nil, fmt.Errorf("unexpected type %d", typ)
+	}
+}
+
+func (d *thriftDecoder) byte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, fmt.Errorf("unexpected end at %d", d.pos)
+	}
+
+	d.pos++
+
+	return d.data[d.pos-1], nil
+}
+
+func (d *thriftDecoder) varint() (uint64, error) {
+	var v uint64
+
+	for shift := 0; ; shift += 7 {
+		b, err := d.byte()
+		if err != nil {
+			return 0, err
+		}
+
+		v |= uint64(b&0x7F) << shift
+
+		if b < 0x80 {
+			return v, nil
+		}
+	}
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
@@ -0,0 +1,101 @@
+// Package tabular streams rows into CSV, JSON Lines, XLSX and Parquet files.
+// Rows are written as they come, so memory used does not depend on number of rows,
+// except for Parquet which keeps one row group in memory.
+package tabular
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Formats supported by NewWriter.
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatXLSX    = "xlsx"
+	FormatParquet = "parquet"
+)
+
+// ContentTypes maps formats to media types.
+var ContentTypes = map[string]string{
+	FormatCSV:     "text/csv",
+	FormatJSONL:   "application/jsonl",
+	FormatXLSX:    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatParquet: "application/vnd.apache.parquet",
+}
+
+// Kind is type of column values.
+type Kind int
+
+const (
+	String Kind = iota
+	Int
+)
+
+// Column describes name and type of values in column.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Writer writes rows, values must follow order and kinds of columns.
+// Close must be called to complete file, it does not close underlying writer.
+type Writer interface {
+	Write(row []any) error
+	Close() error
+}
+
+// NewWriter returns Writer of given format.
+func NewWriter(format string, w io.Writer, cols []Column) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSV(w, cols)
+	case FormatJSONL:
+		return newJSONL(w, cols), nil
+	case FormatXLSX:
+		return newXLSX(w, cols)
+	case FormatParquet:
+		return newParquet(w, cols)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// text formats value of any kind.
+func text(val any) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// integer converts value of Int column.
+func integer(val any) (int64, error) {
+	switch v := val.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("expected integer, got %T", val)
+	}
+}
+
+func checkRow(cols []Column, row []any) error {
+	if len(row) != len(cols) {
+		return fmt.Errorf("expected %d values, got %d", len(cols), len(row))
+	}
+
+	return nil
+}
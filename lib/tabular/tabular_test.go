@@ -0,0 +1,115 @@
+package tabular
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite fixtures in testdata")
+
+// fixture returns columns and rows of files in testdata.
+func fixture() ([]Column, [][]any) {
+	cols := []Column{
+		{Name: "id", Kind: String},
+		{Name: "title", Kind: String},
+		{Name: "year", Kind: Int},
+		{Name: "size", Kind: Int},
+	}
+
+	rows := [][]any{
+		{"7d1c1e8e-5b0e-4f3a-9d3b-0c5a2f1e9b11", "Dune", 1965, 412},
+		{"0f3c5a7e-2d4b-4c6a-8e1f-3b5d7c9a1e22", "Кобзар", 1840, 114},
+		{"a1b2c3d4-e5f6-4a7b-8c9d-0e1f2a3b4c33", "Tom & Jerry <Annotated>", 2001, 0},
+	}
+
+	return cols, rows
+}
+
+// fixtureValues returns header and rows of fixture as they are decoded from JSON.
+func fixtureValues() [][]any {
+	cols, rows := fixture()
+
+	header := make([]any, len(cols))
+	for i, col := range cols {
+		header[i] = col.Name
+	}
+
+	values := [][]any{header}
+
+	for _, row := range rows {
+		vals := make([]any, len(row))
+
+		for i, val := range row {
+			if n, ok := val.(int); ok {
+				val = float64(n)
+			}
+
+			vals[i] = val
+		}
+
+		values = append(values, vals)
+	}
+
+	return values
+}
+
+// readExternal opens file with Python script, which exits with code 3 if its library is not installed,
+// and decodes JSON it prints into v. Test is skipped if reader is not available.
+func readExternal(t *testing.T, script, name string, v any) {
+	t.Helper()
+
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+
+	out, err := exec.Command("python3", "-c", script, name).Output()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 3 {
+		t.Skip("reader library is not installed")
+	}
+
+	if err != nil {
+		t.Fatalf("reading %s: %v", name, err)
+	}
+
+	if err := json.Unmarshal(out, v); err != nil {
+		t.Fatalf("decoding output of reader: %v", err)
+	}
+}
+
+func compareValues(t *testing.T, name string, got [][]any) {
+	t.Helper()
+
+	if want := fixtureValues(); !reflect.DeepEqual(got, want) {
+		t.Errorf("%s is read as %v, want %v", name, got, want)
+	}
+}
+
+// compareFixture checks that output equals fixture, fixtures are rewritten with -update.
+// Rewritten fixtures are checked with pyarrow and openpyxl by tests of readers,
+// which are skipped if these are not installed, so run them where they are before committing.
+func compareFixture(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	if *update {
+		if err := os.WriteFile(name, got, 0o644); err != nil {
+			t.Fatalf("writing fixture: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s, run with -update if change is intended and verified", name)
+	}
+}
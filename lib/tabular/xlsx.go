@@ -0,0 +1,170 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Static parts of minimal workbook with single sheet.
+const (
+	xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	xlsxRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+
+	xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	xlsxSheetStart = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd   = `</sheetData></worksheet>`
+)
+
+// xlsxWriter streams rows into the last zip entry, so that
+// workbook never has to be kept in memory. Strings are written inline
+// instead of shared strings table which would require knowing all of them upfront.
+type xlsxWriter struct {
+	cols  []Column
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	rows  int
+}
+
+func newXLSX(w io.Writer, cols []Column) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+
+	for _, part := range []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	} {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("error creating %s: %w", part.name, err)
+		}
+
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, fmt.Errorf("error writing %s: %w", part.name, err)
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("error creating sheet: %w", err)
+	}
+
+	xw := xlsxWriter{cols: cols, zw: zw, sheet: bufio.NewWriter(f)}
+
+	if _, err := xw.sheet.WriteString(xlsxSheetStart); err != nil {
+		return nil, fmt.Errorf("error writing sheet: %w", err)
+	}
+
+	header := make([]any, len(cols))
+	for i, col := range cols {
+		header[i] = col.Name
+	}
+
+	if err := xw.writeRow(header, true); err != nil {
+		return nil, fmt.Errorf("error writing header: %w", err)
+	}
+
+	return &xw, nil
+}
+
+func (xw *xlsxWriter) Write(row []any) error {
+	if err := checkRow(xw.cols, row); err != nil {
+		return err
+	}
+
+	return xw.writeRow(row, false)
+}
+
+func (xw *xlsxWriter) writeRow(row []any, header bool) error {
+	xw.rows++
+
+	var b strings.Builder
+
+	b.WriteString(`<row r="`)
+	b.WriteString(strconv.Itoa(xw.rows))
+	b.WriteString(`">`)
+
+	for i, val := range row {
+		ref := cellRef(i, xw.rows)
+
+		if !header && xw.cols[i].Kind == Int {
+			n, err := integer(val)
+			if err != nil {
+				return fmt.Errorf("column %s: %w", xw.cols[i].Name, err)
+			}
+
+			fmt.Fprintf(&b, `<c r="%s"><v>%d</v></c>`, ref, n)
+
+			continue
+		}
+
+		fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+
+		if err := xml.EscapeText(&b, []byte(cleanXML(text(val)))); err != nil {
+			return err
+		}
+
+		b.WriteString(`</t></is></c>`)
+	}
+
+	b.WriteString(`</row>`)
+
+	_, err := xw.sheet.WriteString(b.String())
+
+	return err
+}
+
+func (xw *xlsxWriter) Close() error {
+	if _, err := xw.sheet.WriteString(xlsxSheetEnd); err != nil {
+		return fmt.Errorf("error writing sheet: %w", err)
+	}
+
+	if err := xw.sheet.Flush(); err != nil {
+		return fmt.Errorf("error flushing sheet: %w", err)
+	}
+
+	return xw.zw.Close()
+}
+
+// cellRef returns A1 style reference of cell.
+func cellRef(col, row int) string {
+	var name []byte
+
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = append([]byte{byte('A' + (col-1)%26)}, name...)
+	}
+
+	return string(name) + strconv.Itoa(row)
+}
+
+// cleanXML drops control characters which are not allowed in XML 1.0.
+func cleanXML(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+
+		return r
+	}, s)
+}
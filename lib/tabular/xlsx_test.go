@@ -0,0 +1,190 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"path"
+	"testing"
+)
+
+type sheetXML struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string `xml:"r,attr"`
+			T      string `xml:"t,attr"`
+			V      string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	cols := []Column{{Name: "title", Kind: String}, {Name: "year", Kind: Int}}
+	rows := [][]any{
+		{"Dune & <Messiah>", 1965},
+		{"Кобзар\x01", -1840},
+		{"  spaced  ", int64(0)},
+	}
+
+	parts := readZip(t, writeXLSX(t, cols, rows))
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		data, ok := parts[name]
+		if !ok {
+			t.Fatalf("part %s is missing", name)
+		}
+
+		if err := wellFormed(data); err != nil {
+			t.Errorf("part %s is not well-formed: %v", name, err)
+		}
+	}
+
+	// Every relationship has to point to existing part.
+	for rels, dir := range map[string]string{"_rels/.rels": "", "xl/_rels/workbook.xml.rels": "xl"} {
+		var doc struct {
+			Relationships []struct {
+				Target string `xml:"Target,attr"`
+			} `xml:"Relationship"`
+		}
+
+		if err := xml.Unmarshal(parts[rels], &doc); err != nil {
+			t.Fatalf("decoding %s: %v", rels, err)
+		}
+
+		for _, rel := range doc.Relationships {
+			if _, ok := parts[path.Join(dir, rel.Target)]; !ok {
+				t.Errorf("%s points to missing part %s", rels, rel.Target)
+			}
+		}
+	}
+
+	var sheet sheetXML
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatalf("decoding sheet: %v", err)
+	}
+
+	want := [][]string{
+		{"A1", "inlineStr", "title", "B1", "inlineStr", "year"},
+		{"A2", "inlineStr", "Dune & <Messiah>", "B2", "", "1965"},
+		{"A3", "inlineStr", "Кобзар", "B3", "", "-1840"},
+		{"A4", "inlineStr", "  spaced  ", "B4", "", "0"},
+	}
+
+	if len(sheet.Rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(sheet.Rows), len(want))
+	}
+
+	for i, row := range sheet.Rows {
+		if row.R != i+1 || len(row.Cells) != len(cols) {
+			t.Fatalf("row %d is %+v", i+1, row)
+		}
+
+		for j, c := range row.Cells {
+			val := c.V
+			if c.T == "inlineStr" {
+				val = c.Inline
+			}
+
+			if got := []string{c.R, c.T, val}; got[0] != want[i][3*j] || got[1] != want[i][3*j+1] || got[2] != want[i][3*j+2] {
+				t.Errorf("cell %s is %q, want %q", c.R, got, want[i][3*j:3*j+3])
+			}
+		}
+	}
+}
+
+func TestXLSXFixture(t *testing.T) {
+	cols, rows := fixture()
+	compareFixture(t, "testdata/books.xlsx", writeXLSX(t, cols, rows))
+}
+
+const openpyxlScript = `
+import json, sys
+try:
+    import openpyxl
+except ImportError:
+    sys.exit(3)
+sheet = openpyxl.load_workbook(sys.argv[1], read_only=True).active
+json.dump([list(row) for row in sheet.iter_rows(values_only=True)], sys.stdout)
+`
+
+// TestXLSXFixtureReader checks fixture with openpyxl, which shares no code with writer.
+func TestXLSXFixtureReader(t *testing.T) {
+	const name = "testdata/books.xlsx"
+
+	var got [][]any
+
+	readExternal(t, openpyxlScript, name, &got)
+	compareValues(t, name, got)
+}
+
+func TestCellRef(t *testing.T) {
+	for col, want := range map[int]string{0: "A1", 25: "Z1", 26: "AA1", 701: "ZZ1", 702: "AAA1"} {
+		if got := cellRef(col, 1); got != want {
+			t.Errorf("cellRef(%d, 1) = %s, want %s", col, got, want)
+		}
+	}
+}
+
+func writeXLSX(t *testing.T, cols []Column, rows [][]any) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w, err := NewWriter(FormatXLSX, &buf, cols)
+	if err != nil {
+		t.Fatalf("creating writer: %v", err)
+	}
+
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("writing row: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("closing writer: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("opening zip: %v", err)
+	}
+
+	parts := make(map[string][]byte, len(zr.File))
+
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", f.Name, err)
+		}
+
+		if parts[f.Name], err = io.ReadAll(rc); err != nil {
+			t.Fatalf("reading %s: %v", f.Name, err)
+		}
+
+		rc.Close()
+	}
+
+	return parts
+}
+
+func wellFormed(data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
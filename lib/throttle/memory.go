@@ -0,0 +1,74 @@
+// Package throttle implements in-memory token bucket rate limiter
+// suitable for single node deployments.
+package throttle
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/delveper/mylib/app/models"
+)
+
+// pruneEvery sets how many calls pass between removing idle buckets.
+const pruneEvery = 1024
+
+type bucket struct {
+	tokens float64
+	ts     time.Time
+	period time.Duration
+}
+
+// Memory keeps token buckets in process memory.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes cost tokens from bucket of given key if there are enough of them.
+func (m *Memory) Allow(_ context.Context, key string, quota models.Quota, cost int) (models.RateLimit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	if m.calls++; m.calls%pruneEvery == 0 {
+		m.prune(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(quota.Limit), ts: now}
+		m.buckets[key] = b
+	}
+
+	b.period = quota.Period
+	b.tokens = math.Min(float64(quota.Limit), b.tokens+now.Sub(b.ts).Seconds()*quota.Rate())
+	b.ts = now
+
+	allowed := b.tokens >= float64(cost)
+	if allowed {
+		b.tokens -= float64(cost)
+	}
+
+	return quota.Result(b.tokens, cost, allowed), nil
+}
+
+// prune removes buckets that were refilled completely.
+func (m *Memory) prune(now time.Time) {
+	for key, b := range m.buckets {
+		if now.Sub(b.ts) > b.period {
+			delete(m.buckets, key)
+		}
+	}
+}
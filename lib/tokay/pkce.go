@@ -0,0 +1,53 @@
+package tokay
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+)
+
+const (
+	MethodPlain = "plain"
+	MethodS256  = "S256"
+)
+
+// verifierPattern is taken from RFC 7636 section 4.1.
+var verifierPattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// VerifyPKCE checks code verifier against code challenge made by given method.
+func VerifyPKCE(verifier, challenge, method string) error {
+	if !verifierPattern.MatchString(verifier) {
+		return fmt.Errorf("malformed code verifier")
+	}
+
+	var expected string
+
+	switch method {
+	case MethodS256:
+		sum := sha256.Sum256([]byte(verifier))
+		expected = base64.RawURLEncoding.EncodeToString(sum[:])
+	case MethodPlain, "":
+		expected = verifier
+	default:
+		return fmt.Errorf("unsupported code challenge method: %s", method)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) != 1 {
+		return fmt.Errorf("code verifier does not match challenge")
+	}
+
+	return nil
+}
+
+// Random generates URL safe random string of n bytes of entropy.
+func Random(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error reading random bytes: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,96 @@
+package tracer
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryTracer returns pgx tracer starting span for every query.
+// Since database/sql passes context down to driver, spans are children of caller span.
+func QueryTracer() pgx.QueryTracer {
+	return queryTracer{}
+}
+
+type queryTracer struct{}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = Start(ctx, "psql "+operation(data.SQL),
+		semconv.DBSystemPostgreSQL,
+		semconv.DBStatementKey.String(data.SQL),
+	)
+
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	Fail(span, data.Err)
+	span.End()
+}
+
+// operation returns first keyword of SQL statement.
+func operation(sql string) string {
+	if fields := strings.Fields(sql); len(fields) > 0 {
+		return strings.ToUpper(fields[0])
+	}
+
+	return "query"
+}
+
+// RedisHook returns hook starting span for every redis command.
+func RedisHook() redis.Hook {
+	return redisHook{}
+}
+
+type redisHook struct{}
+
+func (redisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = Start(ctx, "redis "+cmd.Name(),
+		semconv.DBSystemRedis,
+		semconv.DBOperationKey.String(cmd.Name()),
+	)
+
+	return ctx, nil
+}
+
+func (redisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	end(ctx, cmd.Err())
+	return nil
+}
+
+func (redisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	ctx, _ = Start(ctx, "redis pipeline",
+		semconv.DBSystemRedis,
+		semconv.DBOperationKey.String("pipeline"),
+	)
+
+	return ctx, nil
+}
+
+func (redisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			end(ctx, err)
+			return nil
+		}
+	}
+
+	end(ctx, nil)
+
+	return nil
+}
+
+// end finishes span in ctx. Missing keys are not failures.
+func end(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil && err != redis.Nil {
+		Fail(span, err)
+	}
+
+	span.End()
+}
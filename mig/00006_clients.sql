@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE clients
+(
+    id            UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    secret        CHAR(60)                    DEFAULT NULL,
+    name          VARCHAR(255) NOT NULL,
+    redirect_uris TEXT[]       NOT NULL       DEFAULT '{}',
+    scopes        TEXT[]       NOT NULL       DEFAULT '{}',
+    grant_types   TEXT[]       NOT NULL       DEFAULT '{}',
+    confidential  BOOLEAN      NOT NULL       DEFAULT FALSE,
+    created_at    TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE clients CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE books
+    ADD COLUMN isbn TEXT UNIQUE CHECK (isbn ~ '^(97[89][0-9]{10}|[0-9]{9}[0-9X])$');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE books
+    DROP COLUMN isbn;
+-- +goose StatementEnd
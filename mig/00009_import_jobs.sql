@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE import_jobs
+(
+    id          UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    created_by  UUID        REFERENCES readers (id) ON DELETE SET NULL,
+    status      TEXT        NOT NULL,
+    policy      TEXT        NOT NULL,
+    dry_run     BOOLEAN     NOT NULL DEFAULT FALSE,
+    total       INTEGER     NOT NULL DEFAULT 0,
+    imported    INTEGER     NOT NULL DEFAULT 0,
+    failed      INTEGER     NOT NULL DEFAULT 0,
+    errors      JSONB       NOT NULL DEFAULT '[]',
+    message     TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    finished_at TIMESTAMP WITHOUT TIME ZONE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE import_jobs;
+-- +goose StatementEnd
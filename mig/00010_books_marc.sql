@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE books
+    ADD COLUMN subjects TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN marc     JSONB;
+
+CREATE INDEX IF NOT EXISTS books_subjects_idx ON books USING GIN(subjects);
+
+CREATE INDEX IF NOT EXISTS authors_name_idx ON authors (LOWER(last_name), LOWER(first_name));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS authors_name_idx;
+
+DROP INDEX IF EXISTS books_subjects_idx;
+
+ALTER TABLE books
+    DROP COLUMN marc,
+    DROP COLUMN subjects;
+-- +goose StatementEnd
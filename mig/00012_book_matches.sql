@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE books
+    ADD COLUMN covers INTEGER[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE book_matches
+(
+    id          UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    book_id     UUID        NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    source      TEXT        NOT NULL,
+    edition_key TEXT        NOT NULL,
+    work_key    TEXT        NOT NULL DEFAULT '',
+    method      TEXT        NOT NULL,
+    confidence  NUMERIC     NOT NULL,
+    fields      JSONB       NOT NULL DEFAULT '{}',
+    applied     BOOLEAN     NOT NULL DEFAULT FALSE,
+    status      TEXT        NOT NULL DEFAULT 'pending',
+    created_at  TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    UNIQUE (book_id, source, edition_key)
+);
+
+CREATE INDEX IF NOT EXISTS book_matches_status_idx ON book_matches (status, confidence);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE book_matches;
+
+ALTER TABLE books
+    DROP COLUMN covers;
+-- +goose StatementEnd
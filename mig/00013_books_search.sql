@@ -0,0 +1,74 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE books
+    ADD COLUMN search TSVECTOR NOT NULL DEFAULT '';
+
+-- Search document of book weighs title above names of author, genre and subjects.
+-- Author is looked up, so document is refreshed by trigger on authors as well.
+CREATE OR REPLACE FUNCTION books_search() RETURNS TRIGGER AS
+$$
+BEGIN
+    NEW.search :=
+                SETWEIGHT(TO_TSVECTOR('english', NEW.title), 'A') ||
+                SETWEIGHT(TO_TSVECTOR('english', COALESCE(
+                        (SELECT first_name || ' ' || last_name FROM authors WHERE id = NEW.author_id), '')), 'B') ||
+                SETWEIGHT(TO_TSVECTOR('english', NEW.genre), 'C') ||
+                SETWEIGHT(TO_TSVECTOR('english', ARRAY_TO_STRING(NEW.subjects, ' ')), 'D');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER books_search_trg
+    BEFORE INSERT OR UPDATE OF title, author_id, genre, subjects
+    ON books
+    FOR EACH ROW
+EXECUTE FUNCTION books_search();
+
+CREATE OR REPLACE FUNCTION authors_search() RETURNS TRIGGER AS
+$$
+BEGIN
+    UPDATE books SET author_id = author_id WHERE author_id = NEW.id;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER authors_search_trg
+    AFTER UPDATE OF first_name, last_name
+    ON authors
+    FOR EACH ROW
+EXECUTE FUNCTION authors_search();
+
+UPDATE books SET title = title;
+
+DROP INDEX IF EXISTS books_title_idx;
+
+CREATE INDEX IF NOT EXISTS books_search_idx ON books USING GIN(search);
+
+CREATE INDEX IF NOT EXISTS books_title_trgm_idx ON books USING GIN(title gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS authors_name_trgm_idx ON authors USING GIN((first_name || ' ' || last_name) gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS authors_name_trgm_idx;
+
+DROP INDEX IF EXISTS books_title_trgm_idx;
+
+DROP INDEX IF EXISTS books_search_idx;
+
+CREATE INDEX IF NOT EXISTS books_title_idx ON books USING GIN(to_tsvector('simple', title));
+
+DROP TRIGGER IF EXISTS authors_search_trg ON authors;
+
+DROP FUNCTION IF EXISTS authors_search();
+
+DROP TRIGGER IF EXISTS books_search_trg ON books;
+
+DROP FUNCTION IF EXISTS books_search();
+
+ALTER TABLE books
+    DROP COLUMN search;
+-- +goose StatementEnd
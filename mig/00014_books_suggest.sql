@@ -0,0 +1,69 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- suggest_key folds text for prefix matching regardless of case and diacritics.
+-- Dictionary is given explicitly, so that function is immutable and can be indexed.
+CREATE OR REPLACE FUNCTION suggest_key(TEXT) RETURNS TEXT AS
+$$
+SELECT LOWER(public.unaccent('public.unaccent'::REGDICTIONARY, $1))
+$$ LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE;
+
+-- Popularity of book counts readers having it in favorites or wishlist.
+ALTER TABLE books
+    ADD COLUMN popularity INTEGER NOT NULL DEFAULT 0;
+
+UPDATE books
+SET popularity = (SELECT COUNT(*) FROM favorites WHERE favorites.book_id = books.id) +
+                 (SELECT COUNT(*) FROM wishlist WHERE wishlist.book_id = books.id);
+
+CREATE OR REPLACE FUNCTION books_popularity() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE books SET popularity = popularity + 1 WHERE id = NEW.book_id;
+    ELSE
+        UPDATE books SET popularity = popularity - 1 WHERE id = OLD.book_id;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER favorites_popularity_trg
+    AFTER INSERT OR DELETE
+    ON favorites
+    FOR EACH ROW
+EXECUTE FUNCTION books_popularity();
+
+CREATE TRIGGER wishlist_popularity_trg
+    AFTER INSERT OR DELETE
+    ON wishlist
+    FOR EACH ROW
+EXECUTE FUNCTION books_popularity();
+
+CREATE INDEX IF NOT EXISTS books_title_suggest_idx ON books USING GIN(suggest_key(title) gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS books_genre_suggest_idx ON books USING GIN(suggest_key(genre) gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS authors_name_suggest_idx ON authors USING GIN(suggest_key(first_name || ' ' || last_name) gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS authors_name_suggest_idx;
+
+DROP INDEX IF EXISTS books_genre_suggest_idx;
+
+DROP INDEX IF EXISTS books_title_suggest_idx;
+
+DROP TRIGGER IF EXISTS wishlist_popularity_trg ON wishlist;
+
+DROP TRIGGER IF EXISTS favorites_popularity_trg ON favorites;
+
+DROP FUNCTION IF EXISTS books_popularity();
+
+ALTER TABLE books
+    DROP COLUMN popularity;
+
+DROP FUNCTION IF EXISTS suggest_key(TEXT);
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE book_similarity
+(
+    book_id    UUID    NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    similar_id UUID    NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    score      REAL    NOT NULL,
+    shared     INTEGER NOT NULL,
+    PRIMARY KEY (book_id, similar_id)
+);
+
+CREATE INDEX IF NOT EXISTS favorites_book_id_idx ON favorites (book_id);
+
+CREATE INDEX IF NOT EXISTS wishlist_book_id_idx ON wishlist (book_id);
+
+CREATE INDEX IF NOT EXISTS books_genre_idx ON books (genre);
+
+CREATE INDEX IF NOT EXISTS books_author_id_idx ON books (author_id);
+
+CREATE INDEX IF NOT EXISTS books_popularity_idx ON books (popularity DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS books_popularity_idx;
+
+DROP INDEX IF EXISTS books_author_id_idx;
+
+DROP INDEX IF EXISTS books_genre_idx;
+
+DROP INDEX IF EXISTS wishlist_book_id_idx;
+
+DROP INDEX IF EXISTS favorites_book_id_idx;
+
+DROP TABLE book_similarity;
+-- +goose StatementEnd
@@ -0,0 +1,162 @@
+-- +goose Up
+-- +goose StatementBegin
+-- genre_key folds name of genre, so that "SciFi", "sci-fi" and "Sci Fi" are the same genre.
+CREATE OR REPLACE FUNCTION genre_key(TEXT) RETURNS TEXT AS
+$$
+SELECT REGEXP_REPLACE(suggest_key($1), '[^[:alnum:]]+', '', 'g')
+$$ LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE;
+
+CREATE TABLE IF NOT EXISTS genres
+(
+    id        UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    parent_id UUID REFERENCES genres (id) ON DELETE SET NULL,
+    name      TEXT NOT NULL,
+    key       TEXT NOT NULL GENERATED ALWAYS AS (genre_key(name)) STORED UNIQUE,
+    CHECK (parent_id <> id)
+);
+
+CREATE INDEX IF NOT EXISTS genres_parent_id_idx ON genres (parent_id);
+
+-- Synonyms are curated by admins, every synonym stands for single genre.
+CREATE TABLE IF NOT EXISTS genre_synonyms
+(
+    genre_id UUID NOT NULL REFERENCES genres (id) ON DELETE CASCADE,
+    name     TEXT NOT NULL,
+    key      TEXT NOT NULL GENERATED ALWAYS AS (genre_key(name)) STORED UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS genre_synonyms_genre_id_idx ON genre_synonyms (genre_id);
+
+-- Seed taxonomy, so that common genres are nested and spelled the same.
+INSERT INTO genres (name)
+VALUES ('Fiction'),
+       ('Nonfiction'),
+       ('Poetry'),
+       ('Drama');
+
+INSERT INTO genres (parent_id, name)
+SELECT parent.id, child.name
+FROM (VALUES ('Fiction', 'Science Fiction'),
+             ('Fiction', 'Fantasy'),
+             ('Fiction', 'Mystery'),
+             ('Fiction', 'Thriller'),
+             ('Fiction', 'Romance'),
+             ('Fiction', 'Horror'),
+             ('Fiction', 'Historical Fiction'),
+             ('Fiction', 'Literary Fiction'),
+             ('Fiction', 'Young Adult'),
+             ('Fiction', 'Children''s'),
+             ('Nonfiction', 'Biography'),
+             ('Nonfiction', 'History'),
+             ('Nonfiction', 'Science'),
+             ('Nonfiction', 'Philosophy'),
+             ('Nonfiction', 'Self-Help')) AS child (parent, name)
+         JOIN genres parent ON parent.name = child.parent;
+
+INSERT INTO genre_synonyms (genre_id, name)
+SELECT genres.id, synonym.name
+FROM (VALUES ('Science Fiction', 'SciFi'),
+             ('Science Fiction', 'SF'),
+             ('Fantasy', 'Fantasy Fiction'),
+             ('Mystery', 'Detective'),
+             ('Mystery', 'Crime'),
+             ('Thriller', 'Suspense'),
+             ('Young Adult', 'YA'),
+             ('Children''s', 'Kids'),
+             ('Biography', 'Autobiography'),
+             ('Biography', 'Memoir')) AS synonym (genre, name)
+         JOIN genres ON genres.name = synonym.genre;
+
+-- genre_of looks genre up by its name or synonym.
+CREATE OR REPLACE FUNCTION genre_of(TEXT) RETURNS UUID AS
+$$
+SELECT id
+FROM genres
+WHERE key = genre_key($1)
+UNION ALL
+SELECT genre_id
+FROM genre_synonyms
+WHERE key = genre_key($1)
+LIMIT 1
+$$ LANGUAGE sql STABLE STRICT;
+
+-- genre_descendants returns names of genre and all genres beneath it, genre is given by name or synonym.
+CREATE OR REPLACE FUNCTION genre_descendants(TEXT) RETURNS SETOF TEXT AS
+$$
+WITH RECURSIVE tree AS (SELECT id, name
+                        FROM genres
+                        WHERE id = genre_of($1)
+                        UNION
+                        SELECT genres.id, genres.name
+                        FROM genres
+                                 JOIN tree ON genres.parent_id = tree.id)
+SELECT name
+FROM tree
+$$ LANGUAGE sql STABLE STRICT;
+
+ALTER TABLE books
+    ADD COLUMN genre_id UUID REFERENCES genres (id);
+
+-- Genre of book is resolved to taxonomy, unknown one becomes new top level genre,
+-- so that genre of book is always spelled as its genre in taxonomy.
+CREATE OR REPLACE FUNCTION books_genre() RETURNS TRIGGER AS
+$$
+BEGIN
+    NEW.genre_id := genre_of(NEW.genre);
+
+    IF NEW.genre_id IS NULL THEN
+        INSERT INTO genres (name)
+        VALUES (NEW.genre)
+        ON CONFLICT (key) DO UPDATE SET name = genres.name
+        RETURNING id INTO NEW.genre_id;
+    END IF;
+
+    NEW.genre := (SELECT name FROM genres WHERE id = NEW.genre_id);
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+-- Name of trigger sorts before books_search_trg, so that search document has resolved genre.
+CREATE TRIGGER books_genre_trg
+    BEFORE INSERT OR UPDATE OF genre
+    ON books
+    FOR EACH ROW
+EXECUTE FUNCTION books_genre();
+
+-- Existing genres are spelled as the most frequent spelling of the same key.
+INSERT INTO genres (name)
+SELECT DISTINCT ON (genre_key(genre)) genre
+FROM books
+WHERE genre_of(genre) IS NULL
+GROUP BY genre
+ORDER BY genre_key(genre), COUNT(*) DESC, genre;
+
+UPDATE books SET genre = genre;
+
+ALTER TABLE books
+    ALTER COLUMN genre_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS books_genre_id_idx ON books (genre_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS books_genre_id_idx;
+
+DROP TRIGGER IF EXISTS books_genre_trg ON books;
+
+DROP FUNCTION IF EXISTS books_genre();
+
+ALTER TABLE books
+    DROP COLUMN genre_id;
+
+DROP FUNCTION IF EXISTS genre_descendants(TEXT);
+
+DROP FUNCTION IF EXISTS genre_of(TEXT);
+
+DROP TABLE IF EXISTS genre_synonyms;
+
+DROP TABLE IF EXISTS genres;
+
+DROP FUNCTION IF EXISTS genre_key(TEXT);
+-- +goose StatementEnd
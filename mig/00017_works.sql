@@ -0,0 +1,209 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS series
+(
+    id         UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    title      TEXT NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+-- Work is book as it is written, books are its editions. Work in series has its number,
+-- which is not necessarily integer, e.g. 1.5 for novella between the first and the second volume.
+CREATE TABLE IF NOT EXISTS works
+(
+    id            UUID PRIMARY KEY DEFAULT GEN_RANDOM_UUID(),
+    author_id     UUID REFERENCES authors (id) ON DELETE SET NULL,
+    title         TEXT NOT NULL,
+    series_id     UUID REFERENCES series (id) ON DELETE SET NULL,
+    series_number NUMERIC(8, 2) CHECK (series_number > 0),
+    created_at    TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    UNIQUE (series_id, series_number)
+);
+
+CREATE INDEX IF NOT EXISTS works_title_author_id_idx ON works (title, author_id);
+
+-- Every book becomes single edition of its own work, work shares ID with it.
+INSERT INTO works (id, author_id, title)
+SELECT id, author_id, title
+FROM books;
+
+ALTER TABLE books
+    ADD COLUMN work_id   UUID REFERENCES works (id),
+    ADD COLUMN publisher TEXT NOT NULL DEFAULT '',
+    ADD COLUMN language  TEXT NOT NULL DEFAULT '' CHECK (language ~ '^([a-z]{2,3})?$'),
+    ADD COLUMN format    TEXT NOT NULL DEFAULT '' CHECK (format IN ('', 'hardcover', 'paperback', 'ebook', 'audiobook'));
+
+UPDATE books SET work_id = id;
+
+-- Title is shared by editions and by volumes of series, editions without ISBN are told apart by the rest.
+ALTER TABLE books
+    ALTER COLUMN work_id SET NOT NULL,
+    DROP CONSTRAINT IF EXISTS books_title_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS books_edition_key ON books (work_id, publisher, year, language, format) WHERE isbn IS NULL;
+
+-- latest_edition returns the latest edition of work, it stands for work where one book per work is listed.
+CREATE OR REPLACE FUNCTION latest_edition(UUID) RETURNS UUID AS
+$$
+SELECT id
+FROM books
+WHERE work_id = $1
+ORDER BY year DESC, id
+LIMIT 1
+$$ LANGUAGE sql STABLE STRICT;
+
+-- Readers save works, edition is kept if reader saved particular one.
+ALTER TABLE favorites
+    ADD COLUMN work_id UUID REFERENCES works (id) ON DELETE CASCADE;
+
+ALTER TABLE wishlist
+    ADD COLUMN work_id UUID REFERENCES works (id) ON DELETE CASCADE;
+
+UPDATE favorites SET work_id = book_id;
+
+UPDATE wishlist SET work_id = book_id;
+
+ALTER TABLE favorites
+    ALTER COLUMN work_id SET NOT NULL,
+    ALTER COLUMN book_id DROP NOT NULL,
+    DROP CONSTRAINT IF EXISTS favorites_reader_id_book_id_key,
+    ADD CONSTRAINT favorites_reader_id_work_id_key UNIQUE (reader_id, work_id);
+
+ALTER TABLE wishlist
+    ALTER COLUMN work_id SET NOT NULL,
+    ALTER COLUMN book_id DROP NOT NULL,
+    DROP CONSTRAINT IF EXISTS wishlist_reader_id_book_id_key,
+    ADD CONSTRAINT wishlist_reader_id_work_id_key UNIQUE (reader_id, work_id);
+
+CREATE INDEX IF NOT EXISTS favorites_work_id_idx ON favorites (work_id);
+
+CREATE INDEX IF NOT EXISTS wishlist_work_id_idx ON wishlist (work_id);
+
+-- Popularity of work is kept by every its edition.
+CREATE OR REPLACE FUNCTION books_popularity() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE books SET popularity = popularity + 1 WHERE work_id = NEW.work_id;
+    ELSE
+        UPDATE books SET popularity = popularity - 1 WHERE work_id = OLD.work_id;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+-- Book without work is edition of the first work of the same author and title, new work is created if there is none.
+CREATE OR REPLACE FUNCTION books_work() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF NEW.work_id IS NULL THEN
+        NEW.work_id := (SELECT id
+                        FROM works
+                        WHERE author_id IS NOT DISTINCT FROM NEW.author_id
+                          AND title = NEW.title
+                        ORDER BY created_at, id
+                        LIMIT 1);
+    END IF;
+
+    IF NEW.work_id IS NULL THEN
+        INSERT INTO works (author_id, title) VALUES (NEW.author_id, NEW.title) RETURNING id INTO NEW.work_id;
+    END IF;
+
+    NEW.popularity := (SELECT COUNT(*) FROM favorites WHERE work_id = NEW.work_id) +
+                      (SELECT COUNT(*) FROM wishlist WHERE work_id = NEW.work_id);
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER books_work_trg
+    BEFORE INSERT
+    ON books
+    FOR EACH ROW
+EXECUTE FUNCTION books_work();
+
+-- Readers save works, so that similarity is of works as well.
+CREATE TABLE work_similarity
+(
+    work_id    UUID    NOT NULL REFERENCES works (id) ON DELETE CASCADE,
+    similar_id UUID    NOT NULL REFERENCES works (id) ON DELETE CASCADE,
+    score      REAL    NOT NULL,
+    shared     INTEGER NOT NULL,
+    PRIMARY KEY (work_id, similar_id)
+);
+
+INSERT INTO work_similarity (work_id, similar_id, score, shared)
+SELECT book_id, similar_id, score, shared
+FROM book_similarity;
+
+DROP TABLE book_similarity;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE book_similarity
+(
+    book_id    UUID    NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    similar_id UUID    NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    score      REAL    NOT NULL,
+    shared     INTEGER NOT NULL,
+    PRIMARY KEY (book_id, similar_id)
+);
+
+DROP TABLE IF EXISTS work_similarity;
+
+DROP TRIGGER IF EXISTS books_work_trg ON books;
+
+DROP FUNCTION IF EXISTS books_work();
+
+CREATE OR REPLACE FUNCTION books_popularity() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE books SET popularity = popularity + 1 WHERE id = NEW.book_id;
+    ELSE
+        UPDATE books SET popularity = popularity - 1 WHERE id = OLD.book_id;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS wishlist_work_id_idx;
+
+DROP INDEX IF EXISTS favorites_work_id_idx;
+
+UPDATE favorites SET book_id = latest_edition(work_id) WHERE book_id IS NULL;
+
+UPDATE wishlist SET book_id = latest_edition(work_id) WHERE book_id IS NULL;
+
+ALTER TABLE wishlist
+    DROP CONSTRAINT IF EXISTS wishlist_reader_id_work_id_key,
+    ADD CONSTRAINT wishlist_reader_id_book_id_key UNIQUE (reader_id, book_id),
+    ALTER COLUMN book_id SET NOT NULL,
+    DROP COLUMN work_id;
+
+ALTER TABLE favorites
+    DROP CONSTRAINT IF EXISTS favorites_reader_id_work_id_key,
+    ADD CONSTRAINT favorites_reader_id_book_id_key UNIQUE (reader_id, book_id),
+    ALTER COLUMN book_id SET NOT NULL,
+    DROP COLUMN work_id;
+
+DROP FUNCTION IF EXISTS latest_edition(UUID);
+
+UPDATE books
+SET popularity = (SELECT COUNT(*) FROM favorites WHERE favorites.book_id = books.id) +
+                 (SELECT COUNT(*) FROM wishlist WHERE wishlist.book_id = books.id);
+
+DROP INDEX IF EXISTS books_edition_key;
+
+-- Fails if editions share title, they have to be told apart first.
+ALTER TABLE books
+    ADD CONSTRAINT books_title_key UNIQUE (title),
+    DROP COLUMN format,
+    DROP COLUMN language,
+    DROP COLUMN publisher,
+    DROP COLUMN work_id;
+
+DROP TABLE IF EXISTS works;
+
+DROP TABLE IF EXISTS series;
+-- +goose StatementEnd
@@ -1,52 +1,42 @@
 package mig
 
 import (
+	"context"
 	"database/sql"
 	"embed"
-	"errors"
 	"fmt"
-	"os"
+	"io/fs"
 
 	"github.com/delveper/mylib/app/models"
 	"github.com/pressly/goose/v3"
 )
 
 //go:embed *.sql
-var fs embed.FS
+var migrations embed.FS
 
 const root = "."
 
 type Migration struct{ *embed.FS }
 
 func New() Migration {
-	return Migration{&fs}
+	return Migration{&migrations}
 }
 
 func (m Migration) SetLogger(logger models.Logger) {
 	goose.SetLogger(logger)
 }
 
-func (m Migration) Run(db *sql.DB) error {
+func (m Migration) Run(db *sql.DB, dialect, direction string) error {
 	goose.SetBaseFS(m.FS)
 
 	defer func() {
 		goose.SetBaseFS(nil)
 	}()
 
-	d, ok := os.LookupEnv("DB_DIALECT")
-	if !ok {
-		return fmt.Errorf("there is no DB_DIALECT among environment variables")
-	}
-
-	if err := goose.SetDialect(d); err != nil {
+	if err := goose.SetDialect(dialect); err != nil {
 		return fmt.Errorf("error setting dialect: %w", err)
 	}
 
-	direction, ok := os.LookupEnv("DB_MIGRATE")
-	if !ok {
-		return errors.New("there no DB_MIGRATE above environment variables")
-	}
-
 	if err := goose.Fix(root); err != nil {
 		return fmt.Errorf("error during fixing migrations timestamps: %w", err)
 	}
@@ -66,3 +56,52 @@ func (m Migration) Run(db *sql.DB) error {
 
 	return nil
 }
+
+// Latest returns version of the latest embedded migration.
+func (m Migration) Latest() (int64, error) {
+	entries, err := fs.ReadDir(m.FS, root)
+	if err != nil {
+		return 0, fmt.Errorf("error reading embedded migrations: %w", err)
+	}
+
+	var latest int64
+
+	for _, entry := range entries {
+		version, err := goose.NumericComponent(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		if version > latest {
+			latest = version
+		}
+	}
+
+	return latest, nil
+}
+
+// Check returns error if version of applied migrations
+// does not match the latest embedded one.
+func (m Migration) Check(ctx context.Context, db *sql.DB) error {
+	const SQL = `SELECT version_id
+				 FROM goose_db_version
+				 WHERE is_applied
+				 ORDER BY id DESC
+				 LIMIT 1;`
+
+	latest, err := m.Latest()
+	if err != nil {
+		return err
+	}
+
+	var current int64
+	if err := db.QueryRowContext(ctx, SQL).Scan(&current); err != nil {
+		return fmt.Errorf("error fetching migration version: %w", err)
+	}
+
+	if current != latest {
+		return fmt.Errorf("migration version mismatch: applied %d, embedded %d", current, latest)
+	}
+
+	return nil
+}